@@ -2,22 +2,30 @@ package main
 
 import (
 	"context"
+	"flag"
+	"image"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	// Встроенная база часовых поясов для параметра tz статистики (в alpine образе ее нет)
+	_ "time/tzdata"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-redis/redis/v8"
 
 	"url-short/internal/config"
 	"url-short/internal/database"
 	"url-short/internal/handlers"
 	"url-short/internal/middleware"
+	"url-short/internal/models"
 	"url-short/internal/repository"
 	"url-short/internal/service"
+	"url-short/pkg/geoip"
+	"url-short/pkg/qrcode"
 	"url-short/pkg/shortener"
 )
 
@@ -61,53 +69,261 @@ func main() {
 	// Инициализируем repositories
 	urlRepo := repository.NewURLRepository(db)
 	analyticsRepo := repository.NewAnalyticsRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db)
+	redirectRuleRepo := repository.NewRedirectRuleRepository(db)
+	urlVariantRepo := repository.NewURLVariantRepository(db)
+	lockRepo := repository.NewLockRepository(db)
+
+	// Разовая очистка истекших ссылок по команде администратора: ./main janitor [-mode=...] [-grace-days=N]
+	if len(os.Args) > 1 && os.Args[1] == "janitor" {
+		if err := runJanitorCommand(os.Args[2:], cfg, urlRepo, lockRepo, redisClient); err != nil {
+			log.Fatalf("Ошибка очистки истекших ссылок: %v", err)
+		}
+		return
+	}
 
 	// Инициализируем services
 	generator := shortener.NewGenerator()
-	urlService := service.NewURLService(urlRepo, generator, redisClient, cfg.App.BaseURL, cfg.App.CacheTTL)
-	analyticsService := service.NewAnalyticsService(analyticsRepo, urlRepo)
+	webhookService := service.NewWebhookService(webhookRepo, webhookDeliveryRepo)
+	urlService := service.NewURLService(urlRepo, generator, redisClient, cfg.App.BaseURL, cfg.App.CacheTTL, cfg.App.ShortURLStyle, webhookService)
+	var qrLogo image.Image
+	if cfg.App.QRLogoPath != "" {
+		qrLogo, err = qrcode.LoadLogo(cfg.App.QRLogoPath)
+		if err != nil {
+			log.Fatalf("Ошибка загрузки логотипа QR: %v", err)
+		}
+	}
+	qrService := service.NewQRService(urlService, redisClient, cfg.App.CacheTTL, qrLogo)
+	geoLocator := geoip.NewNoopLocator()
+	if cfg.Analytics.GeoIPDBPath != "" {
+		geoLocator, err = geoip.OpenMaxMind(cfg.Analytics.GeoIPDBPath)
+		if err != nil {
+			log.Fatalf("Ошибка загрузки GeoIP базы: %v", err)
+		}
+		log.Println("✓ GeoIP база загружена")
+	}
+	defer geoLocator.Close() // nolint:errcheck
+	redirectRuleService := service.NewRedirectRuleService(redirectRuleRepo, urlRepo, geoLocator, redisClient, cfg.App.CacheTTL)
+	variantService := service.NewVariantService(urlVariantRepo, urlRepo, redisClient, cfg.App.CacheTTL)
+
+	// Поток кликов в реальном времени (SSE) через Redis pub/sub
+	clickStream := service.NewClickStream(redisClient)
+	clickStream.Start()
+
+	clickPipeline := service.NewClickPipeline(analyticsRepo, service.ClickPipelineConfig{
+		QueueSize:     cfg.Analytics.QueueSize,
+		Workers:       cfg.Analytics.Workers,
+		BatchSize:     cfg.Analytics.BatchSize,
+		FlushInterval: cfg.Analytics.GetFlushInterval(),
+		Publisher:     clickStream,
+		CountObserver: webhookService,
+	}, service.NewGeoIPEnricher(geoLocator), service.NewUserAgentEnricher())
+	clickPipeline.Start()
+	analyticsService := service.NewAnalyticsService(analyticsRepo, urlRepo, clickPipeline, clickStream)
+	authService := service.NewAuthService(userRepo, sessionRepo, cfg.App.SessionTTL)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+
+	// Отправка webhook из журнала доставок в фоне
+	webhookDispatcher := service.NewWebhookDispatcher(webhookDeliveryRepo, cfg.Webhook)
+	webhookDispatcher.Start()
+
+	// Периодическая проверка истекших ссылок для события link.expired
+	stopExpiryWatch := make(chan struct{})
+	go watchExpiredLinks(urlService, stopExpiryWatch)
+
+	// Очистка давно истекших ссылок по расписанию, один инстанс за раз
+	janitor, err := service.NewJanitor(urlRepo, lockRepo, redisClient, cfg.Janitor, cfg.App.GetCodeQuarantine())
+	if err != nil {
+		log.Fatalf("Ошибка настройки очистки истекших ссылок: %v", err)
+	}
+	janitor.Start()
+
+	// Корзина без срока хранения не очищается
+	stopTrashPurge := make(chan struct{})
+	if cfg.App.TrashRetentionDays > 0 {
+		go purgeTrash(urlService, cfg.App.GetTrashRetention(), cfg.App.GetCodeQuarantine(), stopTrashPurge)
+	}
 
 	// Инициализируем handlers
 	urlHandler := handlers.NewURLHandler(urlService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
-	redirectHandler := handlers.NewRedirectHandler(urlService, analyticsService)
+	authHandler := handlers.NewAuthHandler(authService, cfg.App.IsProduction())
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	qrHandler := handlers.NewQRHandler(qrService)
+	redirectRuleHandler := handlers.NewRedirectRuleHandler(redirectRuleService)
+	urlVariantHandler := handlers.NewURLVariantHandler(variantService)
+	janitorHandler := handlers.NewJanitorHandler(janitor)
+
+	// Rate limiting по клиенту, отдельные лимиты для создания, редиректа и статистики
+	rateLimiter := middleware.NewRateLimiter(redisClient)
+	rateWindow := cfg.RateLimit.GetWindow()
+	createLimit := rateLimiter.Limit("create", cfg.RateLimit.Create, rateWindow)
+	redirectLimit := rateLimiter.Limit("redirect", cfg.RateLimit.Redirect, rateWindow)
+	statsLimit := rateLimiter.Limit("stats", cfg.RateLimit.Stats, rateWindow)
+	authLimit := rateLimiter.Limit("auth", cfg.RateLimit.Auth, rateWindow)
+
+	// Редирект ссылок с паролем: попытки ввода ограничиваются отдельно на ссылку и клиента
+	redirectHandler := handlers.NewRedirectHandler(urlService, redirectRuleService, variantService, analyticsService, handlers.PasswordGate{
+		Unlocker:      service.NewLinkUnlocker(cfg.App.LinkUnlockSecret, cfg.App.LinkUnlockTTL),
+		Limiter:       rateLimiter,
+		Attempts:      cfg.RateLimit.LinkPassword,
+		LinkAttempts:  cfg.RateLimit.LinkPasswordPerLink,
+		Window:        cfg.RateLimit.GetLinkPasswordWindow(),
+		SecureCookies: cfg.App.IsProduction(),
+	}, cfg.App.InactiveLinkResponse)
+
+	// Создание ссылок: анонимно (если разрешено) или со scope links:write
+	createURLAuth := middleware.RequireScope(models.ScopeLinksWrite)
+	if cfg.App.AllowAnonymousLinks {
+		createURLAuth = middleware.OptionalScope(models.ScopeLinksWrite)
+	}
+
+	// Адрес клиента берется из заголовков прокси, только если запрос пришел от доверенного прокси
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
 
 	// Настраиваем роутер
 	r := chi.NewRouter()
 
 	// Middleware
 	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.RealIP(trustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(chimiddleware.Recoverer)
-	r.Use(chimiddleware.Timeout(60 * time.Second))
 
-	// Health check endpoint для Render
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// Обычные запросы ограничены по времени. Потоковые ответы (экспорт, SSE) регистрируются
+	// в отдельных группах без таймаута и завершаются при отключении клиента
+	requestTimeout := chimiddleware.Timeout(60 * time.Second)
 
-	// Статические файлы
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "static/index.html")
-	})
-	r.Get("/links", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "static/links.html")
+	// Сжатие потоковых выгрузок (gzip/deflate по Accept-Encoding)
+	compressExport := chimiddleware.Compress(5, "text/csv", "application/x-ndjson")
+
+	r.Group(func(r chi.Router) {
+		r.Use(requestTimeout)
+
+		// Health check endpoint для Render
+		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK"))
+		})
+
+		// Статические файлы
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, "static/index.html")
+		})
+		r.Get("/links", func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, "static/links.html")
+		})
+		r.Get("/login", func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, "static/login.html")
+		})
+		r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 	})
-	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Get("/urls", urlHandler.GetAllURLs)
-		r.Post("/urls", urlHandler.CreateShortURL)
-		r.Get("/urls/{id}", urlHandler.GetURL)
-		r.Delete("/urls/{id}", urlHandler.DeleteURL)
-		r.Get("/urls/{id}/stats", analyticsHandler.GetURLStats)
+		r.Use(middleware.Auth(authService, apiKeyService))
+
+		r.Group(func(r chi.Router) {
+			r.Use(requestTimeout)
+
+			r.With(authLimit).Post("/auth/register", authHandler.Register)
+			r.With(authLimit).Post("/auth/login", authHandler.Login)
+			r.Post("/auth/logout", authHandler.Logout)
+
+			// Ссылка получает владельца, если он известен
+			r.With(createURLAuth, createLimit).Post("/urls", urlHandler.CreateShortURL)
+
+			// Остальные операции только со своими ссылками
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireAuth)
+
+				r.Get("/auth/me", authHandler.Me)
+
+				r.Post("/api-keys", apiKeyHandler.CreateAPIKey)
+				r.Get("/api-keys", apiKeyHandler.ListAPIKeys)
+				r.Delete("/api-keys/{id}", apiKeyHandler.RevokeAPIKey)
+
+				r.With(middleware.RequireScope(models.ScopeLinksRead)).Get("/urls", urlHandler.GetAllURLs)
+				r.With(middleware.RequireScope(models.ScopeLinksRead)).Get("/tags", urlHandler.ListTags)
+				r.With(middleware.RequireScope(models.ScopeLinksRead)).Get("/folders", urlHandler.ListFolders)
+				r.With(middleware.RequireScope(models.ScopeLinksRead)).Get("/urls/trash", urlHandler.ListTrash)
+				r.With(middleware.RequireScope(models.ScopeLinksRead)).Get("/urls/{id}", urlHandler.GetURL)
+				r.With(middleware.RequireScope(models.ScopeLinksRead)).Get("/urls/{id}/qr", qrHandler.GetURLQRCode)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite), createLimit).Post("/urls/bulk", urlHandler.BulkCreateURLs)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Post("/urls/bulk/delete", urlHandler.BulkDeleteURLs)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Post("/urls/bulk/tags", urlHandler.BulkRetagURLs)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Post("/urls/bulk/expiry", urlHandler.BulkSetURLsExpiry)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Patch("/urls/{id}", urlHandler.UpdateURL)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Delete("/urls/{id}", urlHandler.DeleteURL)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Post("/urls/{id}/restore", urlHandler.RestoreURL)
+				r.With(middleware.RequireScope(models.ScopeAnalyticsRead), statsLimit).Get("/urls/{id}/stats", analyticsHandler.GetURLStats)
+				r.With(middleware.RequireScope(models.ScopeLinksRead)).Get("/urls/{id}/rules", redirectRuleHandler.ListRules)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Post("/urls/{id}/rules", redirectRuleHandler.CreateRule)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Patch("/urls/{id}/rules/{ruleID}", redirectRuleHandler.UpdateRule)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Delete("/urls/{id}/rules/{ruleID}", redirectRuleHandler.DeleteRule)
+				r.With(middleware.RequireScope(models.ScopeLinksRead)).Get("/urls/{id}/variants", urlVariantHandler.ListVariants)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Post("/urls/{id}/variants", urlVariantHandler.CreateVariant)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Patch("/urls/{id}/variants/{variantID}", urlVariantHandler.UpdateVariant)
+				r.With(middleware.RequireScope(models.ScopeLinksWrite)).Delete("/urls/{id}/variants/{variantID}", urlVariantHandler.DeleteVariant)
+
+				r.Route("/webhooks", func(r chi.Router) {
+					r.Use(middleware.RequireScope(models.ScopeWebhooks))
+
+					r.Post("/", webhookHandler.CreateWebhook)
+					r.Get("/", webhookHandler.ListWebhooks)
+					r.Get("/{id}", webhookHandler.GetWebhook)
+					r.Patch("/{id}", webhookHandler.UpdateWebhook)
+					r.Delete("/{id}", webhookHandler.DeleteWebhook)
+					r.Get("/{id}/deliveries", webhookHandler.ListDeliveries)
+					r.Post("/{id}/deliveries/{deliveryID}/replay", webhookHandler.ReplayDelivery)
+				})
+
+				r.With(middleware.RequireScope(models.ScopeAdmin)).Get("/admin/clicks", analyticsHandler.GetPipelineStats)
+				r.With(middleware.RequireScope(models.ScopeAdmin)).Get("/admin/janitor", janitorHandler.GetJanitorStats)
+			})
+		})
+
+		// Потоковые ответы без таймаута запроса
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireAuth)
+
+			r.With(middleware.RequireScope(models.ScopeAnalyticsRead), statsLimit, compressExport).Get("/urls/{id}/clicks/export", analyticsHandler.ExportClicks)
+			r.With(middleware.RequireScope(models.ScopeAnalyticsRead), statsLimit).Get("/urls/{id}/events", analyticsHandler.StreamClicks)
+			r.With(middleware.RequireScope(models.ScopeAdmin)).Get("/admin/events", analyticsHandler.StreamAllClicks)
+		})
 	})
 
-	// Redirect route - внутри /api/ namespace для обхода ограничений Render
-	r.Get("/api/r", redirectHandler.Redirect)
+	// Редиректы ставят клики в очередь: при остановке их нужно дождаться до остановки очереди
+	redirects := middleware.NewInFlight()
+
+	r.Group(func(r chi.Router) {
+		r.Use(redirects.Track)
+		r.Use(requestTimeout)
+		r.Use(redirectLimit)
+
+		// Redirect route - внутри /api/ namespace для обхода ограничений Render
+		r.Get("/api/r", redirectHandler.Redirect)
+		r.Head("/api/r", redirectHandler.Redirect)
+		r.Post("/api/r", redirectHandler.Redirect)
+
+		// QR код ссылки для печати, доступен всем, кто знает короткий код
+		r.Get("/qr/{code}", qrHandler.GetQRCode)
+		r.Head("/qr/{code}", qrHandler.GetQRCode)
+
+		// Короткие ссылки в корне (/{code}). Статические маршруты выше имеют приоритет в chi,
+		// а коды, совпадающие с ними, запрещены в shortener.IsReservedCode
+		r.Get("/{code}", redirectHandler.Redirect)
+		r.Head("/{code}", redirectHandler.Redirect)
+		r.Post("/{code}", redirectHandler.Redirect)
+	})
 
 	// Настраиваем сервер
 	server := &http.Server{
@@ -136,9 +352,106 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// SSE соединения не завершаются сами, и Shutdown ждал бы их до таймаута. Поток кликов
+	// закрывается в начале остановки, но после редиректов и дозаписи очереди: последние
+	// пачки еще доходят до подписчиков
+	server.RegisterOnShutdown(func() {
+		if err := redirects.Wait(ctx); err != nil {
+			log.Printf("Редиректы не завершены: %v", err)
+		}
+		clickPipeline.Shutdown(ctx) // nolint:errcheck // ошибку залогирует повторный вызов ниже
+		clickStream.Close()
+	})
+
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Ошибка остановки сервера: %v", err)
+		log.Printf("Ошибка остановки сервера: %v", err)
+	}
+
+	// Очередь дописывается в хуке остановки, здесь только ждем ее
+	if err := clickPipeline.Shutdown(ctx); err != nil {
+		log.Printf("Очередь кликов не дописана: %v", err)
+	}
+	stats := clickPipeline.Stats()
+	log.Printf("✓ Очередь кликов остановлена (записано %d, отброшено %d, ошибок %d)", stats.Recorded, stats.Dropped, stats.Failed)
+
+	// Неотправленные доставки останутся в журнале и уйдут после перезапуска
+	close(stopExpiryWatch)
+	close(stopTrashPurge)
+	if err := janitor.Shutdown(ctx); err != nil {
+		log.Printf("Очистка истекших ссылок не завершена: %v", err)
+	}
+	if err := webhookDispatcher.Shutdown(ctx); err != nil {
+		log.Printf("Отправка webhook не завершена: %v", err)
 	}
 
 	log.Println("✓ Сервер остановлен")
 }
+
+// watchExpiredLinks раз в минуту отправляет link.expired по истекшим ссылкам
+func watchExpiredLinks(urlService service.URLService, stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if _, err := urlService.NotifyExpiredLinks(ctx); err != nil {
+				log.Printf("Ошибка проверки истекших ссылок: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// purgeTrash раз в час окончательно удаляет ссылки, срок хранения которых в корзине истек
+func purgeTrash(urlService service.URLService, retention, quarantine time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			purged, err := urlService.PurgeDeletedLinks(ctx, retention, quarantine)
+			if err != nil {
+				log.Printf("Ошибка очистки корзины: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("Корзина очищена: удалено ссылок %d", purged)
+			}
+			cancel()
+		}
+	}
+}
+
+// runJanitorCommand выполняет один проход очистки истекших ссылок и завершается.
+// Флаги переопределяют JANITOR_MODE и JANITOR_GRACE_DAYS, остальное берется из конфигурации
+func runJanitorCommand(args []string, cfg *config.Config, urlRepo repository.URLRepository, locks repository.LockRepository, redisClient *redis.Client) error {
+	janitorCfg := cfg.Janitor
+	flags := flag.NewFlagSet("janitor", flag.ContinueOnError)
+	flags.StringVar(&janitorCfg.Mode, "mode", janitorCfg.Mode, "что делать со ссылками: archive, soft_delete или hard_delete")
+	flags.IntVar(&janitorCfg.GraceDays, "grace-days", janitorCfg.GraceDays, "сколько дней после истечения срока ссылка не трогается")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	janitor, err := service.NewJanitor(urlRepo, locks, redisClient, janitorCfg, cfg.App.GetCodeQuarantine())
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C прерывает проход: уже обработанные пачки сохраняются
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := janitor.RunOnce(ctx)
+	if report != nil {
+		log.Printf("✓ Очистка истекших ссылок (%s): обработано %d за %d мс", report.Mode, report.Processed, report.DurationMs)
+	}
+	return err
+}
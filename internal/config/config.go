@@ -4,22 +4,29 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config содержит всю конфигурацию приложения
 type Config struct {
-	Server   ServerConfig
-	Postgres PostgresConfig
-	Redis    RedisConfig
-	App      AppConfig
+	Server    ServerConfig
+	Postgres  PostgresConfig
+	Redis     RedisConfig
+	App       AppConfig
+	RateLimit RateLimitConfig
+	Analytics AnalyticsConfig
+	Webhook   WebhookConfig
+	Janitor   JanitorConfig
 }
 
 // ServerConfig настройки HTTP сервера
 type ServerConfig struct {
 	Host string
 	Port string
+	// TrustedProxies сети (CIDR) прокси, чьим заголовкам X-Forwarded-For и X-Real-IP можно верить
+	TrustedProxies string
 }
 
 // PostgresConfig настройки PostgreSQL
@@ -46,6 +53,71 @@ type AppConfig struct {
 	BaseURL         string
 	CacheTTL        int
 	Env             string
+	// ShortURLStyle формат коротких ссылок: "query" (/api/r?code={code}, по умолчанию,
+	// как до появления коротких путей) или "path" (/{code})
+	ShortURLStyle string
+	// SessionTTL время жизни сессии пользователя в секундах
+	SessionTTL int
+	// AllowAnonymousLinks разрешает создавать ссылки без аутентификации
+	AllowAnonymousLinks bool
+	// QRLogoPath путь к логотипу (PNG/JPEG) для QR кодов, пусто - без логотипа
+	QRLogoPath string
+	// LinkUnlockSecret ключ подписи cookie разблокировки ссылок с паролем
+	LinkUnlockSecret string
+	// LinkUnlockTTL сколько секунд ссылка остается разблокированной после ввода пароля
+	LinkUnlockTTL int
+	// InactiveLinkResponse ответ до активации ссылки: "not_found" (404) или "page" (страница "скоро")
+	InactiveLinkResponse string
+	// TrashRetentionDays сколько дней удаленная ссылка хранится в корзине (0 - бессрочно)
+	TrashRetentionDays int
+	// CodeQuarantineDays сколько дней с момента удаления код нельзя занять заново
+	CodeQuarantineDays int
+}
+
+// RateLimitConfig лимиты запросов на клиента за окно Window (секунды).
+// Нулевой лимит отключает ограничение для группы маршрутов
+type RateLimitConfig struct {
+	Window   int
+	Create   int
+	Redirect int
+	Stats    int
+	// Auth попыток входа и регистрации с одного адреса
+	Auth int
+	// LinkPassword попыток ввода пароля ссылки с одного адреса за LinkPasswordWindow секунд,
+	// LinkPasswordPerLink - со всех адресов
+	LinkPassword        int
+	LinkPasswordPerLink int
+	LinkPasswordWindow  int
+}
+
+// AnalyticsConfig настройки асинхронной записи кликов
+type AnalyticsConfig struct {
+	QueueSize       int
+	Workers         int
+	BatchSize       int
+	FlushIntervalMs int
+	// GeoIPDBPath путь к локальной базе MaxMind (.mmdb), пусто - геолокация отключена
+	GeoIPDBPath string
+}
+
+// WebhookConfig настройки доставки webhook
+type WebhookConfig struct {
+	// MaxAttempts количество попыток доставки до пометки failed
+	MaxAttempts int
+	// TimeoutSeconds таймаут одного запроса к подписчику
+	TimeoutSeconds int
+	// AllowPrivateTargets разрешает доставку на локальные и частные адреса (для разработки)
+	AllowPrivateTargets bool
+}
+
+// JanitorConfig настройки очистки давно истекших ссылок
+type JanitorConfig struct {
+	// Mode что делать со ссылками: off, archive, soft_delete или hard_delete
+	Mode string
+	// GraceDays сколько дней после истечения срока ссылка остается нетронутой
+	GraceDays int
+	// IntervalMinutes период запуска очистки
+	IntervalMinutes int
 }
 
 // Load загружает конфигурацию из .env файла и переменных окружения
@@ -58,6 +130,9 @@ func Load() (*Config, error) {
 		Server: ServerConfig{
 			Host: getEnv("SERVER_HOST", "localhost"),
 			Port: getEnv("SERVER_PORT", "8080"),
+			// Loopback и частные сети: балансировщик в той же сети. Напрямую подключенный
+			// клиент имеет публичный адрес и подменить свой адрес заголовком не может
+			TrustedProxies: getEnv("TRUSTED_PROXIES", "127.0.0.0/8,::1/128,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7"),
 		},
 		Postgres: PostgresConfig{
 			Host:     getEnv("POSTGRES_HOST", "localhost"),
@@ -74,16 +149,97 @@ func Load() (*Config, error) {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		App: AppConfig{
-			ShortCodeLength: getEnvAsInt("SHORT_CODE_LENGTH", 7),
-			BaseURL:         getEnv("BASE_URL", "http://localhost:8080"),
-			CacheTTL:        getEnvAsInt("CACHE_TTL", 86400), // 24 часа
-			Env:             getEnv("ENV", "development"),
+			ShortCodeLength:      getEnvAsInt("SHORT_CODE_LENGTH", 7),
+			BaseURL:              getEnv("BASE_URL", "http://localhost:8080"),
+			CacheTTL:             getEnvAsInt("CACHE_TTL", 86400), // 24 часа
+			Env:                  getEnv("ENV", "development"),
+			ShortURLStyle:        getEnv("SHORT_URL_STYLE", "query"),
+			SessionTTL:           getEnvAsInt("SESSION_TTL", 604800), // 7 дней
+			AllowAnonymousLinks:  getEnvAsBool("ALLOW_ANONYMOUS_LINKS", false),
+			QRLogoPath:           getEnv("QR_LOGO_PATH", ""),
+			LinkUnlockSecret:     getEnv("LINK_UNLOCK_SECRET", ""),
+			LinkUnlockTTL:        getEnvAsInt("LINK_UNLOCK_TTL", 3600), // 1 час
+			InactiveLinkResponse: getEnv("INACTIVE_LINK_RESPONSE", "not_found"),
+			TrashRetentionDays:   getEnvAsInt("TRASH_RETENTION_DAYS", 30),
+			CodeQuarantineDays:   getEnvAsInt("CODE_QUARANTINE_DAYS", 365),
+		},
+		RateLimit: RateLimitConfig{
+			Window:              getEnvAsInt("RATE_LIMIT_WINDOW", 60),
+			Create:              getEnvAsInt("RATE_LIMIT_CREATE", 30),
+			Redirect:            getEnvAsInt("RATE_LIMIT_REDIRECT", 300),
+			Stats:               getEnvAsInt("RATE_LIMIT_STATS", 60),
+			Auth:                getEnvAsInt("RATE_LIMIT_AUTH", 10),
+			LinkPassword:        getEnvAsInt("RATE_LIMIT_LINK_PASSWORD", 5),
+			LinkPasswordPerLink: getEnvAsInt("RATE_LIMIT_LINK_PASSWORD_PER_LINK", 50),
+			LinkPasswordWindow:  getEnvAsInt("RATE_LIMIT_LINK_PASSWORD_WINDOW", 900),
+		},
+		Analytics: AnalyticsConfig{
+			QueueSize:       getEnvAsInt("CLICK_QUEUE_SIZE", 10000),
+			Workers:         getEnvAsInt("CLICK_WORKERS", 2),
+			BatchSize:       getEnvAsInt("CLICK_BATCH_SIZE", 500),
+			FlushIntervalMs: getEnvAsInt("CLICK_FLUSH_INTERVAL_MS", 1000),
+			GeoIPDBPath:     getEnv("GEOIP_DB_PATH", ""),
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:         getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			TimeoutSeconds:      getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+			AllowPrivateTargets: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_TARGETS", false),
+		},
+		Janitor: JanitorConfig{
+			Mode:            getEnv("JANITOR_MODE", "archive"),
+			GraceDays:       getEnvAsInt("JANITOR_GRACE_DAYS", 30),
+			IntervalMinutes: getEnvAsInt("JANITOR_INTERVAL_MINUTES", 60),
 		},
 	}
 
 	return config, nil
 }
 
+// IsProduction проверяет, что приложение запущено в production
+func (c *AppConfig) IsProduction() bool {
+	return c.Env == "production"
+}
+
+// GetTrashRetention возвращает срок хранения ссылок в корзине
+func (c *AppConfig) GetTrashRetention() time.Duration {
+	return time.Duration(c.TrashRetentionDays) * 24 * time.Hour
+}
+
+// GetCodeQuarantine возвращает срок карантина кодов удаленных ссылок
+func (c *AppConfig) GetCodeQuarantine() time.Duration {
+	return time.Duration(c.CodeQuarantineDays) * 24 * time.Hour
+}
+
+// GetWindow возвращает окно rate limiting
+func (c *RateLimitConfig) GetWindow() time.Duration {
+	return time.Duration(c.Window) * time.Second
+}
+
+// GetLinkPasswordWindow возвращает окно ограничения попыток ввода пароля ссылки
+func (c *RateLimitConfig) GetLinkPasswordWindow() time.Duration {
+	return time.Duration(c.LinkPasswordWindow) * time.Second
+}
+
+// GetFlushInterval возвращает максимальный интервал между записями пачек кликов
+func (c *AnalyticsConfig) GetFlushInterval() time.Duration {
+	return time.Duration(c.FlushIntervalMs) * time.Millisecond
+}
+
+// GetTimeout возвращает таймаут запроса к подписчику webhook
+func (c *WebhookConfig) GetTimeout() time.Duration {
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// GetGrace возвращает срок после истечения ссылки, в течение которого очистка ее не трогает
+func (c *JanitorConfig) GetGrace() time.Duration {
+	return time.Duration(c.GraceDays) * 24 * time.Hour
+}
+
+// GetInterval возвращает период запуска очистки
+func (c *JanitorConfig) GetInterval() time.Duration {
+	return time.Duration(c.IntervalMinutes) * time.Minute
+}
+
 // GetDSN возвращает строку подключения к PostgreSQL
 func (c *PostgresConfig) GetDSN() string {
 	return fmt.Sprintf(
@@ -118,3 +274,12 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsBool получает переменную окружения как bool или возвращает значение по умолчанию
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// variantCookieMaxAge время, на которое вариант A/B теста закрепляется за посетителем
+const variantCookieMaxAge = 30 * 24 * time.Hour
+
+// variantCookieName имя cookie с вариантом A/B теста ссылки
+func variantCookieName(url *models.URL) string {
+	return fmt.Sprintf("ab_%d", url.ID)
+}
+
+// pickVariant выбирает вариант A/B теста для посетителя. Для ссылки со sticky_variants
+// учитывается вариант из cookie, а выбранный вариант закрепляется cookie заново
+func (h *RedirectHandler) pickVariant(w http.ResponseWriter, r *http.Request, url *models.URL) *models.URLVariant {
+	var stickyID int64
+	if url.StickyVariants {
+		if cookie, err := r.Cookie(variantCookieName(url)); err == nil {
+			stickyID, _ = strconv.ParseInt(cookie.Value, 10, 64)
+		}
+	}
+
+	variant := h.variantService.Pick(r.Context(), url, stickyID)
+	if variant == nil || !url.StickyVariants {
+		return variant
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     variantCookieName(url),
+		Value:    strconv.FormatInt(variant.ID, 10),
+		Path:     "/",
+		MaxAge:   int(variantCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return variant
+}
@@ -1,14 +1,26 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"url-short/internal/models"
 	"url-short/internal/service"
 )
 
+// clickStreamHeartbeat интервал комментариев-пингов в SSE потоке, чтобы прокси
+// не закрывали соединение без событий
+const clickStreamHeartbeat = 15 * time.Second
+
 // AnalyticsHandler обработчик для аналитики
 type AnalyticsHandler struct {
 	analyticsService service.AnalyticsService
@@ -21,8 +33,8 @@ func NewAnalyticsHandler(analyticsService service.AnalyticsService) *AnalyticsHa
 	}
 }
 
-// GetURLStats получает статистику по URL
-// GET /api/v1/urls/{id}/stats
+// GetURLStats получает статистику по URL за период
+// GET /api/v1/urls/{id}/stats?from=&to=&granularity=&tz=&recent_limit=&include_bots=
 func (h *AnalyticsHandler) GetURLStats(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -31,11 +43,324 @@ func (h *AnalyticsHandler) GetURLStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats, err := h.analyticsService.GetURLStats(r.Context(), id)
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := h.analyticsService.GetURLStats(r.Context(), userID, id, filter)
 	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			respondWithError(w, http.StatusNotFound, "URL не найден")
+			return
+		}
+		if strings.Contains(err.Error(), "невалидный") {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, stats)
 }
+
+// ExportClicks выгружает все клики URL потоком в CSV или NDJSON.
+// Ответ сжимается gzip, если клиент его поддерживает (middleware Compress)
+// GET /api/v1/urls/{id}/clicks/export?format=csv|ndjson&from=&to=&tz=&include_bots=
+func (h *AnalyticsHandler) ExportClicks(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный ID")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatCSV
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Невалидный format: допустимо csv или ndjson")
+		return
+	}
+
+	filter, err := parseExportFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Заголовки пишутся при первой строке: до нее ошибки (404, 400) отдаются обычным JSON
+	writer := newClickExportWriter(format, w)
+	started := false
+	start := func() error {
+		started = true
+
+		// Выгрузка может длиться дольше WriteTimeout сервера
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"clicks-%d.%s\"", id, format))
+		w.WriteHeader(http.StatusOK)
+		return writer.WriteHeader()
+	}
+
+	err = h.analyticsService.ExportClicks(r.Context(), userID, id, filter, func(click *models.Analytics) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return writer.Write(click)
+	})
+
+	if err != nil {
+		if started {
+			// Статус уже отправлен, обрываем выгрузку
+			log.Printf("Ошибка экспорта кликов URL %d: %v", id, err)
+			return
+		}
+		switch {
+		case strings.Contains(err.Error(), "не найден"):
+			respondWithError(w, http.StatusNotFound, "URL не найден")
+		case strings.Contains(err.Error(), "невалидный"):
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Кликов нет: отдаем пустой файл (для CSV - только заголовок)
+	if !started {
+		if err := start(); err != nil {
+			log.Printf("Ошибка экспорта кликов URL %d: %v", id, err)
+			return
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		log.Printf("Ошибка экспорта кликов URL %d: %v", id, err)
+	}
+}
+
+// StreamClicks отправляет клики URL в реальном времени (Server-Sent Events)
+// GET /api/v1/urls/{id}/events
+func (h *AnalyticsHandler) StreamClicks(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный ID")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.analyticsService.SubscribeClicks(r.Context(), userID, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			respondWithError(w, http.StatusNotFound, "URL не найден")
+			return
+		}
+		respondWithError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	serveClickStream(w, r, sub)
+}
+
+// StreamAllClicks отправляет клики всех ссылок в реальном времени (для администраторов)
+// GET /api/v1/admin/events
+func (h *AnalyticsHandler) StreamAllClicks(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.analyticsService.SubscribeAllClicks()
+	if err != nil {
+		respondWithError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	serveClickStream(w, r, sub)
+}
+
+// serveClickStream пишет события подписки в формате SSE, пока клиент не отключится
+// или поток не будет закрыт при остановке сервера
+func serveClickStream(w http.ResponseWriter, r *http.Request, sub *service.ClickSubscription) {
+	rc := http.NewResponseController(w)
+
+	// Соединение живет дольше WriteTimeout сервера
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Ошибка настройки SSE соединения: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Отключаем буферизацию в nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Клиент переподключается через 5 секунд после обрыва
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(clickStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("Ошибка сериализации события клика: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: click\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// GetPipelineStats возвращает метрики очереди кликов (принято, отброшено, записано)
+// GET /api/v1/admin/clicks
+func (h *AnalyticsHandler) GetPipelineStats(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.analyticsService.PipelineStats())
+}
+
+// parseStatsFilter читает параметры статистики из query. Пустые параметры
+// остаются нулевыми, значения по умолчанию подставляет сервис
+func parseStatsFilter(r *http.Request) (models.StatsFilter, error) {
+	query := r.URL.Query()
+	filter := models.StatsFilter{
+		Granularity: query.Get("granularity"),
+	}
+
+	if err := parseTimeWindow(query, &filter); err != nil {
+		return filter, err
+	}
+
+	if recentLimit := query.Get("recent_limit"); recentLimit != "" {
+		var err error
+		filter.RecentLimit, err = strconv.Atoi(recentLimit)
+		if err != nil || filter.RecentLimit <= 0 {
+			return filter, fmt.Errorf("невалидный recent_limit: %s", recentLimit)
+		}
+	}
+
+	if err := parseIncludeBots(query, &filter); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseExportFilter читает окно экспорта из query. В отличие от статистики, окно
+// по умолчанию не ограничено, а клики ботов включаются (include_bots=false исключает их)
+func parseExportFilter(r *http.Request) (models.StatsFilter, error) {
+	query := r.URL.Query()
+	filter := models.StatsFilter{IncludeBots: true}
+
+	if err := parseTimeWindow(query, &filter); err != nil {
+		return filter, err
+	}
+
+	if err := parseIncludeBots(query, &filter); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseTimeWindow читает tz, from и to. Даты без времени считаются в tz,
+// дата в to включает весь день
+func parseTimeWindow(query url.Values, filter *models.StatsFilter) error {
+	loc := time.UTC
+	if tz := query.Get("tz"); tz != "" {
+		var err error
+		// Имя зоны уходит в AT TIME ZONE PostgreSQL, поэтому допускаются только имена IANA:
+		// "Local" (часовой пояс сервера) PostgreSQL не знает
+		if loc, err = time.LoadLocation(tz); err != nil || tz == "Local" || loc.String() != tz {
+			return fmt.Errorf("невалидный tz: %s", tz)
+		}
+		filter.Location = loc
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		if filter.From, _, err = parseStatsTime(from, loc); err != nil {
+			return fmt.Errorf("невалидный from: %s", from)
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		var dateOnly bool
+		if filter.To, dateOnly, err = parseStatsTime(to, loc); err != nil {
+			return fmt.Errorf("невалидный to: %s", to)
+		}
+		if dateOnly {
+			filter.To = filter.To.AddDate(0, 0, 1)
+		}
+	}
+
+	return nil
+}
+
+// parseIncludeBots читает include_bots, если он передан
+func parseIncludeBots(query url.Values, filter *models.StatsFilter) error {
+	includeBots := query.Get("include_bots")
+	if includeBots == "" {
+		return nil
+	}
+
+	value, err := strconv.ParseBool(includeBots)
+	if err != nil {
+		return fmt.Errorf("невалидный include_bots: %s", includeBots)
+	}
+	filter.IncludeBots = value
+	return nil
+}
+
+// parseStatsTime разбирает время в RFC3339 или дату YYYY-MM-DD в часовом поясе loc
+func parseStatsTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
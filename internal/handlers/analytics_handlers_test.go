@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"url-short/internal/models"
+	"url-short/internal/service"
+)
+
+// mockAnalyticsService мок для тестирования handlers аналитики
+type mockAnalyticsService struct {
+	recorded   []*models.ClickEvent
+	clicks     []*models.Analytics
+	stream     *service.ClickStream
+	subscribed chan struct{}
+	err        error
+}
+
+func (m *mockAnalyticsService) RecordClick(ctx context.Context, event *models.ClickEvent) error {
+	m.recorded = append(m.recorded, event)
+	return nil
+}
+
+func (m *mockAnalyticsService) GetURLStats(ctx context.Context, userID, urlID int64, filter models.StatsFilter) (*models.URLStats, error) {
+	return &models.URLStats{}, m.err
+}
+
+func (m *mockAnalyticsService) ExportClicks(ctx context.Context, userID, urlID int64, filter models.StatsFilter, fn func(*models.Analytics) error) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, click := range m.clicks {
+		if err := fn(click); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockAnalyticsService) SubscribeClicks(ctx context.Context, userID, urlID int64) (*service.ClickSubscription, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	sub, err := m.stream.Subscribe(urlID)
+	if m.subscribed != nil {
+		close(m.subscribed)
+	}
+	return sub, err
+}
+
+func (m *mockAnalyticsService) SubscribeAllClicks() (*service.ClickSubscription, error) {
+	return m.stream.Subscribe(0)
+}
+
+func (m *mockAnalyticsService) PipelineStats() service.ClickPipelineStats {
+	return service.ClickPipelineStats{}
+}
+
+// newExportRequest создает запрос экспорта кликов URL 1
+func newExportRequest(query string) *http.Request {
+	req := httptest.NewRequest("GET", "/api/v1/urls/1/clicks/export?"+query, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	return req.WithContext(context.WithValue(withTestUser(req.Context()), chi.RouteCtxKey, rctx))
+}
+
+// TestParseStatsFilter проверяет разбор параметров статистики
+func TestParseStatsFilter(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/urls/1/stats?from=2025-12-01&to=2025-12-07&granularity=week&tz=Europe/Moscow&recent_limit=10&include_bots=true", nil)
+
+	filter, err := parseStatsFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if filter.Location == nil || filter.Location.String() != "Europe/Moscow" {
+		t.Fatalf("Location = %v", filter.Location)
+	}
+	if got := filter.From.Format(time.RFC3339); got != "2025-12-01T00:00:00+03:00" {
+		t.Errorf("From = %s", got)
+	}
+	// Дата без времени в to включает весь день
+	if got := filter.To.Format(time.RFC3339); got != "2025-12-08T00:00:00+03:00" {
+		t.Errorf("To = %s", got)
+	}
+	if filter.Granularity != "week" || filter.RecentLimit != 10 || !filter.IncludeBots {
+		t.Errorf("filter = %+v", filter)
+	}
+
+	for _, query := range []string{"tz=Mars/Olympus", "tz=Local", "from=yesterday", "recent_limit=0", "include_bots=maybe"} {
+		req := httptest.NewRequest("GET", "/api/v1/urls/1/stats?"+query, nil)
+		if _, err := parseStatsFilter(req); err == nil {
+			t.Errorf("parseStatsFilter(%s) expected error", query)
+		}
+	}
+}
+
+// TestExportClicks_CSV проверяет выгрузку кликов в CSV
+func TestExportClicks_CSV(t *testing.T) {
+	handler := NewAnalyticsHandler(&mockAnalyticsService{clicks: []*models.Analytics{
+		{
+			ID:        7,
+			URLID:     1,
+			ClickedAt: time.Date(2025, 12, 15, 10, 30, 0, 0, time.UTC),
+			IPAddress: sql.NullString{String: "81.2.69.142", Valid: true},
+			UserAgent: sql.NullString{String: "Mozilla/5.0 (X11, Linux)", Valid: true},
+			Country:   sql.NullString{String: "GB", Valid: true},
+		},
+	}})
+
+	w := httptest.NewRecorder()
+	handler.ExportClicks(w, newExportRequest("format=csv"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %s", ct)
+	}
+
+	want := "id,clicked_at,ip_address,user_agent,referer,country,city,browser,os,device_type,is_bot,variant_id\n" +
+		"7,2025-12-15T10:30:00Z,81.2.69.142,\"Mozilla/5.0 (X11, Linux)\",,GB,,,,,false,\n"
+	if w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+}
+
+// TestExportClicks_CSVFormula проверяет, что значения посетителя не выполняются в таблице как формулы,
+// а в NDJSON остаются без изменений
+func TestExportClicks_CSVFormula(t *testing.T) {
+	handler := NewAnalyticsHandler(&mockAnalyticsService{clicks: []*models.Analytics{
+		{
+			ID:        7,
+			ClickedAt: time.Date(2025, 12, 15, 10, 30, 0, 0, time.UTC),
+			UserAgent: sql.NullString{String: "=HYPERLINK(\"https://evil.example\")", Valid: true},
+			Referer:   sql.NullString{String: "@SUM(A1)", Valid: true},
+			City:      sql.NullString{String: "-2+3", Valid: true},
+		},
+	}})
+
+	w := httptest.NewRecorder()
+	handler.ExportClicks(w, newExportRequest("format=csv"))
+
+	want := "id,clicked_at,ip_address,user_agent,referer,country,city,browser,os,device_type,is_bot,variant_id\n" +
+		"7,2025-12-15T10:30:00Z,,\"'=HYPERLINK(\"\"https://evil.example\"\")\",'@SUM(A1),,'-2+3,,,,false,\n"
+	if w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ExportClicks(w, newExportRequest("format=ndjson"))
+	if !strings.Contains(w.Body.String(), `"referer":"@SUM(A1)"`) || !strings.Contains(w.Body.String(), `"city":"-2+3"`) {
+		t.Errorf("ndjson = %s, want values unchanged", w.Body.String())
+	}
+}
+
+// TestExportClicks_NDJSON проверяет выгрузку кликов в NDJSON
+func TestExportClicks_NDJSON(t *testing.T) {
+	handler := NewAnalyticsHandler(&mockAnalyticsService{clicks: []*models.Analytics{
+		{ID: 1, ClickedAt: time.Date(2025, 12, 15, 10, 30, 0, 0, time.UTC)},
+		{ID: 2, ClickedAt: time.Date(2025, 12, 15, 11, 0, 0, 0, time.UTC), IsBot: true},
+	}})
+
+	w := httptest.NewRecorder()
+	handler.ExportClicks(w, newExportRequest("format=ndjson"))
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), w.Body.String())
+	}
+	if !strings.Contains(lines[1], `"is_bot":true`) {
+		t.Errorf("line = %s", lines[1])
+	}
+}
+
+// TestExportClicks_Errors проверяет ошибки до начала выгрузки
+func TestExportClicks_Errors(t *testing.T) {
+	handler := NewAnalyticsHandler(&mockAnalyticsService{err: fmt.Errorf("URL с ID 1 не найден")})
+
+	w := httptest.NewRecorder()
+	handler.ExportClicks(w, newExportRequest("format=csv"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ExportClicks(w, newExportRequest("format=xml"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+// TestStreamClicks проверяет отправку кликов ссылки в SSE и завершение при остановке потока
+func TestStreamClicks(t *testing.T) {
+	stream := service.NewClickStream(nil)
+	subscribed := make(chan struct{})
+	handler := NewAnalyticsHandler(&mockAnalyticsService{stream: stream, subscribed: subscribed})
+
+	req := httptest.NewRequest("GET", "/api/v1/urls/1/events", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(withTestUser(req.Context()), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		handler.StreamClicks(w, req)
+		close(done)
+	}()
+
+	<-subscribed
+	stream.PublishClicks(context.Background(), []*models.ClickEvent{
+		{URLID: 2, Country: "US"},
+		{URLID: 1, Country: "DE"},
+	})
+
+	// Остановка потока завершает соединение после отправки накопленных событий
+	stream.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("StreamClicks не завершился после остановки потока")
+	}
+
+	body := w.Body.String()
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %s", ct)
+	}
+	if !strings.Contains(body, `"url_id":1`) || !strings.Contains(body, `"country":"DE"`) {
+		t.Errorf("body = %q", body)
+	}
+	if strings.Contains(body, `"url_id":2`) {
+		t.Errorf("клик другой ссылки попал в поток: %q", body)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"url-short/internal/middleware"
+	"url-short/internal/models"
+	"url-short/internal/service"
+)
+
+// APIKeyHandler обработчик для управления API ключами
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+// NewAPIKeyHandler создает новый API key handler
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKey создает новый API ключ. Ключ возвращается только в этом ответе
+// POST /api/v1/api-keys
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requireKeyManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	response, err := h.apiKeyService.CreateAPIKey(r.Context(), principal, &req)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "недостаточно прав"):
+			respondWithError(w, http.StatusForbidden, err.Error())
+		case strings.Contains(err.Error(), "невалидн"):
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// ListAPIKeys получает список ключей текущего пользователя
+// GET /api/v1/api-keys
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requireKeyManager(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Ошибка получения списка ключей")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKey отзывает API ключ
+// DELETE /api/v1/api-keys/{id}
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requireKeyManager(w, r)
+	if !ok {
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный ID")
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(r.Context(), principal.UserID, id); err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			respondWithError(w, http.StatusNotFound, "API ключ не найден")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "API ключ отозван",
+	})
+}
+
+// requireKeyManager проверяет право управлять ключами: сессия пользователя
+// или API ключ со scope admin. Иначе утекший ключ мог бы выпускать новые
+func requireKeyManager(w http.ResponseWriter, r *http.Request) (*models.Principal, bool) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Требуется авторизация")
+		return nil, false
+	}
+
+	if principal.IsAPIKey() && !principal.HasScope(models.ScopeAdmin) {
+		respondWithError(w, http.StatusForbidden, "Недостаточно прав: нужен scope "+models.ScopeAdmin)
+		return nil, false
+	}
+
+	return principal, true
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-short/internal/middleware"
+	"url-short/internal/models"
+	"url-short/internal/service"
+)
+
+// AuthHandler обработчик для регистрации и входа
+type AuthHandler struct {
+	authService   service.AuthService
+	secureCookies bool
+}
+
+// NewAuthHandler создает новый auth handler.
+// secureCookies включает флаг Secure у cookie сессии (для HTTPS)
+func NewAuthHandler(authService service.AuthService, secureCookies bool) *AuthHandler {
+	return &AuthHandler{
+		authService:   authService,
+		secureCookies: secureCookies,
+	}
+}
+
+// Register регистрирует нового пользователя
+// POST /api/v1/auth/register
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	user, err := h.authService.Register(r.Context(), &req)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "занят"):
+			respondWithError(w, http.StatusConflict, err.Error())
+		case strings.Contains(err.Error(), "невалидный"):
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, user)
+}
+
+// Login выполняет вход и выдает токен сессии (в теле ответа и в cookie)
+// POST /api/v1/auth/login
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	response, err := h.authService.Login(r.Context(), &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "неверный") {
+			respondWithError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.SessionCookieName,
+		Value:    response.Token,
+		Path:     "/",
+		Expires:  response.ExpiresAt,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// Logout завершает текущую сессию
+// POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	token := middleware.BearerToken(r)
+	if token == "" {
+		if cookie, err := r.Cookie(middleware.SessionCookieName); err == nil {
+			token = cookie.Value
+		}
+	}
+
+	if token != "" {
+		if err := h.authService.Logout(r.Context(), token); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Выход выполнен",
+	})
+}
+
+// Me возвращает текущего пользователя
+// GET /api/v1/auth/me
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.authService.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Пользователь не найден")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, user)
+}
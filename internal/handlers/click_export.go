@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// Форматы экспорта кликов
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+)
+
+// exportContentTypes Content-Type ответа для каждого формата
+var exportContentTypes = map[string]string{
+	exportFormatCSV:    "text/csv; charset=utf-8",
+	exportFormatNDJSON: "application/x-ndjson",
+}
+
+// clickExportColumns колонки CSV экспорта (совпадают с ключами NDJSON)
+var clickExportColumns = []string{
+	"id", "clicked_at", "ip_address", "user_agent", "referer",
+	"country", "city", "browser", "os", "device_type", "is_bot", "variant_id",
+}
+
+// clickExportRow строка экспорта: плоские значения без sql.Null* оберток
+type clickExportRow struct {
+	ID         int64  `json:"id"`
+	ClickedAt  string `json:"clicked_at"`
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	Referer    string `json:"referer"`
+	Country    string `json:"country"`
+	City       string `json:"city"`
+	Browser    string `json:"browser"`
+	OS         string `json:"os"`
+	DeviceType string `json:"device_type"`
+	IsBot      bool   `json:"is_bot"`
+	// VariantID вариант A/B теста (0 - без варианта)
+	VariantID int64 `json:"variant_id,omitempty"`
+}
+
+// newClickExportRow конвертирует клик в строку экспорта. Время в UTC
+func newClickExportRow(click *models.Analytics) clickExportRow {
+	return clickExportRow{
+		ID:         click.ID,
+		ClickedAt:  click.ClickedAt.UTC().Format(time.RFC3339),
+		IPAddress:  click.IPAddress.String,
+		UserAgent:  click.UserAgent.String,
+		Referer:    click.Referer.String,
+		Country:    click.Country.String,
+		City:       click.City.String,
+		Browser:    click.Browser.String,
+		OS:         click.OS.String,
+		DeviceType: click.DeviceType.String,
+		IsBot:      click.IsBot,
+		VariantID:  click.VariantID.Int64,
+	}
+}
+
+// clickExportWriter пишет клики в выбранном формате
+type clickExportWriter interface {
+	WriteHeader() error
+	Write(click *models.Analytics) error
+	Flush() error
+}
+
+// newClickExportWriter создает writer для формата (csv или ndjson)
+func newClickExportWriter(format string, w io.Writer) clickExportWriter {
+	if format == exportFormatNDJSON {
+		return &ndjsonClickWriter{encoder: json.NewEncoder(w)}
+	}
+	return &csvClickWriter{writer: csv.NewWriter(w)}
+}
+
+// csvClickWriter экспорт в CSV с заголовком
+type csvClickWriter struct {
+	writer *csv.Writer
+}
+
+func (cw *csvClickWriter) WriteHeader() error {
+	return cw.writer.Write(clickExportColumns)
+}
+
+func (cw *csvClickWriter) Write(click *models.Analytics) error {
+	row := newClickExportRow(click)
+	return cw.writer.Write([]string{
+		strconv.FormatInt(row.ID, 10),
+		row.ClickedAt,
+		row.IPAddress,
+		csvSafe(row.UserAgent),
+		csvSafe(row.Referer),
+		row.Country,
+		csvSafe(row.City),
+		row.Browser,
+		row.OS,
+		row.DeviceType,
+		strconv.FormatBool(row.IsBot),
+		exportVariantID(row.VariantID),
+	})
+}
+
+// csvSafe обезвреживает значение от посетителя, которое таблица выполнила бы как формулу
+// (начинается с =, +, -, @, табуляции или перевода строки): к нему добавляется апостроф
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// exportVariantID значение variant_id для CSV: пусто для кликов без варианта
+func exportVariantID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+func (cw *csvClickWriter) Flush() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+// ndjsonClickWriter экспорт в NDJSON: один JSON объект на строку
+type ndjsonClickWriter struct {
+	encoder *json.Encoder
+}
+
+func (nw *ndjsonClickWriter) WriteHeader() error {
+	return nil
+}
+
+func (nw *ndjsonClickWriter) Write(click *models.Analytics) error {
+	return nw.encoder.Encode(newClickExportRow(click))
+}
+
+func (nw *ndjsonClickWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+)
+
+// comingSoonTemplate страница ссылки, которая еще не активна
+var comingSoonTemplate = template.Must(template.New("coming-soon").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="robots" content="noindex, nofollow">
+    <title>Ссылка скоро заработает</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); min-height: 100vh; margin: 0; display: flex; align-items: center; justify-content: center; padding: 20px; box-sizing: border-box; }
+        .card { background: white; border-radius: 16px; padding: 32px; max-width: 380px; width: 100%; box-shadow: 0 20px 60px rgba(0, 0, 0, 0.3); text-align: center; }
+        h1 { font-size: 20px; margin: 0 0 8px; color: #333; }
+        p { color: #666; font-size: 14px; margin: 0; }
+    </style>
+</head>
+<body>
+    <div class="card">
+        <h1>⏳ Ссылка скоро заработает</h1>
+        <p>Переход будет доступен с <time id="startsAt" datetime="{{.ISO}}">{{.UTC}}</time></p>
+    </div>
+    <script>
+        const el = document.getElementById('startsAt');
+        el.textContent = new Date(el.getAttribute('datetime')).toLocaleString('ru-RU');
+    </script>
+</body>
+</html>
+`))
+
+// renderComingSoonPage отвечает страницей со временем активации ссылки
+func renderComingSoonPage(w http.ResponseWriter, r *http.Request, startsAt time.Time) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// Ответ меняется в момент активации, поэтому не кешируется
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	startsAt = startsAt.UTC()
+	data := struct {
+		ISO string
+		UTC string
+	}{startsAt.Format(time.RFC3339), startsAt.Format("02.01.2006 15:04 UTC")}
+	if err := comingSoonTemplate.Execute(w, data); err != nil {
+		log.Printf("Ошибка отрисовки страницы активации: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+
+	"url-short/internal/service"
+)
+
+// deepLinkFallbackDelay через сколько миллисекунд страница уходит на запасной адрес,
+// если приложение не открылось
+const deepLinkFallbackDelay = 1500
+
+// deepLinkTemplate промежуточная страница: пробует открыть приложение по deep link,
+// а если страница осталась на экране, переходит на адрес правила (например, в магазин приложений)
+var deepLinkTemplate = template.Must(template.New("deep-link").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="robots" content="noindex, nofollow">
+    <title>Открываем приложение</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); min-height: 100vh; margin: 0; display: flex; align-items: center; justify-content: center; padding: 20px; box-sizing: border-box; }
+        .card { background: white; border-radius: 16px; padding: 32px; max-width: 380px; width: 100%; box-shadow: 0 20px 60px rgba(0, 0, 0, 0.3); text-align: center; }
+        h1 { font-size: 20px; margin: 0 0 8px; color: #333; }
+        p { color: #666; font-size: 14px; margin: 0 0 20px; }
+        a.button { display: block; padding: 12px; background: #667eea; color: white; border-radius: 8px; font-size: 16px; font-weight: 600; text-decoration: none; margin-bottom: 12px; }
+        a.button:hover { background: #5568d3; }
+        a.fallback { color: #667eea; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="card">
+        <h1>📱 Открываем приложение</h1>
+        <p>Если приложение не установлено, вы перейдете дальше автоматически</p>
+        <a class="button" href="{{.DeepLink}}">Открыть в приложении</a>
+        <a class="fallback" href="{{.Fallback}}">Продолжить без приложения</a>
+    </div>
+    <script>
+        (function () {
+            var timer = setTimeout(function () {
+                window.location.replace({{.Fallback}});
+            }, {{.Delay}});
+            // Открывшееся приложение уводит страницу в фон - запасной переход не нужен
+            document.addEventListener('visibilitychange', function () {
+                if (document.hidden) {
+                    clearTimeout(timer);
+                }
+            });
+            window.location.href = {{.DeepLink}};
+        })();
+    </script>
+</body>
+</html>
+`))
+
+// renderDeepLinkPage отвечает промежуточной страницей открытия приложения
+func renderDeepLinkPage(w http.ResponseWriter, r *http.Request, target service.RedirectTarget) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	// Схема deep link проверена при сохранении правила (опасные схемы запрещены),
+	// поэтому адрес помечается безопасным для href
+	data := struct {
+		DeepLink template.URL
+		Fallback string
+		Delay    int
+	}{template.URL(target.DeepLink), target.URL, deepLinkFallbackDelay}
+	if err := deepLinkTemplate.Execute(w, data); err != nil {
+		log.Printf("Ошибка отрисовки страницы deep link: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"url-short/internal/service"
+)
+
+// JanitorHandler обработчик метрик очистки истекших ссылок
+type JanitorHandler struct {
+	janitor *service.Janitor
+}
+
+// NewJanitorHandler создает новый janitor handler
+func NewJanitorHandler(janitor *service.Janitor) *JanitorHandler {
+	return &JanitorHandler{janitor: janitor}
+}
+
+// GetJanitorStats возвращает метрики очистки этого инстанса (проходы, обработанные ссылки, ошибки)
+// GET /api/v1/admin/janitor
+func (h *JanitorHandler) GetJanitorStats(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.janitor.Stats())
+}
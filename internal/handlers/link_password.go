@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-short/internal/middleware"
+	"url-short/internal/models"
+	"url-short/internal/service"
+)
+
+// maxPasswordFormSize ограничение тела формы пароля
+const maxPasswordFormSize = 4 << 10
+
+// PasswordGate настройки проверки пароля защищенных ссылок
+type PasswordGate struct {
+	Unlocker *service.LinkUnlocker
+	// Limiter ограничивает попытки ввода пароля (nil - без ограничения): Attempts на ссылку
+	// и клиента и LinkAttempts на ссылку от всех клиентов за Window. Нулевой лимит отключен
+	Limiter      *middleware.RateLimiter
+	Attempts     int
+	LinkAttempts int
+	Window       time.Duration
+	// SecureCookies включает флаг Secure у cookie разблокировки (для HTTPS)
+	SecureCookies bool
+}
+
+// passwordFormTemplate страница ввода пароля ссылки
+var passwordFormTemplate = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="robots" content="noindex, nofollow">
+    <title>Ссылка защищена паролем</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); min-height: 100vh; margin: 0; display: flex; align-items: center; justify-content: center; padding: 20px; box-sizing: border-box; }
+        .card { background: white; border-radius: 16px; padding: 32px; max-width: 380px; width: 100%; box-shadow: 0 20px 60px rgba(0, 0, 0, 0.3); }
+        h1 { font-size: 20px; margin: 0 0 8px; color: #333; }
+        p { color: #666; font-size: 14px; margin: 0 0 20px; }
+        input { width: 100%; padding: 12px 16px; border: 2px solid #e0e0e0; border-radius: 8px; font-size: 16px; box-sizing: border-box; margin-bottom: 12px; }
+        input:focus { outline: none; border-color: #667eea; }
+        button { width: 100%; padding: 12px; background: #667eea; color: white; border: none; border-radius: 8px; font-size: 16px; font-weight: 600; cursor: pointer; }
+        button:hover { background: #5568d3; }
+        .error { background: #fee; color: #c33; border-radius: 8px; padding: 10px 12px; font-size: 14px; margin-bottom: 12px; }
+    </style>
+</head>
+<body>
+    <form class="card" method="POST">
+        <h1>🔒 Ссылка защищена паролем</h1>
+        <p>Введите пароль, чтобы открыть /{{.ShortCode}}</p>
+        {{if .Error}}<div class="error">{{.Error}}</div>{{end}}
+        <input type="password" name="password" placeholder="Пароль" autocomplete="current-password" required autofocus>
+        <button type="submit">Открыть</button>
+    </form>
+</body>
+</html>
+`))
+
+// isUnlocked проверяет cookie разблокировки ссылки
+func (h *RedirectHandler) isUnlocked(r *http.Request, url *models.URL) bool {
+	cookie, err := r.Cookie(h.passwords.Unlocker.CookieName(url))
+	if err != nil {
+		return false
+	}
+	return h.passwords.Unlocker.Verify(url, cookie.Value, time.Now())
+}
+
+// unlock проверяет пароль из формы. При успехе ставит cookie разблокировки и возвращает true,
+// иначе отвечает формой ввода пароля
+func (h *RedirectHandler) unlock(w http.ResponseWriter, r *http.Request, url *models.URL) bool {
+	if r.Method != http.MethodPost {
+		renderPasswordForm(w, r, url, http.StatusOK, "")
+		return false
+	}
+
+	// Каждая попытка учитывается до проверки пароля, поэтому перебор упирается в лимит
+	if !h.allowPasswordAttempt(w, r, url) {
+		return false
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPasswordFormSize)
+	if err := r.ParseForm(); err != nil {
+		renderPasswordForm(w, r, url, http.StatusBadRequest, "Невалидная форма")
+		return false
+	}
+
+	if !h.urlService.VerifyPassword(url, r.PostForm.Get("password")) {
+		renderPasswordForm(w, r, url, http.StatusUnauthorized, "Неверный пароль")
+		return false
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.passwords.Unlocker.CookieName(url),
+		Value:    h.passwords.Unlocker.Token(url, time.Now()),
+		Path:     "/",
+		MaxAge:   int(h.passwords.Unlocker.TTL().Seconds()),
+		HttpOnly: true,
+		Secure:   h.passwords.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return true
+}
+
+// allowPasswordAttempt учитывает попытку ввода пароля в лимите на ссылку и клиента и в общем
+// лимите ссылки. Адрес клиента берется из заголовков прокси, только если их прислал доверенный
+// прокси (middleware.RealIP), а общий лимит от адреса не зависит вовсе, поэтому смена адреса
+// не снимает ограничение перебора. При превышении отвечает формой с 429
+func (h *RedirectHandler) allowPasswordAttempt(w http.ResponseWriter, r *http.Request, url *models.URL) bool {
+	if h.passwords.Limiter == nil {
+		return true
+	}
+
+	limits := []struct {
+		key      string
+		attempts int
+	}{
+		{fmt.Sprintf("link-password:%d:%s", url.ID, middleware.ClientIP(r)), h.passwords.Attempts},
+		{fmt.Sprintf("link-password:%d", url.ID), h.passwords.LinkAttempts},
+	}
+	for _, limit := range limits {
+		if limit.attempts <= 0 {
+			continue
+		}
+		allowed, _, reset := h.passwords.Limiter.Allow(r.Context(), limit.key, limit.attempts, h.passwords.Window)
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+			renderPasswordForm(w, r, url, http.StatusTooManyRequests, "Слишком много попыток, попробуйте позже")
+			return false
+		}
+	}
+
+	return true
+}
+
+// renderPasswordForm отвечает страницей ввода пароля
+func renderPasswordForm(w http.ResponseWriter, r *http.Request, url *models.URL, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	data := struct {
+		ShortCode string
+		Error     string
+	}{url.ShortCode, message}
+	if err := passwordFormTemplate.Execute(w, data); err != nil {
+		log.Printf("Ошибка отрисовки формы пароля: %v", err)
+	}
+}
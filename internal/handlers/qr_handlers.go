@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"url-short/internal/service"
+	"url-short/pkg/qrcode"
+)
+
+// QRHandler обработчик QR кодов коротких ссылок
+type QRHandler struct {
+	qrService service.QRService
+}
+
+// NewQRHandler создает новый QR handler
+func NewQRHandler(qrService service.QRService) *QRHandler {
+	return &QRHandler{
+		qrService: qrService,
+	}
+}
+
+// GetURLQRCode отдает QR код ссылки владельца
+// GET /api/v1/urls/{id}/qr?format=png|svg&size=256&level=M&fg=000000&bg=ffffff&logo=true
+func (h *QRHandler) GetURLQRCode(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	opts, withLogo, err := parseQROptions(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	code, err := h.qrService.QRCodeByID(r.Context(), userID, id, opts, withLogo)
+	if err != nil {
+		respondWithQRError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	writeQRCode(w, r, code, fmt.Sprintf("qr-%d", id))
+}
+
+// GetQRCode отдает QR код действующей ссылки по короткому коду (без авторизации)
+// GET /qr/{code}?format=png|svg&size=256&level=M&fg=000000&bg=ffffff&logo=true
+func (h *QRHandler) GetQRCode(w http.ResponseWriter, r *http.Request) {
+	shortCode := chi.URLParam(r, "code")
+
+	opts, withLogo, err := parseQROptions(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	code, err := h.qrService.QRCodeByShortCode(r.Context(), shortCode, opts, withLogo)
+	if err != nil {
+		respondWithQRError(w, err)
+		return
+	}
+
+	// Изменение ссылки меняет содержимое кода, поэтому кеш короткий
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeQRCode(w, r, code, "qr-"+shortCode)
+}
+
+// parseQROptions читает параметры изображения. Пустые параметры получают значения по умолчанию
+func parseQROptions(query url.Values) (qrcode.Options, bool, error) {
+	opts := qrcode.DefaultOptions()
+
+	if format := query.Get("format"); format != "" {
+		opts.Format = strings.ToLower(format)
+	}
+
+	if size := query.Get("size"); size != "" {
+		value, err := strconv.Atoi(size)
+		if err != nil {
+			return opts, false, fmt.Errorf("невалидный размер: %s", size)
+		}
+		opts.Size = value
+	}
+
+	if level := query.Get("level"); level != "" {
+		opts.Level = strings.ToUpper(level)
+	}
+
+	if fg := query.Get("fg"); fg != "" {
+		color, err := qrcode.ParseColor(fg)
+		if err != nil {
+			return opts, false, err
+		}
+		opts.Foreground = color
+	}
+
+	if bg := query.Get("bg"); bg != "" {
+		color, err := qrcode.ParseColor(bg)
+		if err != nil {
+			return opts, false, err
+		}
+		opts.Background = color
+	}
+
+	withLogo := false
+	if logo := query.Get("logo"); logo != "" {
+		value, err := strconv.ParseBool(logo)
+		if err != nil {
+			return opts, false, fmt.Errorf("невалидный параметр logo: %s", logo)
+		}
+		withLogo = value
+	}
+
+	return opts, withLogo, opts.Validate()
+}
+
+// writeQRCode отдает изображение с ETag. download=true отдает файлом
+func writeQRCode(w http.ResponseWriter, r *http.Request, code *service.QRCode, filename string) {
+	w.Header().Set("ETag", code.ETag)
+	if r.Header.Get("If-None-Match") == code.ETag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if download, _ := strconv.ParseBool(r.URL.Query().Get("download")); download {
+		extension := qrcode.FormatPNG
+		if code.ContentType == "image/svg+xml" {
+			extension = qrcode.FormatSVG
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, extension))
+	}
+
+	w.Header().Set("Content-Type", code.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(code.Data)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(code.Data) // nolint:errcheck
+	}
+}
+
+// respondWithQRError выбирает статус ответа по ошибке сервиса
+func respondWithQRError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "не найден"), strings.Contains(err.Error(), "не активна"):
+		respondWithError(w, http.StatusNotFound, "URL не найден")
+	case strings.Contains(err.Error(), "истекла"):
+		respondWithError(w, http.StatusGone, "Ссылка истекла")
+	case strings.Contains(err.Error(), "невалидн"):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Ошибка генерации QR кода")
+	}
+}
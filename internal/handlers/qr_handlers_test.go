@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"url-short/internal/models"
+	"url-short/internal/service"
+)
+
+// newQRHandler создает QR handler со ссылкой ID 1 пользователя testUserID
+func newQRHandler() *QRHandler {
+	urlService := &mockURLService{
+		getByID: func(ctx context.Context, userID, id int64) (*models.URLResponse, error) {
+			if userID != testUserID || id != 1 {
+				return nil, fmt.Errorf("URL с ID %d не найден", id)
+			}
+			return &models.URLResponse{ID: 1, ShortCode: "abc123", ShortURL: "http://localhost:8080/abc123"}, nil
+		},
+	}
+	return NewQRHandler(service.NewQRService(urlService, nil, 3600, nil))
+}
+
+// newQRRequest создает запрос QR кода ссылки id
+func newQRRequest(id, query string) *http.Request {
+	req := httptest.NewRequest("GET", "/api/v1/urls/"+id+"/qr?"+query, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(withTestUser(req.Context()), chi.RouteCtxKey, rctx))
+}
+
+// TestGetURLQRCode проверяет форматы, ETag и скачивание
+func TestGetURLQRCode(t *testing.T) {
+	handler := newQRHandler()
+
+	w := httptest.NewRecorder()
+	handler.GetURLQRCode(w, newQRRequest("1", ""))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Content-Type = %s, want image/png", ct)
+	}
+	if !strings.HasPrefix(w.Body.String(), "\x89PNG") {
+		t.Error("body is not PNG")
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag is empty")
+	}
+
+	// Повторный запрос с тем же ETag
+	req := newQRRequest("1", "")
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.GetURLQRCode(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want 304", w.Code)
+	}
+
+	// SVG файлом
+	w = httptest.NewRecorder()
+	handler.GetURLQRCode(w, newQRRequest("1", "format=svg&size=512&level=h&fg=1a2b3c&download=true"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("Content-Type = %s, want image/svg+xml", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="qr-1.svg"` {
+		t.Errorf("Content-Disposition = %s", cd)
+	}
+	if !strings.Contains(w.Body.String(), `fill="#1a2b3c"`) {
+		t.Error("SVG does not use foreground color")
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("ETag does not depend on options")
+	}
+}
+
+// TestGetURLQRCode_Errors проверяет ошибки параметров и доступа
+func TestGetURLQRCode_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		query      string
+		wantStatus int
+	}{
+		{"invalid format", "1", "format=gif", http.StatusBadRequest},
+		{"invalid size", "1", "size=10", http.StatusBadRequest},
+		{"invalid color", "1", "fg=red", http.StatusBadRequest},
+		{"same colors", "1", "fg=ffffff", http.StatusBadRequest},
+		{"logo not configured", "1", "logo=true", http.StatusBadRequest},
+		{"not found", "2", "", http.StatusNotFound},
+		{"invalid id", "abc", "", http.StatusBadRequest},
+	}
+
+	handler := newQRHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.GetURLQRCode(w, newQRRequest(tt.id, tt.query))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,35 +1,74 @@
 package handlers
 
 import (
-	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
+
+	"url-short/internal/middleware"
+	"url-short/internal/models"
 	"url-short/internal/service"
+	"url-short/pkg/useragent"
+)
+
+// Ответы на переход по ссылке до времени ее активации
+const (
+	// InactiveLinkNotFound отвечает 404, как для несуществующей ссылки
+	InactiveLinkNotFound = "not_found"
+	// InactiveLinkPage показывает страницу "скоро" со временем активации
+	InactiveLinkPage = "page"
 )
 
 // RedirectHandler обработчик для редиректа по короткому коду
 type RedirectHandler struct {
 	urlService       service.URLService
+	ruleService      service.RedirectRuleService
+	variantService   service.VariantService
 	analyticsService service.AnalyticsService
+	passwords        PasswordGate
+	inactiveResponse string
 }
 
-// NewRedirectHandler создает новый redirect handler
+// NewRedirectHandler создает новый redirect handler.
+// ruleService выбирает адрес перехода по правилам ссылки (nil - всегда original_url).
+// variantService распределяет переходы без подошедшего правила по вариантам A/B теста (nil - без вариантов).
+// inactiveResponse - ответ до активации ссылки (InactiveLinkNotFound или InactiveLinkPage)
 func NewRedirectHandler(
 	urlService service.URLService,
+	ruleService service.RedirectRuleService,
+	variantService service.VariantService,
 	analyticsService service.AnalyticsService,
+	passwords PasswordGate,
+	inactiveResponse string,
 ) *RedirectHandler {
+	if inactiveResponse != InactiveLinkPage {
+		inactiveResponse = InactiveLinkNotFound
+	}
+
 	return &RedirectHandler{
 		urlService:       urlService,
+		ruleService:      ruleService,
+		variantService:   variantService,
 		analyticsService: analyticsService,
+		passwords:        passwords,
+		inactiveResponse: inactiveResponse,
 	}
 }
 
-// Redirect выполняет редирект на оригинальный URL
-// GET /api/r?code={shortCode}
+// Redirect выполняет редирект на оригинальный URL.
+// Для ссылки с паролем вместо редиректа показывается форма, POST с верным паролем
+// разблокирует ссылку на время LINK_UNLOCK_TTL
+// GET|HEAD|POST /{code}
+// GET|HEAD|POST /api/r?code={shortCode}
 func (h *RedirectHandler) Redirect(w http.ResponseWriter, r *http.Request) {
-	// Читаем shortCode из query параметра вместо path параметра
-	shortCode := r.URL.Query().Get("code")
+	// Код берем из пути (/{code}), а для старых ссылок - из query параметра
+	shortCode := chi.URLParam(r, "code")
+	if shortCode == "" {
+		shortCode = r.URL.Query().Get("code")
+	}
 
 	if shortCode == "" {
 		http.Error(w, "Короткий код не указан", http.StatusBadRequest)
@@ -38,58 +77,91 @@ func (h *RedirectHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 
 	// Получаем полный объект URL (с ID)
 	url, err := h.urlService.GetURLByShortCode(r.Context(), shortCode)
+	var notStarted *service.LinkNotStartedError
+	if errors.As(err, &notStarted) && h.inactiveResponse == InactiveLinkPage {
+		renderComingSoonPage(w, r, notStarted.StartsAt)
+		return
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "не найден") {
-			http.Error(w, "URL не найден", http.StatusNotFound)
+		respondWithRedirectError(w, err)
+		return
+	}
+
+	if url.HasPassword() && !h.isUnlocked(r, url) && !h.unlock(w, r, url) {
+		return
+	}
+
+	if url.HasClickLimit() {
+		// Предзагрузка, HEAD и превью мессенджеров (обычный GET с User-Agent бота)
+		// не должны расходовать одноразовую ссылку, поэтому им не отдается ни переход, ни адрес назначения
+		if useragent.IsPreviewRequest(r.Method, r.Header) || useragent.IsBot(r.UserAgent()) {
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
-		if strings.Contains(err.Error(), "истекла") {
-			http.Error(w, "Ссылка истекла", http.StatusGone)
+
+		if err := h.urlService.ConsumeClick(r.Context(), url); err != nil {
+			respondWithRedirectError(w, err)
 			return
 		}
-		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
-		return
 	}
 
-	// Записываем аналитику (в фоне)
-	go func() {
-		ctx := context.Background() // Используем новый контекст, так как запрос может завершиться
-		ipAddress := getIPAddress(r)
-		userAgent := r.UserAgent()
-		referer := r.Referer()
-
-		// Записываем клик в аналитику (счетчик инкрементируется внутри RecordClick)
-		if err := h.analyticsService.RecordClick(ctx, url.ID, ipAddress, userAgent, referer); err != nil {
-			// Логируем ошибку, но не останавливаем редирект
-			println("Ошибка записи аналитики:", err.Error())
-		}
-	}()
+	// Заголовки прокси учитываются, только если их прислал доверенный прокси (middleware.RealIP)
+	ip := middleware.ClientIP(r)
 
-	// Выполняем редирект (302 вместо 301 чтобы избежать кеширования браузером)
-	http.Redirect(w, r, url.OriginalURL, http.StatusFound)
-}
+	// Правила ссылки (страна, ОС и устройство посетителя) проверяются до выбора адреса перехода
+	target := service.RedirectTarget{URL: url.OriginalURL}
+	if h.ruleService != nil {
+		target = h.ruleService.Destination(r.Context(), url, ip, r.UserAgent())
+	}
 
-// getIPAddress извлекает IP адрес из запроса
-func getIPAddress(r *http.Request) string {
-	// Проверяем заголовки прокси
-	ip := r.Header.Get("X-Real-IP")
-	if ip == "" {
-		ip = r.Header.Get("X-Forwarded-For")
-		if ip != "" {
-			// X-Forwarded-For может содержать несколько IP через запятую
-			ips := strings.Split(ip, ",")
-			ip = strings.TrimSpace(ips[0])
+	// Без подошедшего правила адрес выбирается по весам вариантов A/B теста
+	var variantID int64
+	if target.RuleID == 0 && h.variantService != nil {
+		if variant := h.pickVariant(w, r, url); variant != nil {
+			target = service.RedirectTarget{URL: variant.TargetURL}
+			variantID = variant.ID
 		}
 	}
 
-	// Если заголовки пусты, берем RemoteAddr
-	if ip == "" {
-		ip = r.RemoteAddr
-		// RemoteAddr включает порт, убираем его
-		if idx := strings.LastIndex(ip, ":"); idx != -1 {
-			ip = ip[:idx]
-		}
+	// Ставим клик в очередь аналитики (не блокирует редирект).
+	// HEAD и запросы превью помечаются ботами сразу, User-Agent проверяется в конвейере.
+	// Переполнение очереди учитывается в метриках конвейера
+	event := &models.ClickEvent{
+		URLID:     url.ID,
+		IPAddress: ip,
+		UserAgent: r.UserAgent(),
+		Referer:   r.Referer(),
+		IsBot:     useragent.IsPreviewRequest(r.Method, r.Header),
+		VariantID: variantID,
+	}
+	if err := h.analyticsService.RecordClick(r.Context(), event); err != nil && !errors.Is(err, service.ErrClickQueueFull) {
+		log.Printf("Ошибка записи аналитики: %v", err)
 	}
 
-	return ip
+	// Правило с deep link: страница пробует открыть приложение, иначе переходит на адрес правила
+	if target.DeepLink != "" {
+		renderDeepLinkPage(w, r, target)
+		return
+	}
+
+	// Выполняем редирект (302 вместо 301 чтобы избежать кеширования браузером).
+	// После отправки формы пароля 303, чтобы браузер перешел по ссылке методом GET
+	status := http.StatusFound
+	if r.Method == http.MethodPost {
+		status = http.StatusSeeOther
+	}
+	http.Redirect(w, r, target.URL, status)
+}
+
+// respondWithRedirectError отвечает на ошибку поиска ссылки при редиректе
+func respondWithRedirectError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "не найден"), strings.Contains(err.Error(), "не активна"):
+		http.Error(w, "URL не найден", http.StatusNotFound)
+	case strings.Contains(err.Error(), "истекла"):
+		http.Error(w, "Ссылка истекла", http.StatusGone)
+	default:
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+	}
 }
@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"url-short/internal/middleware"
+	"url-short/internal/models"
+	"url-short/internal/service"
+)
+
+// newPasswordRedirectHandler создает redirect handler со ссылкой "secret" под паролем "letmein".
+// attempts - лимит попыток на клиента, linkAttempts - на ссылку со всех клиентов
+func newPasswordRedirectHandler(attempts, linkAttempts int) *RedirectHandler {
+	link := &models.URL{
+		ID:           7,
+		ShortCode:    "secret",
+		OriginalURL:  "https://example.com/doc",
+		PasswordHash: sql.NullString{String: "hash", Valid: true},
+	}
+	urlService := &mockURLService{
+		getByCode: func(ctx context.Context, code string) (*models.URL, error) {
+			if code != link.ShortCode {
+				return nil, fmt.Errorf("URL с кодом %s не найден", code)
+			}
+			return link, nil
+		},
+		verifyPassword: func(url *models.URL, password string) bool {
+			return password == "letmein"
+		},
+	}
+
+	return NewRedirectHandler(urlService, nil, nil, &mockAnalyticsService{}, PasswordGate{
+		Unlocker:     service.NewLinkUnlocker("test-secret", 3600),
+		Limiter:      middleware.NewRateLimiter(nil),
+		Attempts:     attempts,
+		LinkAttempts: linkAttempts,
+		Window:       time.Minute,
+	}, InactiveLinkNotFound)
+}
+
+// newRedirectRequest создает запрос к короткой ссылке
+func newRedirectRequest(method, code, password string) *http.Request {
+	var req *http.Request
+	if method == http.MethodPost {
+		form := url.Values{"password": {password}}
+		req = httptest.NewRequest(method, "/"+code, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, "/"+code, nil)
+	}
+	req.RemoteAddr = "203.0.113.10:1234"
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("code", code)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// newBrowserRequest создает запрос к короткой ссылке с User-Agent браузера
+func newBrowserRequest(method, code string) *http.Request {
+	req := newRedirectRequest(method, code, "")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36")
+	return req
+}
+
+// TestRedirect_PasswordProtected проверяет форму пароля, разблокировку и cookie
+func TestRedirect_PasswordProtected(t *testing.T) {
+	handler := newPasswordRedirectHandler(5, 0)
+
+	// Без cookie вместо редиректа форма
+	w := httptest.NewRecorder()
+	handler.Redirect(w, newRedirectRequest(http.MethodGet, "secret", ""))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `name="password"`) {
+		t.Fatalf("GET status = %d, want password form", w.Code)
+	}
+	if w.Header().Get("Location") != "" {
+		t.Error("GET without unlock should not redirect")
+	}
+
+	// Неверный пароль
+	w = httptest.NewRecorder()
+	handler.Redirect(w, newRedirectRequest(http.MethodPost, "secret", "wrong"))
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "Неверный пароль") {
+		t.Errorf("POST wrong password status = %d, want 401 with error", w.Code)
+	}
+
+	// Верный пароль: редирект и cookie
+	w = httptest.NewRecorder()
+	handler.Redirect(w, newRedirectRequest(http.MethodPost, "secret", "letmein"))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "https://example.com/doc" {
+		t.Fatalf("POST password status = %d, location = %s, want 303", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "link_unlock_7" || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %+v, want unlock cookie", cookies)
+	}
+
+	// С cookie обычный редирект
+	req := newRedirectRequest(http.MethodGet, "secret", "")
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	handler.Redirect(w, req)
+	if w.Code != http.StatusFound {
+		t.Errorf("GET with cookie status = %d, want 302", w.Code)
+	}
+
+	// Поддельная cookie не подходит
+	req = newRedirectRequest(http.MethodGet, "secret", "")
+	req.AddCookie(&http.Cookie{Name: "link_unlock_7", Value: "9999999999.deadbeef"})
+	w = httptest.NewRecorder()
+	handler.Redirect(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET with forged cookie status = %d, want form", w.Code)
+	}
+}
+
+// TestRedirect_PasswordRateLimit проверяет ограничение попыток ввода пароля
+func TestRedirect_PasswordRateLimit(t *testing.T) {
+	handler := newPasswordRedirectHandler(2, 0)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.Redirect(w, newRedirectRequest(http.MethodPost, "secret", "wrong"))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d status = %d, want 401", i+1, w.Code)
+		}
+	}
+
+	// Лимит исчерпан: даже верный пароль не проверяется
+	w := httptest.NewRecorder()
+	handler.Redirect(w, newRedirectRequest(http.MethodPost, "secret", "letmein"))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("status = %d, want 429 with Retry-After", w.Code)
+	}
+}
+
+// TestRedirect_PasswordRateLimitSpoofedIP проверяет, что подмена X-Forwarded-For не снимает лимит
+func TestRedirect_PasswordRateLimitSpoofedIP(t *testing.T) {
+	// Клиент за одним адресом меняет заголовок на каждой попытке
+	handler := newPasswordRedirectHandler(2, 0)
+	for i := 0; ; i++ {
+		req := newRedirectRequest(http.MethodPost, "secret", "wrong")
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		req.Header.Set("X-Real-IP", fmt.Sprintf("192.0.2.%d", i))
+		w := httptest.NewRecorder()
+		handler.Redirect(w, req)
+		if w.Code == http.StatusTooManyRequests {
+			if i != 2 {
+				t.Errorf("429 after %d attempts, want after 2", i)
+			}
+			break
+		}
+		if i >= 2 {
+			t.Fatalf("attempt %d status = %d, want 429", i+1, w.Code)
+		}
+	}
+
+	// Попытки с разных адресов упираются в общий лимит ссылки
+	handler = newPasswordRedirectHandler(2, 3)
+	for i := 0; i < 4; i++ {
+		req := newRedirectRequest(http.MethodPost, "secret", "wrong")
+		req.RemoteAddr = fmt.Sprintf("198.51.100.%d:1234", i)
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		w := httptest.NewRecorder()
+		handler.Redirect(w, req)
+
+		want := http.StatusUnauthorized
+		if i == 3 {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Fatalf("attempt %d status = %d, want %d", i+1, w.Code, want)
+		}
+	}
+}
+
+// TestRedirect_ClickLimit проверяет лимит переходов и пропуск предзагрузки
+func TestRedirect_ClickLimit(t *testing.T) {
+	link := &models.URL{
+		ID:          8,
+		ShortCode:   "once",
+		OriginalURL: "https://example.com/invite",
+		MaxClicks:   sql.NullInt64{Int64: 1, Valid: true},
+	}
+	consumed := 0
+	urlService := &mockURLService{
+		getByCode: func(ctx context.Context, code string) (*models.URL, error) {
+			return link, nil
+		},
+		consumeClick: func(ctx context.Context, url *models.URL) error {
+			if consumed >= int(url.MaxClicks.Int64) {
+				return fmt.Errorf("ссылка истекла: лимит переходов исчерпан")
+			}
+			consumed++
+			return nil
+		},
+	}
+	handler := NewRedirectHandler(urlService, nil, nil, &mockAnalyticsService{}, PasswordGate{}, InactiveLinkNotFound)
+
+	// HEAD не расходует переход и не раскрывает адрес
+	w := httptest.NewRecorder()
+	handler.Redirect(w, newRedirectRequest(http.MethodHead, "once", ""))
+	if w.Code != http.StatusNoContent || w.Header().Get("Location") != "" || consumed != 0 {
+		t.Fatalf("HEAD status = %d, consumed = %d, want 204 without consuming", w.Code, consumed)
+	}
+
+	// Превью мессенджера приходит обычным GET, его выдает только User-Agent
+	req := newRedirectRequest(http.MethodGet, "once", "")
+	req.Header.Set("User-Agent", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)")
+	w = httptest.NewRecorder()
+	handler.Redirect(w, req)
+	if w.Code != http.StatusNoContent || w.Header().Get("Location") != "" || consumed != 0 {
+		t.Fatalf("Slackbot GET status = %d, consumed = %d, want 204 without consuming", w.Code, consumed)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Redirect(w, newBrowserRequest(http.MethodGet, "once"))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != link.OriginalURL {
+		t.Fatalf("first GET status = %d, want 302", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Redirect(w, newBrowserRequest(http.MethodGet, "once"))
+	if w.Code != http.StatusGone {
+		t.Errorf("second GET status = %d, want 410", w.Code)
+	}
+}
+
+// TestRedirect_NotStarted проверяет настраиваемый ответ до активации ссылки
+func TestRedirect_NotStarted(t *testing.T) {
+	startsAt := time.Date(2030, 1, 2, 9, 30, 0, 0, time.UTC)
+	urlService := &mockURLService{
+		getByCode: func(ctx context.Context, code string) (*models.URL, error) {
+			return nil, &service.LinkNotStartedError{StartsAt: startsAt}
+		},
+	}
+
+	w := httptest.NewRecorder()
+	NewRedirectHandler(urlService, nil, nil, &mockAnalyticsService{}, PasswordGate{}, InactiveLinkNotFound).
+		Redirect(w, newRedirectRequest(http.MethodGet, "launch", ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("not_found mode status = %d, want 404", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	NewRedirectHandler(urlService, nil, nil, &mockAnalyticsService{}, PasswordGate{}, InactiveLinkPage).
+		Redirect(w, newRedirectRequest(http.MethodGet, "launch", ""))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "02.01.2030 09:30 UTC") {
+		t.Errorf("page mode status = %d, want coming soon page with start time", w.Code)
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Error("coming soon page must not be cached")
+	}
+}
+
+// mockRedirectRuleService мок выбора адреса по правилам
+type mockRedirectRuleService struct {
+	service.RedirectRuleService
+	destinations map[string]service.RedirectTarget
+}
+
+func (m *mockRedirectRuleService) Destination(ctx context.Context, url *models.URL, ip, userAgent string) service.RedirectTarget {
+	if target, ok := m.destinations[ip]; ok {
+		return target
+	}
+	return service.RedirectTarget{URL: url.OriginalURL}
+}
+
+// TestRedirect_Rules проверяет, что редирект идет на адрес, выбранный правилами
+func TestRedirect_Rules(t *testing.T) {
+	link := &models.URL{ID: 9, ShortCode: "promo", OriginalURL: "https://example.com"}
+	urlService := &mockURLService{
+		getByCode: func(ctx context.Context, code string) (*models.URL, error) {
+			return link, nil
+		},
+	}
+	rules := &mockRedirectRuleService{destinations: map[string]service.RedirectTarget{
+		"203.0.113.10": {URL: "https://example.com/de"},
+		"198.51.100.8": {URL: "https://apps.apple.com/app/id1", DeepLink: "myapp://home?ref=\"x\""},
+	}}
+	handler := NewRedirectHandler(urlService, rules, nil, &mockAnalyticsService{}, PasswordGate{}, InactiveLinkNotFound)
+
+	w := httptest.NewRecorder()
+	handler.Redirect(w, newRedirectRequest(http.MethodGet, "promo", ""))
+	if got := w.Header().Get("Location"); got != "https://example.com/de" {
+		t.Errorf("Location = %s, want rule target", got)
+	}
+
+	req := newRedirectRequest(http.MethodGet, "promo", "")
+	req.RemoteAddr = "198.51.100.7:1234"
+	w = httptest.NewRecorder()
+	handler.Redirect(w, req)
+	if got := w.Header().Get("Location"); got != link.OriginalURL {
+		t.Errorf("Location = %s, want original_url", got)
+	}
+
+	// Правило с deep link отдает промежуточную страницу вместо редиректа
+	req = newRedirectRequest(http.MethodGet, "promo", "")
+	req.RemoteAddr = "198.51.100.8:1234"
+	w = httptest.NewRecorder()
+	handler.Redirect(w, req)
+	body := w.Body.String()
+	if w.Code != http.StatusOK || w.Header().Get("Location") != "" {
+		t.Fatalf("deep link status = %d, want interstitial page", w.Code)
+	}
+	if !strings.Contains(body, `href="myapp://home?ref=%22x%22"`) || !strings.Contains(body, "https://apps.apple.com/app/id1") {
+		t.Errorf("deep link page does not contain app and store links:\n%s", body)
+	}
+}
+
+// mockVariantService мок выбора варианта A/B теста: закрепленный вариант сохраняется,
+// иначе выбирается next
+type mockVariantService struct {
+	service.VariantService
+	variants map[int64]*models.URLVariant
+	next     int64
+}
+
+func (m *mockVariantService) Pick(ctx context.Context, url *models.URL, stickyID int64) *models.URLVariant {
+	if variant, ok := m.variants[stickyID]; ok {
+		return variant
+	}
+	return m.variants[m.next]
+}
+
+// TestRedirect_Variants проверяет редирект на вариант A/B теста, cookie закрепления
+// и запись варианта в аналитику
+func TestRedirect_Variants(t *testing.T) {
+	link := &models.URL{ID: 9, ShortCode: "promo", OriginalURL: "https://example.com", StickyVariants: true}
+	urlService := &mockURLService{
+		getByCode: func(ctx context.Context, code string) (*models.URL, error) {
+			return link, nil
+		},
+	}
+	rules := &mockRedirectRuleService{destinations: map[string]service.RedirectTarget{
+		"198.51.100.8": {URL: "https://example.com/de", RuleID: 1},
+	}}
+	variants := &mockVariantService{next: 2, variants: map[int64]*models.URLVariant{
+		1: {ID: 1, Name: "a", TargetURL: "https://example.com/a"},
+		2: {ID: 2, Name: "b", TargetURL: "https://example.com/b"},
+	}}
+	analytics := &mockAnalyticsService{}
+	handler := NewRedirectHandler(urlService, rules, variants, analytics, PasswordGate{}, InactiveLinkNotFound)
+
+	w := httptest.NewRecorder()
+	handler.Redirect(w, newRedirectRequest(http.MethodGet, "promo", ""))
+	if got := w.Header().Get("Location"); got != "https://example.com/b" {
+		t.Errorf("Location = %s, want picked variant", got)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "ab_9" || cookies[0].Value != "2" {
+		t.Errorf("cookies = %v, want sticky variant cookie ab_9=2", cookies)
+	}
+
+	// Вариант из cookie важнее случайного выбора
+	req := newRedirectRequest(http.MethodGet, "promo", "")
+	req.AddCookie(&http.Cookie{Name: "ab_9", Value: "1"})
+	w = httptest.NewRecorder()
+	handler.Redirect(w, req)
+	if got := w.Header().Get("Location"); got != "https://example.com/a" {
+		t.Errorf("Location = %s, want sticky variant", got)
+	}
+
+	// Подошедшее правило отменяет A/B тест
+	req = newRedirectRequest(http.MethodGet, "promo", "")
+	req.RemoteAddr = "198.51.100.8:1234"
+	w = httptest.NewRecorder()
+	handler.Redirect(w, req)
+	if got := w.Header().Get("Location"); got != "https://example.com/de" {
+		t.Errorf("Location = %s, want rule target", got)
+	}
+
+	if len(analytics.recorded) != 3 {
+		t.Fatalf("recorded %d clicks, want 3", len(analytics.recorded))
+	}
+	for i, want := range []int64{2, 1, 0} {
+		if got := analytics.recorded[i].VariantID; got != want {
+			t.Errorf("click %d VariantID = %d, want %d", i, got, want)
+		}
+	}
+
+	// Без sticky_variants cookie не ставится и не читается
+	link.StickyVariants = false
+	req = newRedirectRequest(http.MethodGet, "promo", "")
+	req.AddCookie(&http.Cookie{Name: "ab_9", Value: "1"})
+	w = httptest.NewRecorder()
+	handler.Redirect(w, req)
+	if got := w.Header().Get("Location"); got != "https://example.com/b" || len(w.Result().Cookies()) != 0 {
+		t.Errorf("Location = %s, cookies = %v, want random variant without cookie", got, w.Result().Cookies())
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"url-short/internal/models"
+	"url-short/internal/service"
+)
+
+// RedirectRuleHandler обработчик для управления правилами редиректа ссылок
+type RedirectRuleHandler struct {
+	ruleService service.RedirectRuleService
+}
+
+// NewRedirectRuleHandler создает новый redirect rule handler
+func NewRedirectRuleHandler(ruleService service.RedirectRuleService) *RedirectRuleHandler {
+	return &RedirectRuleHandler{
+		ruleService: ruleService,
+	}
+}
+
+// CreateRule добавляет правило ссылке
+// POST /api/v1/urls/{id}/rules
+func (h *RedirectRuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	urlID, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CreateRedirectRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	rule, err := h.ruleService.CreateRule(r.Context(), userID, urlID, &req)
+	if err != nil {
+		respondWithRuleError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, rule)
+}
+
+// ListRules получает правила ссылки в порядке проверки
+// GET /api/v1/urls/{id}/rules
+func (h *RedirectRuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	urlID, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	rules, err := h.ruleService.ListRules(r.Context(), userID, urlID)
+	if err != nil {
+		respondWithRuleError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rules)
+}
+
+// UpdateRule частично обновляет правило
+// PATCH /api/v1/urls/{id}/rules/{ruleID}
+func (h *RedirectRuleHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	urlID, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+	ruleID, ok := parseIDParam(w, r, "ruleID")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateRedirectRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	rule, err := h.ruleService.UpdateRule(r.Context(), userID, urlID, ruleID, &req)
+	if err != nil {
+		respondWithRuleError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rule)
+}
+
+// DeleteRule удаляет правило
+// DELETE /api/v1/urls/{id}/rules/{ruleID}
+func (h *RedirectRuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	urlID, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+	ruleID, ok := parseIDParam(w, r, "ruleID")
+	if !ok {
+		return
+	}
+
+	if err := h.ruleService.DeleteRule(r.Context(), userID, urlID, ruleID); err != nil {
+		respondWithRuleError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Правило успешно удалено",
+	})
+}
+
+// respondWithRuleError выбирает статус ответа по ошибке сервиса
+func respondWithRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "не найден"):
+		respondWithError(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "невалидн"):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Ошибка обработки правила")
+	}
+}
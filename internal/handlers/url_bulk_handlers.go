@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// maxBulkBodySize ограничение тела запроса пакетного создания (JSON или CSV)
+const maxBulkBodySize = 5 << 20
+
+// BulkCreateURLs создает ссылки пакетом из JSON массива или CSV файла
+// (Content-Type: text/csv или multipart/form-data с полем file).
+// Ошибки отдельных строк возвращаются в results и не мешают остальным
+// POST /api/v1/urls/bulk
+func (h *URLHandler) BulkCreateURLs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodySize)
+
+	var rows []models.BulkCreateRow
+	var err error
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		rows, err = parseBulkCSV(r.Body)
+	case "multipart/form-data":
+		file, _, ferr := r.FormFile("file")
+		if ferr != nil {
+			respondWithError(w, http.StatusBadRequest, "Невалидная форма: нужен CSV файл в поле file")
+			return
+		}
+		defer file.Close()
+		rows, err = parseBulkCSV(file)
+	default:
+		var reqs []models.CreateURLRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Невалидный JSON: ожидается массив ссылок")
+			return
+		}
+		rows = make([]models.BulkCreateRow, len(reqs))
+		for i := range reqs {
+			rows[i].Request = reqs[i]
+		}
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.urlService.BulkCreate(r.Context(), userID, rows)
+	if err != nil {
+		respondWithBulkError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// BulkDeleteURLs удаляет ссылки по списку ID
+// POST /api/v1/urls/bulk/delete
+func (h *URLHandler) BulkDeleteURLs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.BulkDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	response, err := h.urlService.BulkDelete(r.Context(), userID, req.IDs)
+	if err != nil {
+		respondWithBulkError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// BulkRetagURLs меняет теги ссылок по списку ID
+// POST /api/v1/urls/bulk/tags
+func (h *URLHandler) BulkRetagURLs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.BulkTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	response, err := h.urlService.BulkRetag(r.Context(), userID, &req)
+	if err != nil {
+		respondWithBulkError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// BulkSetURLsExpiry задает или снимает срок действия ссылок по списку ID
+// POST /api/v1/urls/bulk/expiry
+func (h *URLHandler) BulkSetURLsExpiry(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.BulkExpiryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	response, err := h.urlService.BulkSetExpiry(r.Context(), userID, &req)
+	if err != nil {
+		respondWithBulkError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// respondWithBulkError отвечает ошибкой пакетной операции целиком
+func respondWithBulkError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "невалидн") {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	respondWithError(w, http.StatusInternalServerError, "Ошибка пакетной операции")
+}
+
+// parseBulkCSV читает строки пакетного создания из CSV с заголовком. Колонки называются
+// как поля CreateURLRequest, теги в одной ячейке через запятую. Ошибки значений
+// относятся к своей строке, ошибки структуры файла - ко всему файлу
+func parseBulkCSV(body io.Reader) ([]models.BulkCreateRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("невалидный CSV: пустой файл")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("невалидный CSV: %v", err)
+	}
+
+	columns := make([]string, len(header))
+	hasURL := false
+	for i, name := range header {
+		// Excel добавляет BOM в начало файла
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := bulkCSVColumns[name]; !ok {
+			return nil, fmt.Errorf("невалидный CSV: неизвестная колонка %q", name)
+		}
+		columns[i] = name
+		hasURL = hasURL || name == "original_url"
+	}
+	if !hasURL {
+		return nil, fmt.Errorf("невалидный CSV: нет колонки original_url")
+	}
+
+	var rows []models.BulkCreateRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return nil, fmt.Errorf("невалидный CSV: файл больше %d байт", maxBulkBodySize)
+			}
+			return nil, fmt.Errorf("невалидный CSV: %v", err)
+		}
+
+		var row models.BulkCreateRow
+		if len(record) > len(columns) {
+			row.ParseError = fmt.Sprintf("невалидная строка: %d колонок вместо %d", len(record), len(columns))
+		} else {
+			for i, value := range record {
+				value = strings.TrimSpace(value)
+				if value == "" {
+					continue
+				}
+				if err := bulkCSVColumns[columns[i]](&row.Request, value); err != nil {
+					row.ParseError = fmt.Sprintf("невалидное значение %s: %v", columns[i], err)
+					break
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// bulkCSVColumns колонки CSV пакетного создания и разбор их значений
+var bulkCSVColumns = map[string]func(req *models.CreateURLRequest, value string) error{
+	"original_url": func(req *models.CreateURLRequest, value string) error {
+		req.OriginalURL = value
+		return nil
+	},
+	"custom_code": func(req *models.CreateURLRequest, value string) error {
+		req.CustomCode = value
+		return nil
+	},
+	"starts_at": func(req *models.CreateURLRequest, value string) error {
+		return parseCSVTime(&req.StartsAt, value)
+	},
+	"expires_at": func(req *models.CreateURLRequest, value string) error {
+		return parseCSVTime(&req.ExpiresAt, value)
+	},
+	"password": func(req *models.CreateURLRequest, value string) error {
+		req.Password = value
+		return nil
+	},
+	"max_clicks": func(req *models.CreateURLRequest, value string) error {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается целое число")
+		}
+		req.MaxClicks = &n
+		return nil
+	},
+	"sticky_variants": func(req *models.CreateURLRequest, value string) error {
+		sticky, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("ожидается true или false")
+		}
+		req.StickyVariants = sticky
+		return nil
+	},
+	"title": func(req *models.CreateURLRequest, value string) error {
+		req.Title = value
+		return nil
+	},
+	"notes": func(req *models.CreateURLRequest, value string) error {
+		req.Notes = value
+		return nil
+	},
+	"folder": func(req *models.CreateURLRequest, value string) error {
+		req.Folder = value
+		return nil
+	},
+	"tags": func(req *models.CreateURLRequest, value string) error {
+		req.Tags = strings.Split(value, ",")
+		return nil
+	},
+}
+
+// parseCSVTime разбирает время RFC3339, как в JSON запросе
+func parseCSVTime(dst **time.Time, value string) error {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("ожидается время RFC3339")
+	}
+	*dst = &t
+	return nil
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"url-short/internal/models"
+)
+
+// TestBulkCreateURLs_CSV проверяет разбор CSV: значения по колонкам и ошибки отдельных строк
+func TestBulkCreateURLs_CSV(t *testing.T) {
+	var got []models.BulkCreateRow
+	handler := NewURLHandler(&mockURLService{
+		bulkCreate: func(ctx context.Context, userID int64, rows []models.BulkCreateRow) (*models.BulkCreateResponse, error) {
+			got = rows
+			return &models.BulkCreateResponse{}, nil
+		},
+	})
+
+	body := "\ufeffOriginal_URL,custom_code,max_clicks,tags,expires_at\n" +
+		"https://example.com/a,spring,10,\"promo,spring\",2026-12-31T23:59:59Z\n" +
+		"https://example.com/b,,many,,\n" +
+		"https://example.com/c,,,,,extra\n" +
+		"https://example.com/d\n"
+	req := httptest.NewRequest("POST", "/api/v1/urls/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	req = req.WithContext(withTestUser(req.Context()))
+	w := httptest.NewRecorder()
+	handler.BulkCreateURLs(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if len(got) != 4 {
+		t.Fatalf("rows = %d, want 4", len(got))
+	}
+
+	first := got[0].Request
+	if first.OriginalURL != "https://example.com/a" || first.CustomCode != "spring" || first.MaxClicks == nil || *first.MaxClicks != 10 ||
+		len(first.Tags) != 2 || first.ExpiresAt == nil || got[0].ParseError != "" {
+		t.Errorf("row 1 = %+v, want all columns parsed", got[0])
+	}
+	if !strings.Contains(got[1].ParseError, "max_clicks") {
+		t.Errorf("row 2 ParseError = %q, want max_clicks error", got[1].ParseError)
+	}
+	if !strings.Contains(got[2].ParseError, "колонок") {
+		t.Errorf("row 3 ParseError = %q, want column count error", got[2].ParseError)
+	}
+	if got[3].ParseError != "" || got[3].Request.OriginalURL != "https://example.com/d" {
+		t.Errorf("row 4 = %+v, want short row accepted", got[3])
+	}
+
+	// Файл из формы
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, _ := writer.CreateFormFile("file", "links.csv")
+	part.Write([]byte("original_url,folder\nhttps://example.com,Marketing\n")) // nolint:errcheck
+	writer.Close()
+
+	req = httptest.NewRequest("POST", "/api/v1/urls/bulk", &form)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req = req.WithContext(withTestUser(req.Context()))
+	w = httptest.NewRecorder()
+	handler.BulkCreateURLs(w, req)
+	if w.Code != http.StatusOK || len(got) != 1 || got[0].Request.Folder != "Marketing" {
+		t.Errorf("multipart: status = %d, rows = %+v", w.Code, got)
+	}
+
+	// JSON массив
+	req = httptest.NewRequest("POST", "/api/v1/urls/bulk", strings.NewReader(`[{"original_url":"https://example.com","custom_code":"json1"}]`))
+	req = req.WithContext(withTestUser(req.Context()))
+	w = httptest.NewRecorder()
+	handler.BulkCreateURLs(w, req)
+	if w.Code != http.StatusOK || len(got) != 1 || got[0].Request.CustomCode != "json1" {
+		t.Errorf("json: status = %d, rows = %+v", w.Code, got)
+	}
+
+	for _, csvBody := range []string{"", "url,code\nhttps://example.com,x\n", "title\nНовая\n"} {
+		req := httptest.NewRequest("POST", "/api/v1/urls/bulk", strings.NewReader(csvBody))
+		req.Header.Set("Content-Type", "text/csv")
+		req = req.WithContext(withTestUser(req.Context()))
+		w := httptest.NewRecorder()
+		handler.BulkCreateURLs(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, want %d", csvBody, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"url-short/internal/middleware"
 	"url-short/internal/models"
 	"url-short/internal/service"
 )
@@ -39,9 +43,12 @@ func (h *URLHandler) CreateShortURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.urlService.CreateShortURL(r.Context(), &req)
+	// Анонимные ссылки создаются без владельца
+	userID, _ := middleware.UserIDFromContext(r.Context())
+
+	response, err := h.urlService.CreateShortURL(r.Context(), userID, &req)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, updateErrorStatus(err), err.Error())
 		return
 	}
 
@@ -58,7 +65,12 @@ func (h *URLHandler) GetURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.urlService.GetURLByID(r.Context(), id)
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.urlService.GetURLByID(r.Context(), userID, id)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "URL не найден")
 		return
@@ -67,7 +79,37 @@ func (h *URLHandler) GetURL(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-// DeleteURL удаляет URL
+// UpdateURL частично обновляет ссылку
+// PATCH /api/v1/urls/{id}
+func (h *URLHandler) UpdateURL(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный ID")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	response, err := h.urlService.UpdateURL(r.Context(), userID, id, &req)
+	if err != nil {
+		respondWithError(w, updateErrorStatus(err), err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// DeleteURL перемещает URL в корзину
 // DELETE /api/v1/urls/{id}
 func (h *URLHandler) DeleteURL(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
@@ -77,40 +119,105 @@ func (h *URLHandler) DeleteURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.urlService.DeleteURL(r.Context(), id); err != nil {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.urlService.DeleteURL(r.Context(), userID, id); err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			respondWithError(w, http.StatusNotFound, "URL не найден")
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, map[string]string{
-		"message": "URL успешно удален",
+		"message": "URL перемещен в корзину",
 	})
 }
 
-// GetAllURLs получает список всех URL
-// GET /api/v1/urls
-func (h *URLHandler) GetAllURLs(w http.ResponseWriter, r *http.Request) {
-	// Получаем параметры пагинации из query string
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
+// ListTrash получает ссылки текущего пользователя из корзины
+// GET /api/v1/urls/trash?limit=&offset=
+func (h *URLHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
 
-	limit := 50 // по умолчанию
-	offset := 0
+	filter, err := parseURLFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
+	urls, err := h.urlService.ListTrash(r.Context(), userID, filter)
+	if err != nil {
+		if strings.Contains(err.Error(), "невалидн") {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
 		}
+		respondWithError(w, http.StatusInternalServerError, "Ошибка получения корзины")
+		return
 	}
 
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil {
-			offset = o
+	respondWithJSON(w, http.StatusOK, urls)
+}
+
+// RestoreURL возвращает URL из корзины
+// POST /api/v1/urls/{id}/restore
+func (h *URLHandler) RestoreURL(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.urlService.RestoreURL(r.Context(), userID, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			respondWithError(w, http.StatusNotFound, "URL не найден в корзине")
+			return
 		}
+		respondWithError(w, http.StatusInternalServerError, "Ошибка восстановления URL")
+		return
 	}
 
-	urls, err := h.urlService.GetAllURLs(r.Context(), limit, offset)
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetAllURLs получает список URL текущего пользователя с фильтрами и поиском.
+// С параметрами cursor, sort или include_total отвечает страницей {items, next_cursor, total_count}
+// с keyset-пагинацией, без них - массивом ссылок с пагинацией limit/offset, как раньше
+// GET /api/v1/urls?tag=&folder=&domain=&created_from=&created_to=&state=&q=&sort=&cursor=&include_total=
+func (h *URLHandler) GetAllURLs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := parseURLFilter(r)
 	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if isURLPageRequest(r) {
+		h.listURLPage(w, r, userID, filter)
+		return
+	}
+
+	urls, err := h.urlService.GetAllURLs(r.Context(), userID, filter)
+	if err != nil {
+		if strings.Contains(err.Error(), "невалидн") {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Ошибка получения списка URL")
 		return
 	}
@@ -118,6 +225,164 @@ func (h *URLHandler) GetAllURLs(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, urls)
 }
 
+// isURLPageRequest проверяет, запрошен ли список в виде страницы с курсором.
+// Пустой cursor тоже считается: так запрашивается первая страница
+func isURLPageRequest(r *http.Request) bool {
+	query := r.URL.Query()
+	for _, name := range []string{"cursor", "sort", "include_total"} {
+		if _, ok := query[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// listURLPage отвечает страницей списка ссылок с keyset-пагинацией
+func (h *URLHandler) listURLPage(w http.ResponseWriter, r *http.Request, userID int64, filter models.URLFilter) {
+	query := r.URL.Query()
+	req := models.URLListRequest{
+		Filter: filter,
+		Sort:   query.Get("sort"),
+		Cursor: query.Get("cursor"),
+	}
+
+	// Размер страницы проверяется строго, offset с курсором не совмещается
+	req.Filter.Limit = 0
+	if l := query.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			respondWithError(w, http.StatusBadRequest, "невалидный limit: "+l)
+			return
+		}
+		req.Filter.Limit = limit
+	}
+	if query.Get("offset") != "" {
+		respondWithError(w, http.StatusBadRequest, "невалидный offset: для следующей страницы передайте cursor=next_cursor")
+		return
+	}
+	if total := query.Get("include_total"); total != "" {
+		includeTotal, err := strconv.ParseBool(total)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "невалидный include_total: "+total)
+			return
+		}
+		req.IncludeTotal = includeTotal
+	}
+
+	page, err := h.urlService.ListURLs(r.Context(), userID, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "невалидн") {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Ошибка получения списка URL")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// ListTags получает теги текущего пользователя с количеством ссылок
+// GET /api/v1/tags
+func (h *URLHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := h.urlService.ListTags(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Ошибка получения тегов")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tags)
+}
+
+// ListFolders получает папки текущего пользователя с количеством ссылок
+// GET /api/v1/folders
+func (h *URLHandler) ListFolders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	folders, err := h.urlService.ListFolders(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Ошибка получения папок")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, folders)
+}
+
+// parseURLFilter читает фильтры списка ссылок из query. Невалидные limit и offset
+// игнорируются, лимит вне допустимого диапазона заменяет сервис.
+// Даты без времени считаются в UTC, дата в created_to включает весь день
+func parseURLFilter(r *http.Request) (models.URLFilter, error) {
+	query := r.URL.Query()
+	filter := models.URLFilter{
+		Limit:  50, // по умолчанию
+		Tag:    query.Get("tag"),
+		Folder: query.Get("folder"),
+		Domain: query.Get("domain"),
+		State:  query.Get("state"),
+		Query:  query.Get("q"),
+	}
+
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil {
+		filter.Limit = l
+	}
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil {
+		filter.Offset = o
+	}
+
+	var err error
+	if from := query.Get("created_from"); from != "" {
+		if filter.CreatedFrom, _, err = parseStatsTime(from, time.UTC); err != nil {
+			return filter, fmt.Errorf("невалидный created_from: %s", from)
+		}
+	}
+	if to := query.Get("created_to"); to != "" {
+		var dateOnly bool
+		if filter.CreatedTo, dateOnly, err = parseStatsTime(to, time.UTC); err != nil {
+			return filter, fmt.Errorf("невалидный created_to: %s", to)
+		}
+		if dateOnly {
+			filter.CreatedTo = filter.CreatedTo.AddDate(0, 0, 1)
+		}
+	}
+
+	return filter, nil
+}
+
+// updateErrorStatus подбирает HTTP статус для ошибки создания или обновления ссылки
+func updateErrorStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "не найден"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "занят"):
+		return http.StatusConflict
+	case strings.Contains(msg, "невалидн"),
+		strings.Contains(msg, "зарезервирован"),
+		strings.Contains(msg, "обязателен"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// requireUserID возвращает ID текущего пользователя или отвечает 401
+func requireUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Требуется авторизация")
+		return 0, false
+	}
+	return userID, true
+}
+
 // respondWithJSON отправляет JSON ответ
 func respondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
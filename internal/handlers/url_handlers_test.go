@@ -4,27 +4,47 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"url-short/internal/middleware"
 	"url-short/internal/models"
 )
 
+// testUserID аутентифицированный пользователь в тестах
+const testUserID int64 = 1
+
+// withTestUser добавляет в контекст пользователя с правами обычной сессии
+func withTestUser(ctx context.Context) context.Context {
+	return middleware.WithPrincipal(ctx, &models.Principal{
+		UserID: testUserID,
+		Scopes: models.UserScopes,
+	})
+}
+
 // mockURLService мок для тестирования handlers
 type mockURLService struct {
-	createFunc     func(context.Context, *models.CreateURLRequest) (*models.URLResponse, error)
+	createFunc     func(context.Context, int64, *models.CreateURLRequest) (*models.URLResponse, error)
 	getOriginalURL func(context.Context, string) (string, error)
-	getByID        func(context.Context, int64) (*models.URLResponse, error)
-	getAllURLs     func(context.Context, int, int) ([]*models.URLResponse, error)
-	deleteURL      func(context.Context, int64) error
+	getByID        func(context.Context, int64, int64) (*models.URLResponse, error)
+	getAllURLs     func(context.Context, int64, models.URLFilter) ([]*models.URLResponse, error)
+	listURLs       func(context.Context, int64, models.URLListRequest) (*models.URLPage, error)
+	bulkCreate     func(context.Context, int64, []models.BulkCreateRow) (*models.BulkCreateResponse, error)
+	updateURL      func(context.Context, int64, int64, *models.UpdateURLRequest) (*models.URLResponse, error)
+	deleteURL      func(context.Context, int64, int64) error
+	getByCode      func(context.Context, string) (*models.URL, error)
+	verifyPassword func(*models.URL, string) bool
+	consumeClick   func(context.Context, *models.URL) error
 }
 
-func (m *mockURLService) CreateShortURL(ctx context.Context, req *models.CreateURLRequest) (*models.URLResponse, error) {
+func (m *mockURLService) CreateShortURL(ctx context.Context, userID int64, req *models.CreateURLRequest) (*models.URLResponse, error) {
 	if m.createFunc != nil {
-		return m.createFunc(ctx, req)
+		return m.createFunc(ctx, userID, req)
 	}
 	return nil, nil
 }
@@ -37,26 +57,51 @@ func (m *mockURLService) GetOriginalURL(ctx context.Context, shortCode string) (
 }
 
 func (m *mockURLService) GetURLByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+	if m.getByCode != nil {
+		return m.getByCode(ctx, shortCode)
+	}
 	return nil, nil
 }
 
-func (m *mockURLService) GetURLByID(ctx context.Context, id int64) (*models.URLResponse, error) {
+func (m *mockURLService) GetURLByID(ctx context.Context, userID, id int64) (*models.URLResponse, error) {
 	if m.getByID != nil {
-		return m.getByID(ctx, id)
+		return m.getByID(ctx, userID, id)
 	}
 	return nil, nil
 }
 
-func (m *mockURLService) GetAllURLs(ctx context.Context, limit, offset int) ([]*models.URLResponse, error) {
+func (m *mockURLService) GetAllURLs(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error) {
 	if m.getAllURLs != nil {
-		return m.getAllURLs(ctx, limit, offset)
+		return m.getAllURLs(ctx, userID, filter)
 	}
 	return []*models.URLResponse{}, nil
 }
 
-func (m *mockURLService) DeleteURL(ctx context.Context, id int64) error {
+func (m *mockURLService) ListURLs(ctx context.Context, userID int64, req models.URLListRequest) (*models.URLPage, error) {
+	if m.listURLs != nil {
+		return m.listURLs(ctx, userID, req)
+	}
+	return &models.URLPage{Items: []*models.URLResponse{}}, nil
+}
+
+func (m *mockURLService) ListTags(ctx context.Context, userID int64) ([]models.GroupCount, error) {
+	return []models.GroupCount{}, nil
+}
+
+func (m *mockURLService) ListFolders(ctx context.Context, userID int64) ([]models.GroupCount, error) {
+	return []models.GroupCount{}, nil
+}
+
+func (m *mockURLService) UpdateURL(ctx context.Context, userID, id int64, req *models.UpdateURLRequest) (*models.URLResponse, error) {
+	if m.updateURL != nil {
+		return m.updateURL(ctx, userID, id, req)
+	}
+	return nil, nil
+}
+
+func (m *mockURLService) DeleteURL(ctx context.Context, userID, id int64) error {
 	if m.deleteURL != nil {
-		return m.deleteURL(ctx, id)
+		return m.deleteURL(ctx, userID, id)
 	}
 	return nil
 }
@@ -65,10 +110,63 @@ func (m *mockURLService) IncrementClicks(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (m *mockURLService) NotifyExpiredLinks(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *mockURLService) VerifyPassword(url *models.URL, password string) bool {
+	if m.verifyPassword != nil {
+		return m.verifyPassword(url, password)
+	}
+	return true
+}
+
+func (m *mockURLService) ConsumeClick(ctx context.Context, url *models.URL) error {
+	if m.consumeClick != nil {
+		return m.consumeClick(ctx, url)
+	}
+	return nil
+}
+
+func (m *mockURLService) BulkCreate(ctx context.Context, userID int64, rows []models.BulkCreateRow) (*models.BulkCreateResponse, error) {
+	if m.bulkCreate != nil {
+		return m.bulkCreate(ctx, userID, rows)
+	}
+	return &models.BulkCreateResponse{}, nil
+}
+
+func (m *mockURLService) BulkDelete(ctx context.Context, userID int64, ids []int64) (*models.BulkResponse, error) {
+	return &models.BulkResponse{}, nil
+}
+
+func (m *mockURLService) BulkRetag(ctx context.Context, userID int64, req *models.BulkTagsRequest) (*models.BulkResponse, error) {
+	return &models.BulkResponse{}, nil
+}
+
+func (m *mockURLService) BulkSetExpiry(ctx context.Context, userID int64, req *models.BulkExpiryRequest) (*models.BulkResponse, error) {
+	return &models.BulkResponse{}, nil
+}
+
+func (m *mockURLService) ListTrash(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error) {
+	return []*models.URLResponse{}, nil
+}
+
+func (m *mockURLService) RestoreURL(ctx context.Context, userID, id int64) (*models.URLResponse, error) {
+	return &models.URLResponse{ID: id}, nil
+}
+
+func (m *mockURLService) PurgeDeletedLinks(ctx context.Context, retention, quarantine time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (m *mockURLService) ShortURL(shortCode string) string {
+	return "http://localhost:8080/" + shortCode
+}
+
 // TestCreateShortURL_Success проверяет успешное создание ссылки
 func TestCreateShortURL_Success(t *testing.T) {
 	mockService := &mockURLService{
-		createFunc: func(ctx context.Context, req *models.CreateURLRequest) (*models.URLResponse, error) {
+		createFunc: func(ctx context.Context, userID int64, req *models.CreateURLRequest) (*models.URLResponse, error) {
 			return &models.URLResponse{
 				ID:          1,
 				ShortCode:   "abc123",
@@ -140,10 +238,46 @@ func TestCreateShortURL_EmptyURL(t *testing.T) {
 	}
 }
 
+// TestCreateShortURL_ServiceErrors проверяет статусы ошибок сервиса при создании ссылки
+func TestCreateShortURL_ServiceErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"invalid custom code", fmt.Errorf("невалидный короткий код: от 1 до 10 символов, латиница, цифры, - и _"), http.StatusBadRequest},
+		{"reserved custom code", fmt.Errorf("короткий код зарезервирован"), http.StatusBadRequest},
+		{"taken custom code", fmt.Errorf("короткий код уже занят"), http.StatusConflict},
+		{"max clicks below one", fmt.Errorf("невалидный лимит переходов: должен быть больше 0"), http.StatusBadRequest},
+		{"password length", fmt.Errorf("невалидный пароль ссылки: от 4 до 72 символов"), http.StatusBadRequest},
+		{"starts_at not before expires_at", fmt.Errorf("невалидный период действия: starts_at должен быть раньше expires_at"), http.StatusBadRequest},
+		{"database error", fmt.Errorf("ошибка создания URL: connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewURLHandler(&mockURLService{
+				createFunc: func(ctx context.Context, userID int64, req *models.CreateURLRequest) (*models.URLResponse, error) {
+					return nil, tt.err
+				},
+			})
+
+			reqBody := `{"original_url":"https://example.com","custom_code":"code"}`
+			req := httptest.NewRequest("POST", "/api/v1/urls", bytes.NewBufferString(reqBody))
+			w := httptest.NewRecorder()
+			handler.CreateShortURL(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("Status code = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
 // TestGetURL_Success проверяет успешное получение URL по ID
 func TestGetURL_Success(t *testing.T) {
 	mockService := &mockURLService{
-		getByID: func(ctx context.Context, id int64) (*models.URLResponse, error) {
+		getByID: func(ctx context.Context, userID, id int64) (*models.URLResponse, error) {
 			return &models.URLResponse{
 				ID:          id,
 				ShortCode:   "test123",
@@ -161,7 +295,7 @@ func TestGetURL_Success(t *testing.T) {
 	// Используем chi context для передачи параметра ID
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = req.WithContext(context.WithValue(withTestUser(req.Context()), chi.RouteCtxKey, rctx))
 
 	w := httptest.NewRecorder()
 	handler.GetURL(w, req)
@@ -194,7 +328,7 @@ func TestGetURL_InvalidID(t *testing.T) {
 
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "invalid")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = req.WithContext(context.WithValue(withTestUser(req.Context()), chi.RouteCtxKey, rctx))
 
 	w := httptest.NewRecorder()
 	handler.GetURL(w, req)
@@ -207,7 +341,7 @@ func TestGetURL_InvalidID(t *testing.T) {
 // TestGetAllURLs_Success проверяет получение списка URL
 func TestGetAllURLs_Success(t *testing.T) {
 	mockService := &mockURLService{
-		getAllURLs: func(ctx context.Context, limit, offset int) ([]*models.URLResponse, error) {
+		getAllURLs: func(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error) {
 			return []*models.URLResponse{
 				{
 					ID:          1,
@@ -226,6 +360,7 @@ func TestGetAllURLs_Success(t *testing.T) {
 	handler := NewURLHandler(mockService)
 
 	req := httptest.NewRequest("GET", "/api/v1/urls?limit=10&offset=0", nil)
+	req = req.WithContext(withTestUser(req.Context()))
 	w := httptest.NewRecorder()
 	handler.GetAllURLs(w, req)
 
@@ -251,13 +386,13 @@ func TestGetAllURLs_Success(t *testing.T) {
 // TestGetAllURLs_DefaultParams проверяет дефолтные параметры пагинации
 func TestGetAllURLs_DefaultParams(t *testing.T) {
 	mockService := &mockURLService{
-		getAllURLs: func(ctx context.Context, limit, offset int) ([]*models.URLResponse, error) {
+		getAllURLs: func(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error) {
 			// Проверяем что вызвалось с дефолтными значениями
-			if limit != 50 {
-				t.Errorf("limit = %d, want 50", limit)
+			if filter.Limit != 50 {
+				t.Errorf("limit = %d, want 50", filter.Limit)
 			}
-			if offset != 0 {
-				t.Errorf("offset = %d, want 0", offset)
+			if filter.Offset != 0 {
+				t.Errorf("offset = %d, want 0", filter.Offset)
 			}
 			return []*models.URLResponse{}, nil
 		},
@@ -266,6 +401,7 @@ func TestGetAllURLs_DefaultParams(t *testing.T) {
 	handler := NewURLHandler(mockService)
 
 	req := httptest.NewRequest("GET", "/api/v1/urls", nil)
+	req = req.WithContext(withTestUser(req.Context()))
 	w := httptest.NewRecorder()
 	handler.GetAllURLs(w, req)
 
@@ -274,11 +410,189 @@ func TestGetAllURLs_DefaultParams(t *testing.T) {
 	}
 }
 
+// TestGetAllURLs_Filters проверяет разбор фильтров и поиска списка ссылок
+func TestGetAllURLs_Filters(t *testing.T) {
+	var got models.URLFilter
+	mockService := &mockURLService{
+		getAllURLs: func(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error) {
+			got = filter
+			if filter.State == "deleted" {
+				return nil, fmt.Errorf("невалидный state: %s", filter.State)
+			}
+			return []*models.URLResponse{}, nil
+		},
+	}
+	handler := NewURLHandler(mockService)
+
+	req := httptest.NewRequest("GET", "/api/v1/urls?tag=promo&folder=Marketing&domain=example.com"+
+		"&created_from=2025-12-01&created_to=2025-12-15&state=active&q=sale&limit=20&offset=40", nil)
+	req = req.WithContext(withTestUser(req.Context()))
+	w := httptest.NewRecorder()
+	handler.GetAllURLs(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	want := models.URLFilter{
+		Limit:       20,
+		Offset:      40,
+		Tag:         "promo",
+		Folder:      "Marketing",
+		Domain:      "example.com",
+		CreatedFrom: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+		CreatedTo:   time.Date(2025, 12, 16, 0, 0, 0, 0, time.UTC),
+		State:       models.LinkStateActive,
+		Query:       "sale",
+	}
+	if got != want {
+		t.Errorf("filter = %+v, want %+v", got, want)
+	}
+
+	for _, query := range []string{"created_from=yesterday", "created_to=2025-13-01", "state=deleted"} {
+		req := httptest.NewRequest("GET", "/api/v1/urls?"+query, nil)
+		req = req.WithContext(withTestUser(req.Context()))
+		w := httptest.NewRecorder()
+		handler.GetAllURLs(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestGetAllURLs_Page проверяет ответ-страницу с курсором и обратную совместимость массива
+func TestGetAllURLs_Page(t *testing.T) {
+	var got models.URLListRequest
+	mockService := &mockURLService{
+		listURLs: func(ctx context.Context, userID int64, req models.URLListRequest) (*models.URLPage, error) {
+			got = req
+			total := int64(3)
+			return &models.URLPage{
+				Items:      []*models.URLResponse{{ID: 3}, {ID: 2}},
+				NextCursor: "next",
+				TotalCount: &total,
+			}, nil
+		},
+	}
+	handler := NewURLHandler(mockService)
+
+	req := httptest.NewRequest("GET", "/api/v1/urls?sort=-clicks_count&cursor=abc&limit=2&include_total=true&tag=promo", nil)
+	req = req.WithContext(withTestUser(req.Context()))
+	w := httptest.NewRecorder()
+	handler.GetAllURLs(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got.Sort != "-clicks_count" || got.Cursor != "abc" || !got.IncludeTotal || got.Filter.Limit != 2 || got.Filter.Tag != "promo" {
+		t.Errorf("request = %+v, want sort, cursor, total, limit and tag passed", got)
+	}
+
+	var page struct {
+		Items      []models.URLResponse `json:"items"`
+		NextCursor string               `json:"next_cursor"`
+		TotalCount int64                `json:"total_count"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(page.Items) != 2 || page.NextCursor != "next" || page.TotalCount != 3 {
+		t.Errorf("page = %+v, want 2 items, next cursor and total", page)
+	}
+
+	// Пустой cursor запрашивает первую страницу, размер по умолчанию выбирает сервис
+	req = httptest.NewRequest("GET", "/api/v1/urls?cursor=", nil)
+	req = req.WithContext(withTestUser(req.Context()))
+	w = httptest.NewRecorder()
+	handler.GetAllURLs(w, req)
+	if w.Code != http.StatusOK || got.Cursor != "" || got.Filter.Limit != 0 {
+		t.Errorf("first page: status = %d, request = %+v", w.Code, got)
+	}
+
+	for _, query := range []string{"sort=created_at&limit=abc", "cursor=&limit=0", "cursor=abc&offset=20", "include_total=maybe"} {
+		req := httptest.NewRequest("GET", "/api/v1/urls?"+query, nil)
+		req = req.WithContext(withTestUser(req.Context()))
+		w := httptest.NewRecorder()
+		handler.GetAllURLs(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestUpdateURL_Success проверяет частичное обновление ссылки
+func TestUpdateURL_Success(t *testing.T) {
+	mockService := &mockURLService{
+		updateURL: func(ctx context.Context, userID, id int64, req *models.UpdateURLRequest) (*models.URLResponse, error) {
+			if req.OriginalURL == nil || *req.OriginalURL != "https://new.example.com" {
+				t.Errorf("OriginalURL not passed to service")
+			}
+			if req.CustomCode != nil {
+				t.Errorf("CustomCode = %v, want nil", *req.CustomCode)
+			}
+			return &models.URLResponse{
+				ID:          id,
+				ShortCode:   "test123",
+				OriginalURL: *req.OriginalURL,
+			}, nil
+		},
+	}
+
+	handler := NewURLHandler(mockService)
+
+	reqBody := `{"original_url":"https://new.example.com"}`
+	req := httptest.NewRequest("PATCH", "/api/v1/urls/1", bytes.NewBufferString(reqBody))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(withTestUser(req.Context()), chi.RouteCtxKey, rctx))
+
+	w := httptest.NewRecorder()
+	handler.UpdateURL(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var response models.URLResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.OriginalURL != "https://new.example.com" {
+		t.Errorf("OriginalURL = %s, want https://new.example.com", response.OriginalURL)
+	}
+}
+
+// TestUpdateURL_DuplicateCode проверяет конфликт кастомного кода
+func TestUpdateURL_DuplicateCode(t *testing.T) {
+	mockService := &mockURLService{
+		updateURL: func(ctx context.Context, userID, id int64, req *models.UpdateURLRequest) (*models.URLResponse, error) {
+			return nil, fmt.Errorf("короткий код уже занят")
+		},
+	}
+
+	handler := NewURLHandler(mockService)
+
+	reqBody := `{"custom_code":"taken"}`
+	req := httptest.NewRequest("PATCH", "/api/v1/urls/1", bytes.NewBufferString(reqBody))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(withTestUser(req.Context()), chi.RouteCtxKey, rctx))
+
+	w := httptest.NewRecorder()
+	handler.UpdateURL(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
 // TestDeleteURL_Success проверяет успешное удаление URL
 func TestDeleteURL_Success(t *testing.T) {
 	deleted := false
 	mockService := &mockURLService{
-		deleteURL: func(ctx context.Context, id int64) error {
+		deleteURL: func(ctx context.Context, userID, id int64) error {
 			deleted = true
 			return nil
 		},
@@ -290,7 +604,7 @@ func TestDeleteURL_Success(t *testing.T) {
 
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = req.WithContext(context.WithValue(withTestUser(req.Context()), chi.RouteCtxKey, rctx))
 
 	w := httptest.NewRecorder()
 	handler.DeleteURL(w, req)
@@ -313,7 +627,7 @@ func TestDeleteURL_InvalidID(t *testing.T) {
 
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "abc")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = req.WithContext(context.WithValue(withTestUser(req.Context()), chi.RouteCtxKey, rctx))
 
 	w := httptest.NewRecorder()
 	handler.DeleteURL(w, req)
@@ -322,3 +636,51 @@ func TestDeleteURL_InvalidID(t *testing.T) {
 		t.Errorf("Status code = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
+
+// TestGetAllURLs_Unauthorized проверяет отказ без аутентификации
+func TestGetAllURLs_Unauthorized(t *testing.T) {
+	mockService := &mockURLService{
+		getAllURLs: func(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error) {
+			t.Error("GetAllURLs should not be called without user")
+			return nil, nil
+		},
+	}
+
+	handler := NewURLHandler(mockService)
+
+	req := httptest.NewRequest("GET", "/api/v1/urls", nil)
+	w := httptest.NewRecorder()
+	handler.GetAllURLs(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+// TestCreateShortURL_Owner проверяет передачу владельца при создании ссылки
+func TestCreateShortURL_Owner(t *testing.T) {
+	var gotUserID int64
+	mockService := &mockURLService{
+		createFunc: func(ctx context.Context, userID int64, req *models.CreateURLRequest) (*models.URLResponse, error) {
+			gotUserID = userID
+			return &models.URLResponse{ID: 1, OriginalURL: req.OriginalURL}, nil
+		},
+	}
+
+	handler := NewURLHandler(mockService)
+
+	reqBody := `{"original_url":"https://example.com"}`
+	req := httptest.NewRequest("POST", "/api/v1/urls", bytes.NewBufferString(reqBody))
+	req = req.WithContext(withTestUser(req.Context()))
+
+	w := httptest.NewRecorder()
+	handler.CreateShortURL(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	if gotUserID != testUserID {
+		t.Errorf("userID = %d, want %d", gotUserID, testUserID)
+	}
+}
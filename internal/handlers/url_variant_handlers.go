@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"url-short/internal/models"
+	"url-short/internal/service"
+)
+
+// URLVariantHandler обработчик для управления вариантами A/B теста ссылок
+type URLVariantHandler struct {
+	variantService service.VariantService
+}
+
+// NewURLVariantHandler создает новый url variant handler
+func NewURLVariantHandler(variantService service.VariantService) *URLVariantHandler {
+	return &URLVariantHandler{
+		variantService: variantService,
+	}
+}
+
+// CreateVariant добавляет вариант ссылке
+// POST /api/v1/urls/{id}/variants
+func (h *URLVariantHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	urlID, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CreateURLVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	variant, err := h.variantService.CreateVariant(r.Context(), userID, urlID, &req)
+	if err != nil {
+		respondWithVariantError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, variant)
+}
+
+// ListVariants получает варианты ссылки
+// GET /api/v1/urls/{id}/variants
+func (h *URLVariantHandler) ListVariants(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	urlID, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	variants, err := h.variantService.ListVariants(r.Context(), userID, urlID)
+	if err != nil {
+		respondWithVariantError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, variants)
+}
+
+// UpdateVariant частично обновляет вариант
+// PATCH /api/v1/urls/{id}/variants/{variantID}
+func (h *URLVariantHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	urlID, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+	variantID, ok := parseIDParam(w, r, "variantID")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateURLVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	variant, err := h.variantService.UpdateVariant(r.Context(), userID, urlID, variantID, &req)
+	if err != nil {
+		respondWithVariantError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, variant)
+}
+
+// DeleteVariant удаляет вариант
+// DELETE /api/v1/urls/{id}/variants/{variantID}
+func (h *URLVariantHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	urlID, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+	variantID, ok := parseIDParam(w, r, "variantID")
+	if !ok {
+		return
+	}
+
+	if err := h.variantService.DeleteVariant(r.Context(), userID, urlID, variantID); err != nil {
+		respondWithVariantError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Вариант успешно удален",
+	})
+}
+
+// respondWithVariantError выбирает статус ответа по ошибке сервиса
+func respondWithVariantError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "не найден"):
+		respondWithError(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "невалидн"):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Ошибка обработки варианта")
+	}
+}
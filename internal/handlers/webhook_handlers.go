@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"url-short/internal/models"
+	"url-short/internal/service"
+)
+
+// WebhookHandler обработчик для управления подписками webhook
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler создает новый webhook handler
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook создает подписку. Секрет подписи возвращается только в этом ответе
+// POST /api/v1/webhooks
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	response, err := h.webhookService.CreateWebhook(r.Context(), userID, &req)
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// ListWebhooks получает подписки текущего пользователя
+// GET /api/v1/webhooks
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Ошибка получения списка webhooks")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, webhooks)
+}
+
+// GetWebhook получает подписку
+// GET /api/v1/webhooks/{id}
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(r.Context(), userID, id)
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, webhook)
+}
+
+// UpdateWebhook частично обновляет подписку
+// PATCH /api/v1/webhooks/{id}
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный JSON")
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(r.Context(), userID, id, &req)
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhook удаляет подписку вместе с журналом доставок
+// DELETE /api/v1/webhooks/{id}
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), userID, id); err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Webhook успешно удален",
+	})
+}
+
+// ListDeliveries получает журнал последних доставок подписки
+// GET /api/v1/webhooks/{id}/deliveries?limit=50
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), userID, id, limit)
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// ReplayDelivery повторно ставит событие доставки в очередь
+// POST /api/v1/webhooks/{id}/deliveries/{deliveryID}/replay
+func (h *WebhookHandler) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseIDParam(w, r, "deliveryID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.ReplayDelivery(r.Context(), userID, id, deliveryID)
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, delivery)
+}
+
+// parseIDParam читает числовой параметр пути
+func parseIDParam(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Невалидный ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondWithWebhookError выбирает статус ответа по ошибке сервиса
+func respondWithWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "не найден"):
+		respondWithError(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "невалидн"):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
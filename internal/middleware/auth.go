@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"url-short/internal/models"
+)
+
+// SessionCookieName имя cookie с токеном сессии
+const SessionCookieName = "session"
+
+// contextKey тип ключей контекста, чтобы не пересекаться с другими пакетами
+type contextKey string
+
+// principalKey ключ аутентифицированного субъекта в контексте
+const principalKey contextKey = "principal"
+
+// Authenticator проверяет токен (сессии или API ключа) и возвращает субъект запроса
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*models.Principal, error)
+}
+
+// Auth middleware определяет субъект запроса по заголовку Authorization: Bearer
+// (API ключ или токен сессии) или по cookie сессии.
+// Запросы без учетных данных пропускаются анонимно
+func Auth(sessions, apiKeys Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Явно переданный токен должен быть валидным
+			if token := BearerToken(r); token != "" {
+				authenticator := sessions
+				if strings.HasPrefix(token, models.APIKeyPrefix) {
+					authenticator = apiKeys
+				}
+
+				principal, err := authenticator.Authenticate(r.Context(), token)
+				if err != nil {
+					writeJSONError(w, http.StatusUnauthorized, "Невалидный токен")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+				return
+			}
+
+			// Устаревшая cookie не мешает анонимным запросам
+			if cookie, err := r.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+				if principal, err := sessions.Authenticate(r.Context(), cookie.Value); err == nil {
+					r = r.WithContext(WithPrincipal(r.Context(), principal))
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAuth middleware отклоняет запросы без аутентифицированного субъекта
+func RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := PrincipalFromContext(r.Context()); !ok {
+			writeJSONError(w, http.StatusUnauthorized, "Требуется авторизация")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope middleware требует аутентификацию и наличие scope
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				writeJSONError(w, http.StatusUnauthorized, "Требуется авторизация")
+				return
+			}
+			if !principal.HasScope(scope) {
+				writeJSONError(w, http.StatusForbidden, "Недостаточно прав: нужен scope "+scope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// OptionalScope middleware пропускает анонимные запросы,
+// но требует scope, если запрос аутентифицирован
+func OptionalScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal, ok := PrincipalFromContext(r.Context()); ok && !principal.HasScope(scope) {
+				writeJSONError(w, http.StatusForbidden, "Недостаточно прав: нужен scope "+scope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithPrincipal добавляет субъект запроса в контекст
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// PrincipalFromContext возвращает аутентифицированный субъект запроса
+func PrincipalFromContext(ctx context.Context) (*models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*models.Principal)
+	return principal, ok && principal != nil
+}
+
+// UserIDFromContext возвращает ID аутентифицированного пользователя
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	return principal.UserID, true
+}
+
+// BearerToken извлекает токен из заголовка Authorization: Bearer
+func BearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// writeJSONError отправляет ошибку в JSON формате
+func writeJSONError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error": message}) // nolint:errcheck
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-short/internal/models"
+)
+
+// mockAuthenticator мок проверки токенов
+type mockAuthenticator struct {
+	tokens map[string]*models.Principal
+}
+
+func (m *mockAuthenticator) Authenticate(ctx context.Context, token string) (*models.Principal, error) {
+	principal, exists := m.tokens[token]
+	if !exists {
+		return nil, fmt.Errorf("токен не найден")
+	}
+	return principal, nil
+}
+
+// newTestAuth создает middleware с одной сессией и одним API ключом
+func newTestAuth() func(http.Handler) http.Handler {
+	sessions := &mockAuthenticator{tokens: map[string]*models.Principal{
+		"session-token": {UserID: 1, Scopes: models.UserScopes},
+	}}
+	apiKeys := &mockAuthenticator{tokens: map[string]*models.Principal{
+		models.APIKeyPrefix + "readonly": {UserID: 2, APIKeyID: 10, Scopes: []string{models.ScopeLinksRead}},
+	}}
+	return Auth(sessions, apiKeys)
+}
+
+// TestAuth_Scopes проверяет аутентификацию и проверку scopes
+func TestAuth_Scopes(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := newTestAuth()(RequireScope(models.ScopeLinksWrite)(okHandler))
+
+	tests := []struct {
+		name   string
+		header string
+		cookie string
+		want   int
+	}{
+		{"anonymous", "", "", http.StatusUnauthorized},
+		{"invalid bearer", "Bearer wrong", "", http.StatusUnauthorized},
+		{"session bearer", "Bearer session-token", "", http.StatusOK},
+		{"session cookie", "", "session-token", http.StatusOK},
+		{"key without scope", "Bearer " + models.APIKeyPrefix + "readonly", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/urls", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: tt.cookie})
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("Status code = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestOptionalScope проверяет пропуск анонимных запросов
+func TestOptionalScope(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := newTestAuth()(OptionalScope(models.ScopeLinksWrite)(okHandler))
+
+	req := httptest.NewRequest("POST", "/api/v1/urls", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("anonymous: Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/urls", nil)
+	req.Header.Set("Authorization", "Bearer "+models.APIKeyPrefix+"readonly")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("read-only key: Status code = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
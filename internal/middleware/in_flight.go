@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// InFlight считает запросы в обработке, чтобы при остановке дождаться их завершения
+// до освобождения ресурсов, которые им нужны (например, очереди кликов)
+type InFlight struct {
+	mu       sync.Mutex
+	active   int
+	draining bool
+	idle     chan struct{}
+	idleOnce sync.Once
+}
+
+// NewInFlight создает счетчик запросов в обработке
+func NewInFlight() *InFlight {
+	return &InFlight{idle: make(chan struct{})}
+}
+
+// Track учитывает запрос, пока он обрабатывается
+func (f *InFlight) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.active++
+		f.mu.Unlock()
+		defer f.done()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Wait ждет, пока завершатся все учтенные запросы, или отмены контекста
+func (f *InFlight) Wait(ctx context.Context) error {
+	f.mu.Lock()
+	f.draining = true
+	if f.active == 0 {
+		f.idleOnce.Do(func() { close(f.idle) })
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-f.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// done снимает запрос с учета
+func (f *InFlight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.active--
+	if f.draining && f.active == 0 {
+		f.idleOnce.Do(func() { close(f.idle) })
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestInFlight_Wait проверяет, что Wait дожидается запросов в обработке
+func TestInFlight_Wait(t *testing.T) {
+	inFlight := NewInFlight()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := inFlight.Track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/code", nil))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := inFlight.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait() with active request error = %v, want deadline exceeded", err)
+	}
+
+	close(release)
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := inFlight.Wait(ctx); err != nil {
+		t.Errorf("Wait() error = %v", err)
+	}
+}
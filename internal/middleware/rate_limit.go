@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// slidingWindowScript атомарно считает запросы по алгоритму скользящего окна:
+// оценка = счетчик предыдущего окна * доля его перекрытия + счетчик текущего окна.
+// KEYS[1] - текущее окно, KEYS[2] - предыдущее окно
+// ARGV[1] - вес предыдущего окна, ARGV[2] - лимит, ARGV[3] - TTL ключа в мс
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local estimated = math.floor(previous * tonumber(ARGV[1])) + current
+if estimated >= tonumber(ARGV[2]) then
+	return {0, estimated}
+end
+redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {1, estimated + 1}
+`)
+
+// RateLimiter ограничивает частоту запросов по клиенту (API ключ, пользователь или IP).
+// Счетчики хранятся в Redis, при его недоступности используются локальные счетчики процесса
+type RateLimiter struct {
+	redis *redis.Client
+	local *localLimiter
+
+	// Предупреждение о недоступности Redis логируется не чаще раза в минуту
+	warnMu   sync.Mutex
+	lastWarn time.Time
+}
+
+// NewRateLimiter создает rate limiter. client может быть nil - тогда лимиты только локальные
+func NewRateLimiter(client *redis.Client) *RateLimiter {
+	return &RateLimiter{
+		redis: client,
+		local: newLocalLimiter(),
+	}
+}
+
+// Limit middleware ограничивает группу маршрутов name до limit запросов за window.
+// Отвечает заголовками RateLimit-* и 429 с Retry-After при превышении
+func (l *RateLimiter) Limit(name string, limit int, window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		// Нулевой или отрицательный лимит отключает ограничение
+		if limit <= 0 || window <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := fmt.Sprintf("%s:%s", name, rateLimitClientKey(r))
+			allowed, count, reset := l.Allow(r.Context(), key, limit, window)
+
+			remaining := limit - count
+			if remaining < 0 {
+				remaining = 0
+			}
+			resetSeconds := int(math.Ceil(reset.Seconds()))
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(resetSeconds))
+
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(resetSeconds))
+				writeJSONError(w, http.StatusTooManyRequests, "Слишком много запросов, попробуйте позже")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Allow учитывает запрос для ключа и возвращает: разрешен ли запрос,
+// оценку числа запросов в окне и время до конца текущего окна
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Duration) {
+	now := time.Now()
+	index := now.UnixNano() / int64(window)
+	elapsed := time.Duration(now.UnixNano() % int64(window))
+	weight := 1 - float64(elapsed)/float64(window)
+	reset := window - elapsed
+
+	if l.redis != nil {
+		keys := []string{
+			fmt.Sprintf("ratelimit:%s:%d", key, index),
+			fmt.Sprintf("ratelimit:%s:%d", key, index-1),
+		}
+		ttl := (2 * window).Milliseconds()
+
+		result, err := slidingWindowScript.Run(ctx, l.redis, keys, weight, limit, ttl).Slice()
+		if err == nil && len(result) == 2 {
+			allowed, _ := result[0].(int64)
+			count, _ := result[1].(int64)
+			return allowed == 1, int(count), reset
+		}
+
+		// Redis недоступен - продолжаем с локальными лимитами
+		l.warnRedisUnavailable(err)
+	}
+
+	allowed, count := l.local.allow(key, index, weight, limit)
+	return allowed, count, reset
+}
+
+// warnRedisUnavailable логирует переход на локальные лимиты
+func (l *RateLimiter) warnRedisUnavailable(err error) {
+	l.warnMu.Lock()
+	defer l.warnMu.Unlock()
+
+	if time.Since(l.lastWarn) < time.Minute {
+		return
+	}
+	l.lastWarn = time.Now()
+	log.Printf("Rate limiter: Redis недоступен, используются локальные лимиты: %v", err)
+}
+
+// rateLimitClientKey определяет клиента: API ключ, пользователь или IP адрес
+func rateLimitClientKey(r *http.Request) string {
+	if principal, ok := PrincipalFromContext(r.Context()); ok {
+		if principal.IsAPIKey() {
+			return fmt.Sprintf("key:%d", principal.APIKeyID)
+		}
+		return fmt.Sprintf("user:%d", principal.UserID)
+	}
+
+	// Заголовки прокси учитываются, только если их прислал доверенный прокси (RealIP)
+	return "ip:" + ClientIP(r)
+}
+
+// localLimiter скользящее окно в памяти процесса
+type localLimiter struct {
+	mu       sync.Mutex
+	counters map[string]*windowCounter
+	calls    int
+}
+
+// windowCounter счетчики текущего и предыдущего окна
+type windowCounter struct {
+	index    int64
+	current  int
+	previous int
+}
+
+// localCleanupInterval через сколько вызовов чистить устаревшие счетчики
+const localCleanupInterval = 1000
+
+func newLocalLimiter() *localLimiter {
+	return &localLimiter{counters: make(map[string]*windowCounter)}
+}
+
+// allow учитывает запрос в локальном счетчике
+func (l *localLimiter) allow(key string, index int64, weight float64, limit int) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.calls++
+	if l.calls%localCleanupInterval == 0 {
+		for k, c := range l.counters {
+			if c.index < index-1 {
+				delete(l.counters, k)
+			}
+		}
+	}
+
+	c, exists := l.counters[key]
+	if !exists {
+		c = &windowCounter{index: index}
+		l.counters[key] = c
+	}
+
+	// Сдвигаем окна
+	switch {
+	case c.index == index-1:
+		c.previous, c.current = c.current, 0
+	case c.index < index-1:
+		c.previous, c.current = 0, 0
+	}
+	c.index = index
+
+	estimated := int(math.Floor(float64(c.previous)*weight)) + c.current
+	if estimated >= limit {
+		return false, estimated
+	}
+
+	c.current++
+	return true, estimated + 1
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// TestRateLimit_Local проверяет локальные лимиты (без Redis)
+func TestRateLimit_Local(t *testing.T) {
+	limiter := NewRateLimiter(nil)
+	handler := limiter.Limit("create", 2, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/api/v1/urls", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: Status code = %d, want %d", i+1, w.Code, http.StatusOK)
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("RateLimit-Limit = %s, want 2", w.Header().Get("RateLimit-Limit"))
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/urls", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After header should be set")
+	}
+	if w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("RateLimit-Remaining = %s, want 0", w.Header().Get("RateLimit-Remaining"))
+	}
+
+	// Другой IP и аутентифицированный клиент считаются отдельно
+	req = httptest.NewRequest("POST", "/api/v1/urls", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("other IP: Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/urls", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req = req.WithContext(WithPrincipal(req.Context(), &models.Principal{UserID: 1, APIKeyID: 5}))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("API key: Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+// TestLocalLimiter_SlidingWindow проверяет учет предыдущего окна
+func TestLocalLimiter_SlidingWindow(t *testing.T) {
+	limiter := newLocalLimiter()
+
+	// Заполняем окно 10 полностью
+	for i := 0; i < 4; i++ {
+		if allowed, _ := limiter.allow("k", 10, 1, 4); !allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+
+	// В начале окна 11 предыдущее окно учитывается почти полностью
+	if allowed, _ := limiter.allow("k", 11, 1, 4); allowed {
+		t.Error("request at start of next window should be limited")
+	}
+
+	// Ближе к концу окна 11 вес предыдущего окна мал
+	if allowed, _ := limiter.allow("k", 11, 0.2, 4); !allowed {
+		t.Error("request at end of next window should be allowed")
+	}
+
+	// Через окно счетчики сбрасываются
+	if allowed, count := limiter.allow("k", 13, 1, 4); !allowed || count != 1 {
+		t.Errorf("allow() after gap = %v, %d, want true, 1", allowed, count)
+	}
+}
+
+// TestRateLimit_Disabled проверяет отключение лимита
+func TestRateLimit_Disabled(t *testing.T) {
+	limiter := NewRateLimiter(nil)
+	handler := limiter.Limit("stats", 0, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/urls/1/stats", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Header().Get("RateLimit-Limit") != "" {
+		t.Error("RateLimit headers should not be set when limit is disabled")
+	}
+}
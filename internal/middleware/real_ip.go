@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies сети прокси, которым разрешено сообщать адрес клиента
+// в заголовках X-Forwarded-For и X-Real-IP
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies разбирает список сетей (CIDR) или отдельных адресов через запятую
+func ParseTrustedProxies(value string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("невалидный адрес доверенного прокси: %s", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("невалидная сеть доверенного прокси: %s", item)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// contains проверяет, что адрес принадлежит доверенному прокси
+func (p TrustedProxies) contains(ip net.IP) bool {
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RealIP заменяет RemoteAddr адресом клиента из заголовков прокси, только если соединение
+// пришло от доверенного прокси. Иначе заголовки игнорируются: их может прислать сам клиент.
+// В X-Forwarded-For берется самый правый адрес, не принадлежащий доверенным прокси
+func RealIP(trusted TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := trusted.clientIP(r); ip != "" {
+				r.RemoteAddr = ip
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP адрес клиента из заголовков доверенного прокси, пусто - оставить RemoteAddr
+func (p TrustedProxies) clientIP(r *http.Request) string {
+	peer := net.ParseIP(ClientIP(r))
+	if peer == nil || !p.contains(peer) {
+		return ""
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				// Дальше идут адреса, которые прокси не проверял
+				return ""
+			}
+			if !p.contains(ip) {
+				return ip.String()
+			}
+		}
+		return ""
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// ClientIP адрес клиента без порта. Заголовки прокси учитываются только через RealIP
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
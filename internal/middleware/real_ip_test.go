@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRealIP проверяет, что заголовкам прокси верят только от доверенного прокси
+func TestRealIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 127.0.0.1")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"untrusted peer spoofs header", "203.0.113.10:1234", "198.51.100.1", "198.51.100.2", "203.0.113.10"},
+		{"trusted proxy", "10.0.0.5:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"client prepends fake hop", "10.0.0.5:1234", "1.2.3.4, 198.51.100.1, 10.0.0.7", "", "198.51.100.1"},
+		{"trusted proxy with X-Real-IP", "127.0.0.1:1234", "", "198.51.100.2", "198.51.100.2"},
+		{"garbage hop", "10.0.0.5:1234", "evil, 10.0.0.7", "", "10.0.0.5"},
+		{"only proxies", "10.0.0.5:1234", "10.0.0.7", "", "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("ClientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseTrustedProxies_Invalid проверяет ошибку конфигурации
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	for _, value := range []string{"proxy.local", "10.0.0.0/33"} {
+		if _, err := ParseTrustedProxies(value); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) should fail", value)
+		}
+	}
+}
+
+// TestRateLimit_SpoofedHeader проверяет, что сменой X-Forwarded-For лимит не обойти
+func TestRateLimit_SpoofedHeader(t *testing.T) {
+	limiter := NewRateLimiter(nil)
+	handler := RealIP(nil)(limiter.Limit("auth", 1, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	for i, forwarded := range []string{"198.51.100.1", "198.51.100.2"} {
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", nil)
+		req.RemoteAddr = "203.0.113.10:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		want := http.StatusOK
+		if i > 0 {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Errorf("request %d: Status code = %d, want %d", i+1, w.Code, want)
+		}
+	}
+}
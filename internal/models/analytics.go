@@ -7,36 +7,91 @@ import (
 
 // Analytics представляет запись о клике по короткой ссылке
 type Analytics struct {
-	ID        int64          `json:"id"`
-	URLID     int64          `json:"url_id"`
-	ClickedAt time.Time      `json:"clicked_at"`
-	IPAddress sql.NullString `json:"ip_address,omitempty"`
-	UserAgent sql.NullString `json:"user_agent,omitempty"`
-	Referer   sql.NullString `json:"referer,omitempty"`
-	Country   sql.NullString `json:"country,omitempty"`
-	City      sql.NullString `json:"city,omitempty"`
+	ID         int64          `json:"id"`
+	URLID      int64          `json:"url_id"`
+	ClickedAt  time.Time      `json:"clicked_at"`
+	IPAddress  sql.NullString `json:"ip_address,omitempty"`
+	UserAgent  sql.NullString `json:"user_agent,omitempty"`
+	Referer    sql.NullString `json:"referer,omitempty"`
+	Country    sql.NullString `json:"country,omitempty"`
+	City       sql.NullString `json:"city,omitempty"`
+	Browser    sql.NullString `json:"browser,omitempty"`
+	OS         sql.NullString `json:"os,omitempty"`
+	DeviceType sql.NullString `json:"device_type,omitempty"`
+	IsBot      bool           `json:"is_bot"`
+	// VariantID вариант A/B теста, на который ушел клик
+	VariantID sql.NullInt64 `json:"variant_id,omitempty"`
 }
 
 // ClickEvent данные о клике для записи
 type ClickEvent struct {
-	URLID     int64
-	IPAddress string
-	UserAgent string
-	Referer   string
-	Country   string
-	City      string
+	URLID      int64
+	ClickedAt  time.Time
+	IPAddress  string
+	UserAgent  string
+	Referer    string
+	Country    string
+	City       string
+	Browser    string
+	OS         string
+	DeviceType string
+	IsBot      bool
+	// VariantID вариант A/B теста, выбранный при редиректе (0 - без варианта)
+	VariantID int64
 }
 
-// URLStats статистика по URL
+// Шаг группировки кликов по времени
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// StatsFilter параметры выборки статистики. Все агрегации считаются
+// по кликам в окне [From, To)
+type StatsFilter struct {
+	From        time.Time
+	To          time.Time
+	Granularity string
+	// Location часовой пояс для группировки по времени
+	Location *time.Location
+	// RecentLimit количество последних кликов в ответе
+	RecentLimit int
+	// IncludeBots включает клики ботов в счетчики и разбивки
+	IncludeBots bool
+}
+
+// URLClickCount счетчик кликов ссылки после записи пачки
+type URLClickCount struct {
+	URLID       int64
+	UserID      sql.NullInt64
+	ShortCode   string
+	ClicksCount int64
+	// Added количество кликов людей, добавленных пачкой
+	Added int64
+}
+
+// URLStats статистика по URL. BotClicks считается всегда, остальные поля зависят от StatsFilter
 type URLStats struct {
+	From            time.Time         `json:"from"`
+	To              time.Time         `json:"to"`
+	Granularity     string            `json:"granularity"`
+	Timezone        string            `json:"timezone"`
 	TotalClicks     int64             `json:"total_clicks"`
+	BotClicks       int64             `json:"bot_clicks"`
 	UniqueIPs       int64             `json:"unique_ips"`
 	ClicksByDate    []ClicksByDate    `json:"clicks_by_date"`
 	ClicksByCountry []ClicksByCountry `json:"clicks_by_country"`
+	ClicksByCity    []ClicksByCity    `json:"clicks_by_city"`
+	ClicksByBrowser []ClicksByBrowser `json:"clicks_by_browser"`
+	ClicksByOS      []ClicksByOS      `json:"clicks_by_os"`
+	ClicksByDevice  []ClicksByDevice  `json:"clicks_by_device"`
+	ClicksByVariant []ClicksByVariant `json:"clicks_by_variant"`
 	RecentClicks    []Analytics       `json:"recent_clicks"`
 }
 
-// ClicksByDate клики по датам
+// ClicksByDate клики за интервал. Date - начало интервала (RFC3339 в часовом поясе запроса)
 type ClicksByDate struct {
 	Date  string `json:"date"`
 	Count int64  `json:"count"`
@@ -47,3 +102,35 @@ type ClicksByCountry struct {
 	Country string `json:"country"`
 	Count   int64  `json:"count"`
 }
+
+// ClicksByCity клики по городам
+type ClicksByCity struct {
+	Country string `json:"country"`
+	City    string `json:"city"`
+	Count   int64  `json:"count"`
+}
+
+// ClicksByBrowser клики по семействам браузеров
+type ClicksByBrowser struct {
+	Browser string `json:"browser"`
+	Count   int64  `json:"count"`
+}
+
+// ClicksByOS клики по операционным системам
+type ClicksByOS struct {
+	OS    string `json:"os"`
+	Count int64  `json:"count"`
+}
+
+// ClicksByDevice клики по классам устройств
+type ClicksByDevice struct {
+	DeviceType string `json:"device_type"`
+	Count      int64  `json:"count"`
+}
+
+// ClicksByVariant клики по вариантам A/B теста. Name пустой, если вариант уже удален
+type ClicksByVariant struct {
+	VariantID int64  `json:"variant_id"`
+	Name      string `json:"name"`
+	Count     int64  `json:"count"`
+}
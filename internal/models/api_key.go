@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Scopes API ключей
+const (
+	ScopeLinksRead     = "links:read"
+	ScopeLinksWrite    = "links:write"
+	ScopeAnalyticsRead = "analytics:read"
+	ScopeWebhooks      = "webhooks"
+	ScopeAdmin         = "admin"
+)
+
+// APIKeyPrefix префикс API ключей, отличает их от токенов сессий
+const APIKeyPrefix = "usk_"
+
+// UserScopes права обычного пользователя, вошедшего через сессию
+var UserScopes = []string{ScopeLinksRead, ScopeLinksWrite, ScopeAnalyticsRead, ScopeWebhooks}
+
+// AllScopes все существующие scopes
+var AllScopes = []string{ScopeLinksRead, ScopeLinksWrite, ScopeAnalyticsRead, ScopeWebhooks, ScopeAdmin}
+
+// APIKey представляет API ключ для программных клиентов.
+// В БД хранится только хеш ключа
+type APIKey struct {
+	ID         int64        `json:"id"`
+	UserID     int64        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"-"`
+	Scopes     []string     `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at,omitempty"`
+	RevokedAt  sql.NullTime `json:"revoked_at,omitempty"`
+}
+
+// CreateAPIKeyRequest запрос на создание API ключа
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// APIKeyResponse ответ с информацией о ключе.
+// Key заполняется только при создании, повторно получить ключ нельзя
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Principal аутентифицированный субъект запроса: пользователь через сессию или API ключ
+type Principal struct {
+	UserID   int64
+	APIKeyID int64 // 0 для сессии пользователя
+	Scopes   []string
+}
+
+// HasScope проверяет наличие scope (admin включает все остальные)
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAPIKey проверяет, что запрос аутентифицирован API ключом
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != 0
+}
+
+// IsValidScope проверяет, что scope существует
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
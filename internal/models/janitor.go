@@ -0,0 +1,13 @@
+package models
+
+// Режимы очистки давно истекших ссылок (JANITOR_MODE)
+const (
+	// JanitorModeOff очистка отключена
+	JanitorModeOff = "off"
+	// JanitorModeArchive ссылка убирается в архив: скрыта из списка, код и аналитика сохраняются
+	JanitorModeArchive = "archive"
+	// JanitorModeSoftDelete ссылка перемещается в корзину и удаляется вместе с ней
+	JanitorModeSoftDelete = "soft_delete"
+	// JanitorModeHardDelete ссылка удаляется сразу вместе с аналитикой, код уходит в карантин
+	JanitorModeHardDelete = "hard_delete"
+)
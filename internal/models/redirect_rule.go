@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// RedirectRule правило редиректа ссылки: посетители, подходящие под все заданные
+// условия (страна, ОС, класс устройства), перенаправляются на TargetURL вместо original_url.
+// Пустой список условия подходит любому посетителю
+type RedirectRule struct {
+	ID        int64    `json:"id"`
+	URLID     int64    `json:"url_id"`
+	Priority  int      `json:"priority"`
+	Countries []string `json:"countries"` // ISO 3166-1 alpha-2 коды стран
+	OS        []string `json:"os"`        // семейства ОС из useragent.OSFamilies
+	Devices   []string `json:"devices"`   // классы устройств из useragent.Devices
+	TargetURL string   `json:"target_url"`
+	// DeepLink адрес приложения (custom scheme), который пробуется через промежуточную
+	// страницу до перехода на TargetURL
+	DeepLink  string    `json:"deep_link,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Visitor признаки посетителя, по которым выбирается правило редиректа
+type Visitor struct {
+	Country string
+	OS      string
+	Device  string
+}
+
+// Matches проверяет, подходит ли правило посетителю
+func (r *RedirectRule) Matches(visitor Visitor) bool {
+	return matchesCondition(r.Countries, visitor.Country) &&
+		matchesCondition(r.OS, visitor.OS) &&
+		matchesCondition(r.Devices, visitor.Device)
+}
+
+// matchesCondition проверяет одно условие правила. Пустой список подходит любому значению,
+// неизвестное значение посетителя (пустая строка) - только пустому списку
+func matchesCondition(allowed []string, value string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	if value == "" {
+		return false
+	}
+	for _, a := range allowed {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateRedirectRuleRequest запрос на создание правила редиректа
+type CreateRedirectRuleRequest struct {
+	Countries []string `json:"countries,omitempty"`
+	OS        []string `json:"os,omitempty"`
+	Devices   []string `json:"devices,omitempty"`
+	TargetURL string   `json:"target_url"`
+	DeepLink  string   `json:"deep_link,omitempty"`
+	Priority  int      `json:"priority,omitempty"`
+}
+
+// UpdateRedirectRuleRequest запрос на частичное обновление правила.
+// Поля, равные nil, не изменяются
+type UpdateRedirectRuleRequest struct {
+	Countries *[]string `json:"countries,omitempty"`
+	OS        *[]string `json:"os,omitempty"`
+	Devices   *[]string `json:"devices,omitempty"`
+	TargetURL *string   `json:"target_url,omitempty"`
+	DeepLink  *string   `json:"deep_link,omitempty"`
+	Priority  *int      `json:"priority,omitempty"`
+}
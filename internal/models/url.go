@@ -11,17 +11,84 @@ type URL struct {
 	ShortCode     string        `json:"short_code"`
 	OriginalURL   string        `json:"original_url"`
 	CreatedAt     time.Time     `json:"created_at"`
+	StartsAt      sql.NullTime  `json:"starts_at,omitempty"`
 	ExpiresAt     sql.NullTime  `json:"expires_at,omitempty"`
 	UserID        sql.NullInt64 `json:"user_id,omitempty"`
 	ClicksCount   int64         `json:"clicks_count"`
 	LastClickedAt sql.NullTime  `json:"last_clicked_at,omitempty"`
+	// PasswordHash bcrypt хеш пароля, без пароля ссылка открывается сразу
+	PasswordHash sql.NullString `json:"-"`
+	// MaxClicks лимит переходов, после которого ссылка перестает работать
+	MaxClicks sql.NullInt64 `json:"max_clicks,omitempty"`
+	// UsedClicks переходы, учтенные в лимите
+	UsedClicks int64 `json:"used_clicks"`
+	// StickyVariants закрепляет выбранный вариант A/B теста за посетителем
+	StickyVariants bool `json:"sticky_variants"`
+	// Title, Notes и Folder помогают найти ссылку в списке (пустая строка - не задано)
+	Title  string `json:"title"`
+	Notes  string `json:"notes"`
+	Folder string `json:"folder"`
+	// Tags теги ссылки в нижнем регистре. Загружаются только для списка и просмотра ссылки
+	Tags []string `json:"tags,omitempty"`
+	// DeletedAt время перемещения в корзину. Удаленная ссылка не открывается, но ее код занят
+	DeletedAt sql.NullTime `json:"deleted_at,omitempty"`
+	// ArchivedAt время архивации давно истекшей ссылки. Продление срока возвращает ее из архива
+	ArchivedAt sql.NullTime `json:"archived_at,omitempty"`
+}
+
+// HasPassword проверяет, защищена ли ссылка паролем
+func (u *URL) HasPassword() bool {
+	return u.PasswordHash.Valid && u.PasswordHash.String != ""
+}
+
+// HasClickLimit проверяет, ограничено ли число переходов по ссылке
+func (u *URL) HasClickLimit() bool {
+	return u.MaxClicks.Valid
+}
+
+// ClicksExhausted проверяет, исчерпан ли лимит переходов
+func (u *URL) ClicksExhausted() bool {
+	return u.MaxClicks.Valid && u.UsedClicks >= u.MaxClicks.Int64
 }
 
 // CreateURLRequest запрос на создание короткой ссылки
 type CreateURLRequest struct {
-	OriginalURL string     `json:"original_url" validate:"required,url"`
-	CustomCode  string     `json:"custom_code,omitempty"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	OriginalURL string `json:"original_url" validate:"required,url"`
+	CustomCode  string `json:"custom_code,omitempty"`
+	// StartsAt время активации, до него ссылка не работает
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Password открывает ссылку только после ввода пароля
+	Password string `json:"password,omitempty"`
+	// MaxClicks число переходов, после которого ссылка перестает работать (1 - одноразовая)
+	MaxClicks *int64 `json:"max_clicks,omitempty"`
+	// StickyVariants показывает посетителю один и тот же вариант A/B теста
+	StickyVariants bool     `json:"sticky_variants,omitempty"`
+	Title          string   `json:"title,omitempty"`
+	Notes          string   `json:"notes,omitempty"`
+	Folder         string   `json:"folder,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+}
+
+// UpdateURLRequest запрос на частичное обновление ссылки.
+// Незаполненные поля остаются без изменений
+type UpdateURLRequest struct {
+	OriginalURL    *string    `json:"original_url,omitempty"`
+	CustomCode     *string    `json:"custom_code,omitempty"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	ClearStartsAt  bool       `json:"clear_starts_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	ClearExpiresAt bool       `json:"clear_expires_at,omitempty"`
+	Password       *string    `json:"password,omitempty"`
+	ClearPassword  bool       `json:"clear_password,omitempty"`
+	MaxClicks      *int64     `json:"max_clicks,omitempty"`
+	ClearMaxClicks bool       `json:"clear_max_clicks,omitempty"`
+	StickyVariants *bool      `json:"sticky_variants,omitempty"`
+	Title          *string    `json:"title,omitempty"`
+	Notes          *string    `json:"notes,omitempty"`
+	// Folder "" убирает ссылку из папки, Tags [] снимает все теги
+	Folder *string   `json:"folder,omitempty"`
+	Tags   *[]string `json:"tags,omitempty"`
 }
 
 // URLResponse ответ с информацией о ссылке
@@ -31,6 +98,98 @@ type URLResponse struct {
 	ShortURL    string     `json:"short_url"`
 	OriginalURL string     `json:"original_url"`
 	CreatedAt   time.Time  `json:"created_at"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	ClicksCount int64      `json:"clicks_count"`
+	// PasswordProtected ссылка защищена паролем (сам пароль не возвращается)
+	PasswordProtected bool `json:"password_protected"`
+	// MaxClicks и RemainingClicks заполняются только для ссылок с лимитом переходов
+	MaxClicks       *int64   `json:"max_clicks,omitempty"`
+	RemainingClicks *int64   `json:"remaining_clicks,omitempty"`
+	StickyVariants  bool     `json:"sticky_variants"`
+	Title           string   `json:"title,omitempty"`
+	Notes           string   `json:"notes,omitempty"`
+	Folder          string   `json:"folder,omitempty"`
+	Tags            []string `json:"tags"`
+	// DeletedAt заполняется только для ссылок из корзины
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// ArchivedAt заполняется только для архивных ссылок
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// Состояния ссылки для фильтра списка
+const (
+	// LinkStateActive ссылка работает: активирована, не истекла и лимит переходов не исчерпан
+	LinkStateActive = "active"
+	// LinkStateScheduled время активации еще не наступило
+	LinkStateScheduled = "scheduled"
+	// LinkStateExpired истек срок действия или исчерпан лимит переходов
+	LinkStateExpired = "expired"
+	// LinkStateArchived давно истекшая ссылка, убранная в архив. Остальные состояния архив не включают
+	LinkStateArchived = "archived"
+)
+
+// URLFilter параметры выборки списка ссылок. Пустые поля не ограничивают выборку
+type URLFilter struct {
+	Limit  int
+	Offset int
+	// Tag и Folder точное совпадение тега и папки
+	Tag    string
+	Folder string
+	// Domain подстрока хоста original_url
+	Domain string
+	// CreatedFrom и CreatedTo окно создания [CreatedFrom, CreatedTo)
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	State       string
+	// Query подстрока original_url, короткого кода, названия или заметок
+	Query string
+	// Sort поле сортировки (URLSort*), Descending - по убыванию
+	Sort       string
+	Descending bool
+	// After позиция, после которой начинается страница (nil - с начала)
+	After *URLCursor
+	// Deleted выбирает ссылки из корзины вместо обычного списка
+	Deleted bool
+}
+
+// Поля сортировки списка ссылок. При равных значениях ссылки упорядочиваются по ID
+const (
+	URLSortCreatedAt   = "created_at"
+	URLSortClicksCount = "clicks_count"
+	// URLSortLastClicked ссылки без переходов считаются самыми давними
+	URLSortLastClicked = "last_clicked_at"
+	// URLSortDeletedAt порядок корзины, в параметре sort не принимается
+	URLSortDeletedAt = "deleted_at"
+)
+
+// URLCursor позиция в списке ссылок: значение поля сортировки и ID последней ссылки страницы
+type URLCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+// URLListRequest запрос страницы списка ссылок с keyset-пагинацией.
+// Sort - поле сортировки с необязательным префиксом "-" (по убыванию),
+// Cursor - next_cursor предыдущей страницы
+type URLListRequest struct {
+	Filter       URLFilter
+	Sort         string
+	Cursor       string
+	IncludeTotal bool
+}
+
+// URLPage страница списка ссылок. NextCursor пустой на последней странице,
+// TotalCount заполняется только по запросу
+type URLPage struct {
+	Items      []*URLResponse `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	TotalCount *int64         `json:"total_count,omitempty"`
+}
+
+// GroupCount тег или папка пользователя и количество ссылок в ней
+type GroupCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
 }
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// BulkCreateRow строка пакетного создания ссылок. ParseError - ошибка разбора строки
+// (например, невалидная дата в CSV): такая строка не создается и попадает в результат с ошибкой
+type BulkCreateRow struct {
+	Request    CreateURLRequest
+	ParseError string
+}
+
+// BulkCreateResult результат создания одной ссылки пакета
+type BulkCreateResult struct {
+	// Row номер строки запроса с 1 (в CSV - без строки заголовка)
+	Row   int          `json:"row"`
+	URL   *URLResponse `json:"url,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+// BulkCreateResponse ответ пакетного создания ссылок
+type BulkCreateResponse struct {
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Results []BulkCreateResult `json:"results"`
+}
+
+// BulkDeleteRequest запрос на удаление ссылок по ID
+type BulkDeleteRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// BulkTagsRequest запрос на изменение тегов ссылок по ID. Tags заменяет теги целиком,
+// Add и Remove добавляют и снимают отдельные теги; Tags не совмещается с Add и Remove
+type BulkTagsRequest struct {
+	IDs    []int64   `json:"ids"`
+	Tags   *[]string `json:"tags,omitempty"`
+	Add    []string  `json:"add,omitempty"`
+	Remove []string  `json:"remove,omitempty"`
+}
+
+// BulkExpiryRequest запрос на изменение срока действия ссылок по ID
+type BulkExpiryRequest struct {
+	IDs            []int64    `json:"ids"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	ClearExpiresAt bool       `json:"clear_expires_at,omitempty"`
+}
+
+// BulkResult результат операции над одной ссылкой
+type BulkResult struct {
+	ID    int64  `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+// BulkResponse ответ пакетной операции над ссылками по ID
+type BulkResponse struct {
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
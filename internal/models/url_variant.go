@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// URLVariant вариант перехода ссылки для A/B теста. Посетитель попадает на TargetURL
+// с вероятностью Weight / сумма весов вариантов ссылки. Weight = 0 ставит вариант на паузу
+type URLVariant struct {
+	ID        int64     `json:"id"`
+	URLID     int64     `json:"url_id"`
+	Name      string    `json:"name"`
+	TargetURL string    `json:"target_url"`
+	Weight    int       `json:"weight"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateURLVariantRequest запрос на создание варианта. Weight по умолчанию 1
+type CreateURLVariantRequest struct {
+	Name      string `json:"name"`
+	TargetURL string `json:"target_url"`
+	Weight    *int   `json:"weight,omitempty"`
+}
+
+// UpdateURLVariantRequest запрос на частичное обновление варианта.
+// Поля, равные nil, не изменяются
+type UpdateURLVariantRequest struct {
+	Name      *string `json:"name,omitempty"`
+	TargetURL *string `json:"target_url,omitempty"`
+	Weight    *int    `json:"weight,omitempty"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// User представляет пользователя сервиса
+type User struct {
+	ID           int64     `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	IsAdmin      bool      `json:"is_admin"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Scopes возвращает права пользователя при входе через сессию
+func (u *User) Scopes() []string {
+	if u.IsAdmin {
+		return AllScopes
+	}
+	return UserScopes
+}
+
+// Session представляет сессию пользователя.
+// В БД хранится только хеш токена
+type Session struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// RegisterRequest запрос на регистрацию
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginRequest запрос на вход
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// AuthResponse ответ с токеном сессии
+type AuthResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
+}
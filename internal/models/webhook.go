@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Типы событий webhook
+const (
+	WebhookEventLinkCreated    = "link.created"
+	WebhookEventLinkUpdated    = "link.updated"
+	WebhookEventLinkDeleted    = "link.deleted"
+	WebhookEventLinkRestored   = "link.restored"
+	WebhookEventLinkExpired    = "link.expired"
+	WebhookEventClickThreshold = "click.threshold"
+)
+
+// WebhookEventTypes все типы событий
+var WebhookEventTypes = []string{
+	WebhookEventLinkCreated,
+	WebhookEventLinkUpdated,
+	WebhookEventLinkDeleted,
+	WebhookEventLinkRestored,
+	WebhookEventLinkExpired,
+	WebhookEventClickThreshold,
+}
+
+// IsValidWebhookEvent проверяет, что тип события существует
+func IsValidWebhookEvent(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Статусы доставки webhook
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSecretPrefix префикс секрета подписи webhook
+const WebhookSecretPrefix = "whsec_"
+
+// Webhook подписка пользователя на события
+type Webhook struct {
+	ID              int64     `json:"id"`
+	UserID          int64     `json:"user_id"`
+	URL             string    `json:"url"`
+	Secret          string    `json:"-"`
+	Events          []string  `json:"events"`
+	ClickThresholds []int64   `json:"click_thresholds"`
+	Active          bool      `json:"active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Subscribes проверяет, подписан ли webhook на тип события
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWebhookRequest запрос на создание подписки
+type CreateWebhookRequest struct {
+	URL             string   `json:"url"`
+	Events          []string `json:"events"`
+	ClickThresholds []int64  `json:"click_thresholds,omitempty"`
+}
+
+// UpdateWebhookRequest запрос на частичное обновление подписки.
+// Поля, равные nil, не изменяются
+type UpdateWebhookRequest struct {
+	URL             *string   `json:"url,omitempty"`
+	Events          *[]string `json:"events,omitempty"`
+	ClickThresholds *[]int64  `json:"click_thresholds,omitempty"`
+	Active          *bool     `json:"active,omitempty"`
+}
+
+// WebhookResponse ответ на создание подписки. Секрет показывается только один раз
+type WebhookResponse struct {
+	*Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookEvent событие, отправляемое в теле запроса webhook
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// ClickThresholdData данные события click.threshold
+type ClickThresholdData struct {
+	URLID       int64  `json:"url_id"`
+	ShortCode   string `json:"short_code"`
+	Threshold   int64  `json:"threshold"`
+	ClicksCount int64  `json:"clicks_count"`
+}
+
+// WebhookDelivery попытка доставки события подписке (журнал доставок)
+type WebhookDelivery struct {
+	ID             int64         `json:"id"`
+	WebhookID      int64         `json:"webhook_id"`
+	EventID        string        `json:"event_id"`
+	EventType      string        `json:"event_type"`
+	Payload        string        `json:"payload"`
+	Status         string        `json:"status"`
+	Attempts       int           `json:"attempts"`
+	NextAttemptAt  time.Time     `json:"next_attempt_at"`
+	ResponseStatus sql.NullInt64 `json:"response_status,omitempty"`
+	LastError      string        `json:"last_error,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	DeliveredAt    sql.NullTime  `json:"delivered_at,omitempty"`
+
+	// URL и Secret подписки, заполняются при выборке доставок для отправки
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
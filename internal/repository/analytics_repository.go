@@ -4,16 +4,29 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"url-short/internal/models"
 )
 
 // AnalyticsRepository интерфейс для работы с аналитикой
 type AnalyticsRepository interface {
-	RecordClick(ctx context.Context, event *models.ClickEvent) error
-	GetStatsByURL(ctx context.Context, urlID int64, limit int) (*models.URLStats, error)
+	RecordClicks(ctx context.Context, events []*models.ClickEvent) ([]models.URLClickCount, error)
+	GetStatsByURL(ctx context.Context, urlID int64, filter models.StatsFilter) (*models.URLStats, error)
+	StreamClicks(ctx context.Context, urlID int64, filter models.StatsFilter, fn func(*models.Analytics) error) error
 }
 
+// clickExportFetchSize количество строк, читаемых из курсора за один FETCH
+const clickExportFetchSize = 1000
+
+// clickColumnsPerRow параметров запроса на один клик в RecordClicks
+const clickColumnsPerRow = 12
+
+// MaxClickBatchSize наибольшая пачка RecordClicks: PostgreSQL принимает
+// не больше 65535 параметров в одном запросе
+const MaxClickBatchSize = 65535 / clickColumnsPerRow
+
 // analyticsRepository имплементация AnalyticsRepository
 type analyticsRepository struct {
 	db *sql.DB
@@ -24,95 +37,286 @@ func NewAnalyticsRepository(db *sql.DB) AnalyticsRepository {
 	return &analyticsRepository{db: db}
 }
 
-// RecordClick записывает клик в аналитику
-func (r *analyticsRepository) RecordClick(ctx context.Context, event *models.ClickEvent) error {
-	query := `
-		INSERT INTO analytics (url_id, ip_address, user_agent, referer, country, city)
-		VALUES ($1, $2, $3, $4, $5, $6)
+// RecordClicks записывает пачку кликов одной транзакцией: multi-row INSERT в analytics
+// и одно агрегированное обновление clicks_count / last_clicked_at на каждый URL.
+// Клики ботов записываются, но не увеличивают clicks_count.
+// Клики по уже удаленным ссылкам пропускаются, чтобы не ронять всю пачку.
+// Возвращает новые значения clicks_count обновленных ссылок
+func (r *analyticsRepository) RecordClicks(ctx context.Context, events []*models.ClickEvent) ([]models.URLClickCount, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	// Вставляем клики
+	values := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events)*clickColumnsPerRow)
+	for i, event := range events {
+		n := i * clickColumnsPerRow
+		values = append(values, fmt.Sprintf(
+			"($%d::bigint, $%d::timestamp, $%d::inet, $%d::text, $%d::text, $%d::varchar, $%d::varchar, $%d::varchar, $%d::varchar, $%d::varchar, $%d::boolean, $%d::bigint)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12,
+		))
+		args = append(args,
+			event.URLID,
+			event.ClickedAt,
+			nullString(event.IPAddress),
+			nullString(event.UserAgent),
+			nullString(event.Referer),
+			nullString(event.Country),
+			nullString(event.City),
+			nullString(event.Browser),
+			nullString(event.OS),
+			nullString(event.DeviceType),
+			event.IsBot,
+			nullInt64(event.VariantID),
+		)
+	}
+
+	insertQuery := `
+		INSERT INTO analytics (url_id, clicked_at, ip_address, user_agent, referer, country, city, browser, os, device_type, is_bot, variant_id)
+		SELECT v.url_id, v.clicked_at, v.ip_address, v.user_agent, v.referer, v.country, v.city, v.browser, v.os, v.device_type, v.is_bot, v.variant_id
+		FROM (VALUES ` + strings.Join(values, ", ") + `) AS v(url_id, clicked_at, ip_address, user_agent, referer, country, city, browser, os, device_type, is_bot, variant_id)
+		WHERE EXISTS (SELECT 1 FROM urls WHERE urls.id = v.url_id)
 	`
 
-	_, err := r.db.ExecContext(
-		ctx,
-		query,
-		event.URLID,
-		nullString(event.IPAddress),
-		nullString(event.UserAgent),
-		nullString(event.Referer),
-		nullString(event.Country),
-		nullString(event.City),
-	)
+	if _, err := tx.ExecContext(ctx, insertQuery, args...); err != nil {
+		return nil, fmt.Errorf("ошибка записи кликов: %w", err)
+	}
+
+	// Агрегируем счетчики по URL (только клики людей)
+	type urlClicks struct {
+		count       int64
+		lastClicked time.Time
+	}
+	aggregated := make(map[int64]*urlClicks)
+	order := make([]int64, 0)
+	for _, event := range events {
+		if event.IsBot {
+			continue
+		}
+		agg, exists := aggregated[event.URLID]
+		if !exists {
+			agg = &urlClicks{}
+			aggregated[event.URLID] = agg
+			order = append(order, event.URLID)
+		}
+		agg.count++
+		if event.ClickedAt.After(agg.lastClicked) {
+			agg.lastClicked = event.ClickedAt
+		}
+	}
+
+	if len(order) == 0 {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+		}
+		return nil, nil
+	}
+
+	values = values[:0]
+	args = args[:0]
+	for i, urlID := range order {
+		n := i * 3
+		values = append(values, fmt.Sprintf("($%d::bigint, $%d::bigint, $%d::timestamp)", n+1, n+2, n+3))
+		args = append(args, urlID, aggregated[urlID].count, aggregated[urlID].lastClicked)
+	}
+
+	updateQuery := `
+		UPDATE urls AS u
+		SET clicks_count = u.clicks_count + v.clicks,
+		    last_clicked_at = GREATEST(u.last_clicked_at, v.last_clicked_at)
+		FROM (VALUES ` + strings.Join(values, ", ") + `) AS v(id, clicks, last_clicked_at)
+		WHERE u.id = v.id
+		RETURNING u.id, u.user_id, u.short_code, u.clicks_count, v.clicks
+	`
 
+	rows, err := tx.QueryContext(ctx, updateQuery, args...)
 	if err != nil {
-		return fmt.Errorf("ошибка записи клика: %w", err)
+		return nil, fmt.Errorf("ошибка обновления счетчиков кликов: %w", err)
 	}
 
-	return nil
+	counts := make([]models.URLClickCount, 0, len(order))
+	for rows.Next() {
+		var count models.URLClickCount
+		if err := rows.Scan(&count.URLID, &count.UserID, &count.ShortCode, &count.ClicksCount, &count.Added); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ошибка сканирования счетчиков кликов: %w", err)
+		}
+		counts = append(counts, count)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка обновления счетчиков кликов: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return counts, nil
 }
 
-// GetStatsByURL получает статистику по URL
-func (r *analyticsRepository) GetStatsByURL(ctx context.Context, urlID int64, limit int) (*models.URLStats, error) {
+// GetStatsByURL получает статистику по URL. Все агрегации считаются по кликам
+// в окне filter.From..filter.To. Клики ботов учитываются только в BotClicks,
+// если filter.IncludeBots не указан
+func (r *analyticsRepository) GetStatsByURL(ctx context.Context, urlID int64, filter models.StatsFilter) (*models.URLStats, error) {
 	stats := &models.URLStats{}
+	window, windowArgs := statsWindow(urlID, filter)
+	where := window
+	if !filter.IncludeBots {
+		where += " AND NOT is_bot"
+	}
+	q := statsQuery{where: where, args: windowArgs}
 
 	// Получаем общее количество кликов
-	if err := r.getTotalClicks(ctx, urlID, stats); err != nil {
+	if err := r.getTotalClicks(ctx, q, stats); err != nil {
+		return nil, err
+	}
+
+	// Получаем количество кликов ботов
+	if err := r.getBotClicks(ctx, statsQuery{where: window + " AND is_bot", args: windowArgs}, stats); err != nil {
 		return nil, err
 	}
 
 	// Получаем количество уникальных IP
-	if err := r.getUniqueIPs(ctx, urlID, stats); err != nil {
+	if err := r.getUniqueIPs(ctx, q, stats); err != nil {
 		return nil, err
 	}
 
-	// Получаем клики по датам
-	if err := r.getClicksByDate(ctx, urlID, stats); err != nil {
+	// Получаем клики по интервалам времени
+	if err := r.getClicksByDate(ctx, q, filter, stats); err != nil {
 		return nil, err
 	}
 
 	// Получаем клики по странам
-	if err := r.getClicksByCountry(ctx, urlID, stats); err != nil {
+	if err := r.getClicksByCountry(ctx, q, stats); err != nil {
+		return nil, err
+	}
+
+	// Получаем клики по городам
+	if err := r.getClicksByCity(ctx, q, stats); err != nil {
+		return nil, err
+	}
+
+	// Получаем клики по браузерам, ОС и устройствам
+	if err := r.getClicksByUserAgent(ctx, q, stats); err != nil {
+		return nil, err
+	}
+
+	// Получаем клики по вариантам A/B теста
+	if err := r.getClicksByVariant(ctx, q, stats); err != nil {
 		return nil, err
 	}
 
 	// Получаем последние клики
-	if err := r.getRecentClicks(ctx, urlID, limit, stats); err != nil {
+	if err := r.getRecentClicks(ctx, q, filter.RecentLimit, stats); err != nil {
 		return nil, err
 	}
 
 	return stats, nil
 }
 
+// statsQuery условие выборки кликов и его параметры
+type statsQuery struct {
+	where string
+	args  []interface{}
+}
+
+// withArgs возвращает параметры условия с дополнительными параметрами в конце
+func (q statsQuery) withArgs(extra ...interface{}) []interface{} {
+	args := make([]interface{}, 0, len(q.args)+len(extra))
+	args = append(args, q.args...)
+	return append(args, extra...)
+}
+
+// nextParam плейсхолдер первого параметра после параметров условия
+func (q statsQuery) nextParam() string {
+	return fmt.Sprintf("$%d", len(q.args)+1)
+}
+
+// statsWindow условие выборки кликов по URL в окне фильтра.
+// clicked_at хранится в UTC, поэтому границы передаются в UTC
+func statsWindow(urlID int64, filter models.StatsFilter) (string, []interface{}) {
+	where := "url_id = $1"
+	args := []interface{}{urlID}
+
+	if !filter.From.IsZero() {
+		args = append(args, filter.From.UTC())
+		where += fmt.Sprintf(" AND clicked_at >= $%d", len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To.UTC())
+		where += fmt.Sprintf(" AND clicked_at < $%d", len(args))
+	}
+
+	return where, args
+}
+
+// dateTruncUnits единицы date_trunc для шагов группировки
+var dateTruncUnits = map[string]string{
+	models.GranularityHour:  "hour",
+	models.GranularityDay:   "day",
+	models.GranularityWeek:  "week",
+	models.GranularityMonth: "month",
+}
+
 // getTotalClicks получает общее количество кликов
-func (r *analyticsRepository) getTotalClicks(ctx context.Context, urlID int64, stats *models.URLStats) error {
-	query := `SELECT COUNT(*) FROM analytics WHERE url_id = $1`
-	err := r.db.QueryRowContext(ctx, query, urlID).Scan(&stats.TotalClicks)
+func (r *analyticsRepository) getTotalClicks(ctx context.Context, q statsQuery, stats *models.URLStats) error {
+	query := `SELECT COUNT(*) FROM analytics WHERE ` + q.where
+	err := r.db.QueryRowContext(ctx, query, q.args...).Scan(&stats.TotalClicks)
 	if err != nil {
 		return fmt.Errorf("ошибка получения общего количества кликов: %w", err)
 	}
 	return nil
 }
 
+// getBotClicks получает количество кликов ботов
+func (r *analyticsRepository) getBotClicks(ctx context.Context, q statsQuery, stats *models.URLStats) error {
+	query := `SELECT COUNT(*) FROM analytics WHERE ` + q.where
+	err := r.db.QueryRowContext(ctx, query, q.args...).Scan(&stats.BotClicks)
+	if err != nil {
+		return fmt.Errorf("ошибка получения кликов ботов: %w", err)
+	}
+	return nil
+}
+
 // getUniqueIPs получает количество уникальных IP адресов
-func (r *analyticsRepository) getUniqueIPs(ctx context.Context, urlID int64, stats *models.URLStats) error {
-	query := `SELECT COUNT(DISTINCT ip_address) FROM analytics WHERE url_id = $1 AND ip_address IS NOT NULL`
-	err := r.db.QueryRowContext(ctx, query, urlID).Scan(&stats.UniqueIPs)
+func (r *analyticsRepository) getUniqueIPs(ctx context.Context, q statsQuery, stats *models.URLStats) error {
+	query := `SELECT COUNT(DISTINCT ip_address) FROM analytics WHERE ` + q.where + ` AND ip_address IS NOT NULL`
+	err := r.db.QueryRowContext(ctx, query, q.args...).Scan(&stats.UniqueIPs)
 	if err != nil {
 		return fmt.Errorf("ошибка получения уникальных IP: %w", err)
 	}
 	return nil
 }
 
-// getClicksByDate получает клики сгруппированные по датам
-func (r *analyticsRepository) getClicksByDate(ctx context.Context, urlID int64, stats *models.URLStats) error {
+// getClicksByDate получает клики сгруппированные по интервалам filter.Granularity
+// в часовом поясе filter.Location. Пустые интервалы не возвращаются
+func (r *analyticsRepository) getClicksByDate(ctx context.Context, q statsQuery, filter models.StatsFilter, stats *models.URLStats) error {
+	unit, ok := dateTruncUnits[filter.Granularity]
+	if !ok {
+		unit = dateTruncUnits[models.GranularityDay]
+	}
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
 	query := `
-		SELECT DATE(clicked_at) as date, COUNT(*) as count
+		SELECT date_trunc('` + unit + `', (clicked_at AT TIME ZONE 'UTC') AT TIME ZONE ` + q.nextParam() + `) AS bucket, COUNT(*) as count
 		FROM analytics
-		WHERE url_id = $1
-		GROUP BY DATE(clicked_at)
-		ORDER BY date DESC
-		LIMIT 30
+		WHERE ` + q.where + `
+		GROUP BY bucket
+		ORDER BY bucket
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, urlID)
+	rows, err := r.db.QueryContext(ctx, query, q.withArgs(loc.String())...)
 	if err != nil {
 		return fmt.Errorf("ошибка получения кликов по датам: %w", err)
 	}
@@ -120,10 +324,13 @@ func (r *analyticsRepository) getClicksByDate(ctx context.Context, urlID int64,
 
 	stats.ClicksByDate = []models.ClicksByDate{}
 	for rows.Next() {
+		var bucket time.Time
 		var item models.ClicksByDate
-		if err := rows.Scan(&item.Date, &item.Count); err != nil {
+		if err := rows.Scan(&bucket, &item.Count); err != nil {
 			return fmt.Errorf("ошибка сканирования кликов по датам: %w", err)
 		}
+		// date_trunc возвращает локальное время без пояса, восстанавливаем пояс
+		item.Date = time.Date(bucket.Year(), bucket.Month(), bucket.Day(), bucket.Hour(), 0, 0, 0, loc).Format(time.RFC3339)
 		stats.ClicksByDate = append(stats.ClicksByDate, item)
 	}
 
@@ -131,17 +338,17 @@ func (r *analyticsRepository) getClicksByDate(ctx context.Context, urlID int64,
 }
 
 // getClicksByCountry получает клики сгруппированные по странам
-func (r *analyticsRepository) getClicksByCountry(ctx context.Context, urlID int64, stats *models.URLStats) error {
+func (r *analyticsRepository) getClicksByCountry(ctx context.Context, q statsQuery, stats *models.URLStats) error {
 	query := `
 		SELECT country, COUNT(*) as count
 		FROM analytics
-		WHERE url_id = $1 AND country IS NOT NULL
+		WHERE ` + q.where + ` AND country IS NOT NULL
 		GROUP BY country
 		ORDER BY count DESC
 		LIMIT 10
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, urlID)
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
 		return fmt.Errorf("ошибка получения кликов по странам: %w", err)
 	}
@@ -159,17 +366,146 @@ func (r *analyticsRepository) getClicksByCountry(ctx context.Context, urlID int6
 	return rows.Err()
 }
 
+// getClicksByCity получает клики сгруппированные по городам
+func (r *analyticsRepository) getClicksByCity(ctx context.Context, q statsQuery, stats *models.URLStats) error {
+	query := `
+		SELECT COALESCE(country, ''), city, COUNT(*) as count
+		FROM analytics
+		WHERE ` + q.where + ` AND city IS NOT NULL
+		GROUP BY country, city
+		ORDER BY count DESC
+		LIMIT 10
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return fmt.Errorf("ошибка получения кликов по городам: %w", err)
+	}
+	defer rows.Close()
+
+	stats.ClicksByCity = []models.ClicksByCity{}
+	for rows.Next() {
+		var item models.ClicksByCity
+		if err := rows.Scan(&item.Country, &item.City, &item.Count); err != nil {
+			return fmt.Errorf("ошибка сканирования кликов по городам: %w", err)
+		}
+		stats.ClicksByCity = append(stats.ClicksByCity, item)
+	}
+
+	return rows.Err()
+}
+
+// getClicksByUserAgent получает клики сгруппированные по браузерам, ОС и классам устройств
+func (r *analyticsRepository) getClicksByUserAgent(ctx context.Context, q statsQuery, stats *models.URLStats) error {
+	browsers, err := r.countByColumn(ctx, q, "browser")
+	if err != nil {
+		return fmt.Errorf("ошибка получения кликов по браузерам: %w", err)
+	}
+	stats.ClicksByBrowser = make([]models.ClicksByBrowser, 0, len(browsers))
+	for _, item := range browsers {
+		stats.ClicksByBrowser = append(stats.ClicksByBrowser, models.ClicksByBrowser{Browser: item.value, Count: item.count})
+	}
+
+	systems, err := r.countByColumn(ctx, q, "os")
+	if err != nil {
+		return fmt.Errorf("ошибка получения кликов по ОС: %w", err)
+	}
+	stats.ClicksByOS = make([]models.ClicksByOS, 0, len(systems))
+	for _, item := range systems {
+		stats.ClicksByOS = append(stats.ClicksByOS, models.ClicksByOS{OS: item.value, Count: item.count})
+	}
+
+	devices, err := r.countByColumn(ctx, q, "device_type")
+	if err != nil {
+		return fmt.Errorf("ошибка получения кликов по устройствам: %w", err)
+	}
+	stats.ClicksByDevice = make([]models.ClicksByDevice, 0, len(devices))
+	for _, item := range devices {
+		stats.ClicksByDevice = append(stats.ClicksByDevice, models.ClicksByDevice{DeviceType: item.value, Count: item.count})
+	}
+
+	return nil
+}
+
+// getClicksByVariant получает клики сгруппированные по вариантам A/B теста.
+// Название берется из url_variants, у удаленных вариантов оно пустое
+func (r *analyticsRepository) getClicksByVariant(ctx context.Context, q statsQuery, stats *models.URLStats) error {
+	query := `
+		SELECT c.variant_id, COALESCE(v.name, ''), c.count
+		FROM (
+			SELECT variant_id, COUNT(*) as count
+			FROM analytics
+			WHERE ` + q.where + ` AND variant_id IS NOT NULL
+			GROUP BY variant_id
+		) c
+		LEFT JOIN url_variants v ON v.id = c.variant_id
+		ORDER BY c.count DESC, c.variant_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return fmt.Errorf("ошибка получения кликов по вариантам: %w", err)
+	}
+	defer rows.Close()
+
+	stats.ClicksByVariant = []models.ClicksByVariant{}
+	for rows.Next() {
+		var item models.ClicksByVariant
+		if err := rows.Scan(&item.VariantID, &item.Name, &item.Count); err != nil {
+			return fmt.Errorf("ошибка сканирования кликов по вариантам: %w", err)
+		}
+		stats.ClicksByVariant = append(stats.ClicksByVariant, item)
+	}
+
+	return rows.Err()
+}
+
+// columnCount значение колонки и количество кликов с ним
+type columnCount struct {
+	value string
+	count int64
+}
+
+// countByColumn группирует клики по колонке analytics. column должен быть константой из кода
+func (r *analyticsRepository) countByColumn(ctx context.Context, q statsQuery, column string) ([]columnCount, error) {
+	query := `
+		SELECT ` + column + `, COUNT(*) as count
+		FROM analytics
+		WHERE ` + q.where + ` AND ` + column + ` IS NOT NULL
+		GROUP BY ` + column + `
+		ORDER BY count DESC
+		LIMIT 10
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []columnCount{}
+	for rows.Next() {
+		var item columnCount
+		if err := rows.Scan(&item.value, &item.count); err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+
+	return result, rows.Err()
+}
+
 // getRecentClicks получает последние клики
-func (r *analyticsRepository) getRecentClicks(ctx context.Context, urlID int64, limit int, stats *models.URLStats) error {
+func (r *analyticsRepository) getRecentClicks(ctx context.Context, q statsQuery, limit int, stats *models.URLStats) error {
 	query := `
-		SELECT id, url_id, clicked_at, ip_address, user_agent, referer, country, city
+		SELECT id, url_id, clicked_at, ip_address, user_agent, referer, country, city, browser, os, device_type, is_bot, variant_id
 		FROM analytics
-		WHERE url_id = $1
+		WHERE ` + q.where + `
 		ORDER BY clicked_at DESC
-		LIMIT $2
+		LIMIT ` + q.nextParam() + `
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, urlID, limit)
+	rows, err := r.db.QueryContext(ctx, query, q.withArgs(limit)...)
 	if err != nil {
 		return fmt.Errorf("ошибка получения последних кликов: %w", err)
 	}
@@ -187,6 +523,11 @@ func (r *analyticsRepository) getRecentClicks(ctx context.Context, urlID int64,
 			&item.Referer,
 			&item.Country,
 			&item.City,
+			&item.Browser,
+			&item.OS,
+			&item.DeviceType,
+			&item.IsBot,
+			&item.VariantID,
 		); err != nil {
 			return fmt.Errorf("ошибка сканирования последних кликов: %w", err)
 		}
@@ -196,6 +537,89 @@ func (r *analyticsRepository) getRecentClicks(ctx context.Context, urlID int64,
 	return rows.Err()
 }
 
+// StreamClicks передает в fn все клики URL из окна фильтра по возрастанию времени.
+// Строки читаются серверным курсором пачками по clickExportFetchSize, поэтому
+// память не зависит от количества кликов. Ошибка fn прерывает чтение
+func (r *analyticsRepository) StreamClicks(ctx context.Context, urlID int64, filter models.StatsFilter, fn func(*models.Analytics) error) error {
+	where, args := statsWindow(urlID, filter)
+	if !filter.IncludeBots {
+		where += " AND NOT is_bot"
+	}
+
+	// Курсор живет только внутри транзакции
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	declareQuery := `
+		DECLARE click_export NO SCROLL CURSOR FOR
+		SELECT id, url_id, clicked_at, ip_address, user_agent, referer, country, city, browser, os, device_type, is_bot, variant_id
+		FROM analytics
+		WHERE ` + where + `
+		ORDER BY clicked_at, id
+	`
+	if _, err := tx.ExecContext(ctx, declareQuery, args...); err != nil {
+		return fmt.Errorf("ошибка открытия курсора кликов: %w", err)
+	}
+
+	fetchQuery := fmt.Sprintf("FETCH FORWARD %d FROM click_export", clickExportFetchSize)
+	for {
+		fetched, err := r.fetchClicks(ctx, tx, fetchQuery, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < clickExportFetchSize {
+			break
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "CLOSE click_export"); err != nil {
+		return fmt.Errorf("ошибка закрытия курсора кликов: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// fetchClicks читает одну пачку строк курсора и возвращает их количество
+func (r *analyticsRepository) fetchClicks(ctx context.Context, tx *sql.Tx, query string, fn func(*models.Analytics) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения курсора кликов: %w", err)
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		var item models.Analytics
+		if err := rows.Scan(
+			&item.ID,
+			&item.URLID,
+			&item.ClickedAt,
+			&item.IPAddress,
+			&item.UserAgent,
+			&item.Referer,
+			&item.Country,
+			&item.City,
+			&item.Browser,
+			&item.OS,
+			&item.DeviceType,
+			&item.IsBot,
+			&item.VariantID,
+		); err != nil {
+			return fetched, fmt.Errorf("ошибка сканирования клика: %w", err)
+		}
+		fetched++
+
+		if err := fn(&item); err != nil {
+			return fetched, err
+		}
+	}
+
+	return fetched, rows.Err()
+}
+
 // nullString конвертирует string в sql.NullString
 func nullString(s string) sql.NullString {
 	if s == "" {
@@ -203,3 +627,8 @@ func nullString(s string) sql.NullString {
 	}
 	return sql.NullString{String: s, Valid: true}
 }
+
+// nullInt64 конвертирует int64 в sql.NullInt64 (0 - NULL)
+func nullInt64(n int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: n, Valid: n != 0}
+}
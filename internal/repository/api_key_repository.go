@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"url-short/internal/models"
+)
+
+// APIKeyRepository интерфейс для работы с API ключами в БД
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	GetByUser(ctx context.Context, userID int64) ([]*models.APIKey, error)
+	Revoke(ctx context.Context, userID, id int64) error
+	TouchLastUsed(ctx context.Context, id int64) error
+}
+
+// apiKeyRepository имплементация APIKeyRepository
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository создает новый APIKey repository
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create сохраняет новый API ключ
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		pq.Array(key.Scopes),
+	).Scan(&key.ID, &key.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("ошибка создания API ключа: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash получает активный (не отозванный) ключ по хешу
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+
+	key := &models.APIKey{}
+	err := r.db.QueryRowContext(ctx, query, keyHash).Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		pq.Array(&key.Scopes),
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("API ключ не найден")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения API ключа: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetByUser получает все ключи пользователя, включая отозванные
+func (r *apiKeyRepository) GetByUser(ctx context.Context, userID int64) ([]*models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка API ключей: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key := &models.APIKey{}
+		if err := rows.Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			pq.Array(&key.Scopes),
+			&key.CreatedAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования API ключа: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка итерации по результатам: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke отзывает ключ пользователя
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID, id int64) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва API ключа: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка проверки отзыва: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("API ключ с ID %d не найден", id)
+	}
+
+	return nil
+}
+
+// TouchLastUsed обновляет время последнего использования ключа
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	query := `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("ошибка обновления API ключа: %w", err)
+	}
+
+	return nil
+}
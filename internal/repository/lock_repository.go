@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// LockRepository advisory lock PostgreSQL для фоновых задач, которые в каждый момент
+// должен выполнять только один инстанс
+type LockRepository interface {
+	// TryLock берет lock без ожидания. false - lock держит другой инстанс.
+	// unlock снимает lock и возвращает соединение в пул
+	TryLock(ctx context.Context, key int64) (unlock func() error, ok bool, err error)
+}
+
+// lockRepository имплементация LockRepository
+type lockRepository struct {
+	db *sql.DB
+}
+
+// NewLockRepository создает новый Lock repository
+func NewLockRepository(db *sql.DB) LockRepository {
+	return &lockRepository{db: db}
+}
+
+// TryLock берет сессионный advisory lock. Lock принадлежит соединению, поэтому оно
+// удерживается вне пула до unlock; при обрыве соединения PostgreSQL снимает lock сам
+func (r *lockRepository) TryLock(ctx context.Context, key int64) (func() error, bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("ошибка получения соединения: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Close() // nolint:errcheck
+		return nil, false, fmt.Errorf("ошибка захвата advisory lock: %w", err)
+	}
+	if !locked {
+		conn.Close() // nolint:errcheck
+		return nil, false, nil
+	}
+
+	unlock := func() error {
+		defer conn.Close() // nolint:errcheck
+
+		// Контекст задачи к этому моменту может быть отменен, а lock нужно снять
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			// Соединение с неснятым lock нельзя возвращать в пул: закрываем его, и lock снимется
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn }) // nolint:errcheck
+			return fmt.Errorf("ошибка снятия advisory lock: %w", err)
+		}
+		return nil
+	}
+
+	return unlock, true, nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"url-short/internal/models"
+)
+
+// RedirectRuleRepository интерфейс для работы с правилами редиректа в БД
+type RedirectRuleRepository interface {
+	Create(ctx context.Context, rule *models.RedirectRule) error
+	GetByID(ctx context.Context, urlID, id int64) (*models.RedirectRule, error)
+	GetByURL(ctx context.Context, urlID int64) ([]*models.RedirectRule, error)
+	Update(ctx context.Context, rule *models.RedirectRule) error
+	Delete(ctx context.Context, urlID, id int64) error
+}
+
+// redirectRuleRepository имплементация RedirectRuleRepository
+type redirectRuleRepository struct {
+	db *sql.DB
+}
+
+// NewRedirectRuleRepository создает новый RedirectRule repository
+func NewRedirectRuleRepository(db *sql.DB) RedirectRuleRepository {
+	return &redirectRuleRepository{db: db}
+}
+
+// redirectRuleColumns колонки правила в порядке scanRedirectRule
+const redirectRuleColumns = `id, url_id, priority, countries, os, devices, target_url, deep_link, created_at, updated_at`
+
+// Create сохраняет новое правило
+func (r *redirectRuleRepository) Create(ctx context.Context, rule *models.RedirectRule) error {
+	query := `
+		INSERT INTO redirect_rules (url_id, priority, countries, os, devices, target_url, deep_link)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		rule.URLID,
+		rule.Priority,
+		pq.Array(rule.Countries),
+		pq.Array(rule.OS),
+		pq.Array(rule.Devices),
+		rule.TargetURL,
+		rule.DeepLink,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("ошибка создания правила: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID получает правило ссылки по ID
+func (r *redirectRuleRepository) GetByID(ctx context.Context, urlID, id int64) (*models.RedirectRule, error) {
+	query := `SELECT ` + redirectRuleColumns + ` FROM redirect_rules WHERE id = $1 AND url_id = $2`
+
+	rule, err := scanRedirectRule(r.db.QueryRowContext(ctx, query, id, urlID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("правило с ID %d не найдено", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения правила: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetByURL получает правила ссылки в порядке проверки
+func (r *redirectRuleRepository) GetByURL(ctx context.Context, urlID int64) ([]*models.RedirectRule, error) {
+	query := `SELECT ` + redirectRuleColumns + ` FROM redirect_rules WHERE url_id = $1 ORDER BY priority, id`
+
+	rows, err := r.db.QueryContext(ctx, query, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения правил: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]*models.RedirectRule, 0)
+	for rows.Next() {
+		rule, err := scanRedirectRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования правила: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка итерации по результатам: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Update обновляет условия, адреса и приоритет правила
+func (r *redirectRuleRepository) Update(ctx context.Context, rule *models.RedirectRule) error {
+	query := `
+		UPDATE redirect_rules
+		SET priority = $1, countries = $2, os = $3, devices = $4, target_url = $5, deep_link = $6,
+		    updated_at = NOW()
+		WHERE id = $7 AND url_id = $8
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		rule.Priority,
+		pq.Array(rule.Countries),
+		pq.Array(rule.OS),
+		pq.Array(rule.Devices),
+		rule.TargetURL,
+		rule.DeepLink,
+		rule.ID,
+		rule.URLID,
+	).Scan(&rule.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("правило с ID %d не найдено", rule.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обновления правила: %w", err)
+	}
+
+	return nil
+}
+
+// Delete удаляет правило ссылки
+func (r *redirectRuleRepository) Delete(ctx context.Context, urlID, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM redirect_rules WHERE id = $1 AND url_id = $2`, id, urlID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления правила: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка проверки удаления: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("правило с ID %d не найдено", id)
+	}
+
+	return nil
+}
+
+// scanRedirectRule читает правило из строки результата с колонками redirectRuleColumns
+func scanRedirectRule(row rowScanner) (*models.RedirectRule, error) {
+	rule := &models.RedirectRule{}
+	err := row.Scan(
+		&rule.ID,
+		&rule.URLID,
+		&rule.Priority,
+		pq.Array(&rule.Countries),
+		pq.Array(&rule.OS),
+		pq.Array(&rule.Devices),
+		&rule.TargetURL,
+		&rule.DeepLink,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
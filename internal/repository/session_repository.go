@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"url-short/internal/models"
+)
+
+// SessionRepository интерфейс для работы с сессиями пользователей
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	DeleteByTokenHash(ctx context.Context, tokenHash string) error
+}
+
+// sessionRepository имплементация SessionRepository
+type sessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository создает новый Session repository
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create создает новую сессию
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	query := `
+		INSERT INTO sessions (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		session.UserID,
+		session.TokenHash,
+		session.ExpiresAt,
+	).Scan(&session.ID, &session.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("ошибка создания сессии: %w", err)
+	}
+
+	return nil
+}
+
+// GetByTokenHash получает активную сессию по хешу токена
+func (r *sessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	query := `
+		SELECT id, user_id, token_hash, created_at, expires_at
+		FROM sessions
+		WHERE token_hash = $1 AND expires_at > NOW()
+	`
+
+	session := &models.Session{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.TokenHash,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("сессия не найдена")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сессии: %w", err)
+	}
+
+	return session, nil
+}
+
+// DeleteByTokenHash удаляет сессию (выход из аккаунта)
+func (r *sessionRepository) DeleteByTokenHash(ctx context.Context, tokenHash string) error {
+	query := `DELETE FROM sessions WHERE token_hash = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, tokenHash); err != nil {
+		return fmt.Errorf("ошибка удаления сессии: %w", err)
+	}
+
+	return nil
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 
 	"url-short/internal/models"
 )
@@ -11,13 +15,49 @@ import (
 // URLRepository интерфейс для работы с URL в БД
 type URLRepository interface {
 	Create(ctx context.Context, url *models.URL) error
+	CreateBatch(ctx context.Context, urls []*models.URL) ([]error, error)
 	GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error)
 	GetByID(ctx context.Context, id int64) (*models.URL, error)
-	GetAll(ctx context.Context, limit, offset int) ([]*models.URL, error)
+	GetAll(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URL, error)
+	CountAll(ctx context.Context, userID int64, filter models.URLFilter) (int64, error)
 	Update(ctx context.Context, url *models.URL) error
 	Delete(ctx context.Context, id int64) error
+	Restore(ctx context.Context, userID, id int64) (*models.URL, error)
+	PurgeDeleted(ctx context.Context, retention, quarantine time.Duration, limit int) (int, error)
+	CleanupExpired(ctx context.Context, mode string, grace, quarantine time.Duration, limit int) ([]*models.URL, error)
 	IncrementClicks(ctx context.Context, id int64) error
 	ShortCodeExists(ctx context.Context, shortCode string) (bool, error)
+	ClaimExpired(ctx context.Context, limit int) ([]*models.URL, error)
+	ConsumeClick(ctx context.Context, id int64) (int64, bool, error)
+	ListTags(ctx context.Context, userID int64) ([]models.GroupCount, error)
+	ListFolders(ctx context.Context, userID int64) ([]models.GroupCount, error)
+}
+
+// urlColumns колонки ссылки в порядке scanURL
+const urlColumns = `id, short_code, original_url, created_at, expires_at, user_id, clicks_count, last_clicked_at,
+	password_hash, max_clicks, used_clicks, starts_at, sticky_variants, title, notes, folder, deleted_at,
+	archived_at`
+
+// Выражения поиска по ссылкам. Должны совпадать с выражениями триграммных индексов
+// idx_urls_search_trgm и idx_urls_domain_trgm, иначе поиск не использует индекс
+const (
+	urlSearchExpr = `lower(original_url || ' ' || short_code || ' ' || title || ' ' || notes)`
+	urlDomainExpr = `lower(split_part(split_part(original_url, '://', 2), '/', 1))`
+)
+
+// urlSortKey выражение поля сортировки и тип, к которому приводится значение курсора
+type urlSortKey struct {
+	expr string
+	cast string
+}
+
+// urlSortKeys поля сортировки списка ссылок. Выражения должны совпадать с индексами
+// keyset-пагинации (миграция 000015)
+var urlSortKeys = map[string]urlSortKey{
+	models.URLSortCreatedAt:   {expr: "created_at", cast: "timestamp"},
+	models.URLSortClicksCount: {expr: "clicks_count", cast: "bigint"},
+	models.URLSortLastClicked: {expr: "COALESCE(last_clicked_at, '1970-01-01 00:00:00'::timestamp)", cast: "timestamp"},
+	models.URLSortDeletedAt:   {expr: "deleted_at", cast: "timestamp"},
 }
 
 // urlRepository имплементация URLRepository
@@ -30,49 +70,107 @@ func NewURLRepository(db *sql.DB) URLRepository {
 	return &urlRepository{db: db}
 }
 
-// Create создает новую короткую ссылку
+// Create создает новую короткую ссылку вместе с тегами
 func (r *urlRepository) Create(ctx context.Context, url *models.URL) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	if err := insertURL(ctx, tx, url); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return nil
+}
+
+// CreateBatch создает ссылки одной транзакцией. Каждая ссылка вставляется под своей точкой
+// сохранения, поэтому ошибка одной ссылки (например, код, занятый параллельным запросом)
+// откатывает только ее. Возвращает ошибки по ссылкам в порядке urls (nil - ссылка создана)
+// и общую ошибку, если транзакция не состоялась целиком
+func (r *urlRepository) CreateBatch(ctx context.Context, urls []*models.URL) ([]error, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	errs := make([]error, len(urls))
+	for i, url := range urls {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT bulk_url`); err != nil {
+			return nil, fmt.Errorf("ошибка создания точки сохранения: %w", err)
+		}
+
+		if err := insertURL(ctx, tx, url); err != nil {
+			if _, rbErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT bulk_url`); rbErr != nil {
+				return nil, fmt.Errorf("ошибка отката к точке сохранения: %w", rbErr)
+			}
+			url.ID = 0
+			errs[i] = err
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT bulk_url`); err != nil {
+			return nil, fmt.Errorf("ошибка освобождения точки сохранения: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return errs, nil
+}
+
+// insertURL вставляет ссылку и ее теги в транзакции
+func insertURL(ctx context.Context, tx *sql.Tx, url *models.URL) error {
 	query := `
-		INSERT INTO urls (short_code, original_url, expires_at, user_id)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at, clicks_count
+		INSERT INTO urls (short_code, original_url, expires_at, user_id, password_hash, max_clicks, starts_at, sticky_variants,
+		                  title, notes, folder)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, clicks_count, used_clicks
 	`
 
-	err := r.db.QueryRowContext(
+	err := tx.QueryRowContext(
 		ctx,
 		query,
 		url.ShortCode,
 		url.OriginalURL,
 		url.ExpiresAt,
 		url.UserID,
-	).Scan(&url.ID, &url.CreatedAt, &url.ClicksCount)
-
+		url.PasswordHash,
+		url.MaxClicks,
+		url.StartsAt,
+		url.StickyVariants,
+		url.Title,
+		url.Notes,
+		url.Folder,
+	).Scan(&url.ID, &url.CreatedAt, &url.ClicksCount, &url.UsedClicks)
+
+	if isUniqueViolation(err) {
+		return fmt.Errorf("короткий код уже занят")
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка создания URL: %w", err)
 	}
 
-	return nil
+	return replaceURLTags(ctx, tx, url)
 }
 
-// GetByShortCode получает URL по короткому коду
+// GetByShortCode получает URL по короткому коду. Ссылки из корзины не находятся
 func (r *urlRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
 	query := `
-		SELECT id, short_code, original_url, created_at, expires_at, user_id, clicks_count, last_clicked_at
+		SELECT ` + urlColumns + `
 		FROM urls
-		WHERE short_code = $1
+		WHERE short_code = $1 AND deleted_at IS NULL
 	`
 
-	url := &models.URL{}
-	err := r.db.QueryRowContext(ctx, query, shortCode).Scan(
-		&url.ID,
-		&url.ShortCode,
-		&url.OriginalURL,
-		&url.CreatedAt,
-		&url.ExpiresAt,
-		&url.UserID,
-		&url.ClicksCount,
-		&url.LastClickedAt,
-	)
+	url, err := scanURL(r.db.QueryRowContext(ctx, query, shortCode))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("URL с кодом %s не найден", shortCode)
@@ -85,25 +183,15 @@ func (r *urlRepository) GetByShortCode(ctx context.Context, shortCode string) (*
 	return url, nil
 }
 
-// GetByID получает URL по ID
+// GetByID получает URL по ID вместе с тегами. Ссылки из корзины не находятся
 func (r *urlRepository) GetByID(ctx context.Context, id int64) (*models.URL, error) {
 	query := `
-		SELECT id, short_code, original_url, created_at, expires_at, user_id, clicks_count, last_clicked_at
+		SELECT ` + urlColumns + `
 		FROM urls
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	url := &models.URL{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&url.ID,
-		&url.ShortCode,
-		&url.OriginalURL,
-		&url.CreatedAt,
-		&url.ExpiresAt,
-		&url.UserID,
-		&url.ClicksCount,
-		&url.LastClickedAt,
-	)
+	url, err := scanURL(r.db.QueryRowContext(ctx, query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("URL с ID %d не найден", id)
@@ -113,19 +201,45 @@ func (r *urlRepository) GetByID(ctx context.Context, id int64) (*models.URL, err
 		return nil, fmt.Errorf("ошибка получения URL: %w", err)
 	}
 
+	if err := r.loadTags(ctx, []*models.URL{url}); err != nil {
+		return nil, err
+	}
+
 	return url, nil
 }
 
-// GetAll получает список всех URL с пагинацией
-func (r *urlRepository) GetAll(ctx context.Context, limit, offset int) ([]*models.URL, error) {
-	query := `
-		SELECT id, short_code, original_url, created_at, expires_at, user_id, clicks_count, last_clicked_at
+// GetAll получает страницу URL пользователя по фильтру. Без сортировки в фильтре новые первыми.
+// Если задан filter.After, страница начинается сразу после этой позиции (keyset-пагинация)
+func (r *urlRepository) GetAll(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URL, error) {
+	sortField := filter.Sort
+	descending := filter.Descending
+	if sortField == "" {
+		sortField, descending = models.URLSortCreatedAt, true
+	}
+	key, ok := urlSortKeys[sortField]
+	if !ok {
+		return nil, fmt.Errorf("невалидное поле сортировки: %s", sortField)
+	}
+	direction, compare := "ASC", ">"
+	if descending {
+		direction, compare = "DESC", "<"
+	}
+
+	where, args := urlFilterCondition(userID, filter)
+	if filter.After != nil {
+		args = append(args, filter.After.Value, filter.After.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d::%s, $%d)", key.expr, compare, len(args)-1, key.cast, len(args))
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT `+urlColumns+`
 		FROM urls
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT $%d OFFSET $%d
+	`, where, key.expr, direction, direction, len(args)-1, len(args))
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения списка URL: %w", err)
 	}
@@ -133,17 +247,7 @@ func (r *urlRepository) GetAll(ctx context.Context, limit, offset int) ([]*model
 
 	var urls []*models.URL
 	for rows.Next() {
-		url := &models.URL{}
-		err := rows.Scan(
-			&url.ID,
-			&url.ShortCode,
-			&url.OriginalURL,
-			&url.CreatedAt,
-			&url.ExpiresAt,
-			&url.UserID,
-			&url.ClicksCount,
-			&url.LastClickedAt,
-		)
+		url, err := scanURL(rows)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка сканирования URL: %w", err)
 		}
@@ -154,37 +258,250 @@ func (r *urlRepository) GetAll(ctx context.Context, limit, offset int) ([]*model
 		return nil, fmt.Errorf("ошибка итерации по результатам: %w", err)
 	}
 
+	if err := r.loadTags(ctx, urls); err != nil {
+		return nil, err
+	}
+
 	return urls, nil
 }
 
-// Update обновляет URL
+// CountAll считает ссылки пользователя, подходящие под фильтр, без учета пагинации
+func (r *urlRepository) CountAll(ctx context.Context, userID int64, filter models.URLFilter) (int64, error) {
+	where, args := urlFilterCondition(userID, filter)
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM urls WHERE `+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчета URL: %w", err)
+	}
+
+	return count, nil
+}
+
+// urlFilterCondition условие выборки ссылок пользователя по фильтру и его параметры
+func urlFilterCondition(userID int64, filter models.URLFilter) (string, []interface{}) {
+	conditions := []string{"user_id = $1"}
+	switch {
+	case filter.Deleted:
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	case filter.State == models.LinkStateArchived:
+		conditions = append(conditions, "deleted_at IS NULL", "archived_at IS NOT NULL")
+	default:
+		conditions = append(conditions, "deleted_at IS NULL", "archived_at IS NULL")
+	}
+	args := []interface{}{userID}
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Tag != "" {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM url_tags ut JOIN tags t ON t.id = ut.tag_id
+			WHERE ut.url_id = urls.id AND t.name = `+param(filter.Tag)+`)`)
+	}
+	if filter.Folder != "" {
+		conditions = append(conditions, "folder = "+param(filter.Folder))
+	}
+	if filter.Domain != "" {
+		conditions = append(conditions, urlDomainExpr+" LIKE "+param(containsPattern(filter.Domain)))
+	}
+	// created_at хранится в UTC, поэтому границы передаются в UTC
+	if !filter.CreatedFrom.IsZero() {
+		conditions = append(conditions, "created_at >= "+param(filter.CreatedFrom.UTC()))
+	}
+	if !filter.CreatedTo.IsZero() {
+		conditions = append(conditions, "created_at < "+param(filter.CreatedTo.UTC()))
+	}
+	if filter.Query != "" {
+		conditions = append(conditions, urlSearchExpr+" LIKE "+param(containsPattern(filter.Query)))
+	}
+
+	const expired = "(expires_at <= NOW() OR used_clicks >= max_clicks)"
+	switch filter.State {
+	case models.LinkStateActive:
+		conditions = append(conditions, "NOT COALESCE("+expired+", FALSE)", "(starts_at IS NULL OR starts_at <= NOW())")
+	case models.LinkStateScheduled:
+		conditions = append(conditions, "NOT COALESCE("+expired+", FALSE)", "starts_at > NOW()")
+	case models.LinkStateExpired:
+		conditions = append(conditions, expired)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// containsPattern шаблон LIKE для поиска подстроки без учета регистра.
+// Спецсимволы LIKE в значении экранируются
+func containsPattern(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(value))
+	return "%" + escaped + "%"
+}
+
+// loadTags загружает теги ссылок одним запросом. Ссылки без тегов получают пустой список
+func (r *urlRepository) loadTags(ctx context.Context, urls []*models.URL) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(urls))
+	byID := make(map[int64]*models.URL, len(urls))
+	for _, url := range urls {
+		url.Tags = []string{}
+		ids = append(ids, url.ID)
+		byID[url.ID] = url
+	}
+
+	query := `
+		SELECT ut.url_id, t.name
+		FROM url_tags ut
+		JOIN tags t ON t.id = ut.tag_id
+		WHERE ut.url_id = ANY($1)
+		ORDER BY t.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("ошибка получения тегов: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var urlID int64
+		var name string
+		if err := rows.Scan(&urlID, &name); err != nil {
+			return fmt.Errorf("ошибка сканирования тега: %w", err)
+		}
+		if url, ok := byID[urlID]; ok {
+			url.Tags = append(url.Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+// replaceURLTags заменяет теги ссылки на url.Tags, создавая новые теги владельца.
+// Tags == nil оставляет теги без изменений, у анонимных ссылок тегов нет
+func replaceURLTags(ctx context.Context, tx *sql.Tx, url *models.URL) error {
+	if url.Tags == nil || !url.UserID.Valid {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM url_tags WHERE url_id = $1`, url.ID); err != nil {
+		return fmt.Errorf("ошибка обновления тегов: %w", err)
+	}
+	if len(url.Tags) == 0 {
+		return nil
+	}
+
+	insertTags := `
+		INSERT INTO tags (user_id, name)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT (user_id, name) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, insertTags, url.UserID.Int64, pq.Array(url.Tags)); err != nil {
+		return fmt.Errorf("ошибка создания тегов: %w", err)
+	}
+
+	linkTags := `
+		INSERT INTO url_tags (url_id, tag_id)
+		SELECT $1, id FROM tags WHERE user_id = $2 AND name = ANY($3)
+	`
+	if _, err := tx.ExecContext(ctx, linkTags, url.ID, url.UserID.Int64, pq.Array(url.Tags)); err != nil {
+		return fmt.Errorf("ошибка обновления тегов: %w", err)
+	}
+
+	return nil
+}
+
+// ListTags получает теги пользователя, которые есть хотя бы у одной ссылки, по имени
+func (r *urlRepository) ListTags(ctx context.Context, userID int64) ([]models.GroupCount, error) {
+	query := `
+		SELECT t.name, COUNT(*)
+		FROM tags t
+		JOIN url_tags ut ON ut.tag_id = t.id
+		JOIN urls u ON u.id = ut.url_id AND u.deleted_at IS NULL
+		WHERE t.user_id = $1
+		GROUP BY t.name
+		ORDER BY t.name
+	`
+	return r.listGroups(ctx, query, userID)
+}
+
+// ListFolders получает папки пользователя по имени
+func (r *urlRepository) ListFolders(ctx context.Context, userID int64) ([]models.GroupCount, error) {
+	query := `
+		SELECT folder, COUNT(*)
+		FROM urls
+		WHERE user_id = $1 AND folder <> '' AND deleted_at IS NULL
+		GROUP BY folder
+		ORDER BY folder
+	`
+	return r.listGroups(ctx, query, userID)
+}
+
+// listGroups выполняет запрос, возвращающий имя группы и количество ссылок
+func (r *urlRepository) listGroups(ctx context.Context, query string, userID int64) ([]models.GroupCount, error) {
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения групп ссылок: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []models.GroupCount{}
+	for rows.Next() {
+		var group models.GroupCount
+		if err := rows.Scan(&group.Name, &group.Count); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования группы ссылок: %w", err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+// Update обновляет URL и его теги
 func (r *urlRepository) Update(ctx context.Context, url *models.URL) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
 	query := `
 		UPDATE urls
-		SET original_url = $1, expires_at = $2
-		WHERE id = $3
+		SET short_code = $1, original_url = $2, expires_at = $3, password_hash = $4, max_clicks = $5,
+		    starts_at = $6, sticky_variants = $7, title = $8, notes = $9, folder = $10,
+		    expiry_notified = expiry_notified AND expires_at IS NOT DISTINCT FROM $3
+		        AND max_clicks IS NOT DISTINCT FROM $5,
+		    archived_at = CASE WHEN expires_at IS NOT DISTINCT FROM $3 THEN archived_at END
+		WHERE id = $11 AND deleted_at IS NULL
+		RETURNING archived_at
 	`
 
-	result, err := r.db.ExecContext(ctx, query, url.OriginalURL, url.ExpiresAt, url.ID)
+	// Новый срок действия возвращает ссылку из архива
+	err = tx.QueryRowContext(ctx, query,
+		url.ShortCode, url.OriginalURL, url.ExpiresAt, url.PasswordHash, url.MaxClicks, url.StartsAt,
+		url.StickyVariants, url.Title, url.Notes, url.Folder, url.ID).Scan(&url.ArchivedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("URL с ID %d не найден", url.ID)
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка обновления URL: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("ошибка проверки обновления: %w", err)
+	if err := replaceURLTags(ctx, tx, url); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("URL с ID %d не найден", url.ID)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
 	}
 
 	return nil
 }
 
-// Delete удаляет URL
+// Delete перемещает URL в корзину. Ссылка, ее теги и аналитика сохраняются до PurgeDeleted
 func (r *urlRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM urls WHERE id = $1`
+	query := `UPDATE urls SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
 
 	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
@@ -203,6 +520,155 @@ func (r *urlRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// Restore возвращает ссылку пользователя из корзины
+func (r *urlRepository) Restore(ctx context.Context, userID, id int64) (*models.URL, error) {
+	query := `
+		UPDATE urls
+		SET deleted_at = NULL
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+		RETURNING ` + urlColumns
+
+	url, err := scanURL(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("URL с ID %d не найден в корзине", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка восстановления URL: %w", err)
+	}
+
+	if err := r.loadTags(ctx, []*models.URL{url}); err != nil {
+		return nil, err
+	}
+
+	return url, nil
+}
+
+// PurgeDeleted окончательно удаляет до limit ссылок, пролежавших в корзине дольше retention,
+// вместе с аналитикой. Их коды уходят в карантин до deleted_at + quarantine.
+// Заодно освобождает коды с истекшим карантином. Возвращает число удаленных ссылок
+func (r *urlRepository) PurgeDeleted(ctx context.Context, retention, quarantine time.Duration, limit int) (int, error) {
+	query := `
+		WITH purged AS (
+			DELETE FROM urls
+			WHERE id IN (
+				SELECT id FROM urls
+				WHERE deleted_at < NOW() - make_interval(secs => $1)
+				ORDER BY deleted_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING short_code, deleted_at
+		), retired AS (
+			INSERT INTO retired_codes (short_code, available_at)
+			SELECT short_code, deleted_at + make_interval(secs => $2)
+			FROM purged
+			WHERE deleted_at + make_interval(secs => $2) > NOW()
+			ON CONFLICT (short_code) DO UPDATE
+				SET available_at = GREATEST(retired_codes.available_at, EXCLUDED.available_at)
+		)
+		SELECT COUNT(*) FROM purged
+	`
+
+	var purged int
+	err := r.db.QueryRowContext(ctx, query, retention.Seconds(), quarantine.Seconds(), limit).Scan(&purged)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки корзины: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM retired_codes WHERE available_at <= NOW()`); err != nil {
+		return purged, fmt.Errorf("ошибка освобождения кодов из карантина: %w", err)
+	}
+
+	return purged, nil
+}
+
+// cleanupExpiredQueries запросы CleanupExpired по режимам очистки: $1 - grace в секундах,
+// $2 - размер пачки, $3 - карантин кода в секундах (только для окончательного удаления).
+// Истекшие по лимиту переходов ссылки не трогаются: их можно вернуть, подняв лимит
+var cleanupExpiredQueries = map[string]string{
+	models.JanitorModeArchive: `
+		UPDATE urls
+		SET archived_at = NOW()
+		WHERE id IN (
+			SELECT id FROM urls
+			WHERE expires_at < NOW() - make_interval(secs => $1)
+			  AND deleted_at IS NULL AND archived_at IS NULL
+			ORDER BY expires_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + urlColumns,
+	models.JanitorModeSoftDelete: `
+		UPDATE urls
+		SET deleted_at = NOW()
+		WHERE id IN (
+			SELECT id FROM urls
+			WHERE expires_at < NOW() - make_interval(secs => $1)
+			  AND deleted_at IS NULL
+			ORDER BY expires_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + urlColumns,
+	models.JanitorModeHardDelete: `
+		WITH removed AS (
+			DELETE FROM urls
+			WHERE id IN (
+				SELECT id FROM urls
+				WHERE expires_at < NOW() - make_interval(secs => $1) AND deleted_at IS NULL
+				ORDER BY expires_at
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + urlColumns + `
+		), retired AS (
+			INSERT INTO retired_codes (short_code, available_at)
+			SELECT short_code, NOW() + make_interval(secs => $3)
+			FROM removed
+			WHERE $3 > 0
+			ON CONFLICT (short_code) DO UPDATE
+				SET available_at = GREATEST(retired_codes.available_at, EXCLUDED.available_at)
+		)
+		SELECT ` + urlColumns + ` FROM removed`,
+}
+
+// CleanupExpired архивирует, перемещает в корзину или окончательно удаляет (по mode) до limit
+// ссылок, срок которых истек больше grace назад. Ссылки из корзины не трогаются, их удаляет
+// PurgeDeleted. Коды окончательно удаленных ссылок уходят в карантин на quarantine.
+// Возвращает обработанные ссылки
+func (r *urlRepository) CleanupExpired(ctx context.Context, mode string, grace, quarantine time.Duration, limit int) ([]*models.URL, error) {
+	query, ok := cleanupExpiredQueries[mode]
+	if !ok {
+		return nil, fmt.Errorf("невалидный режим очистки: %s", mode)
+	}
+
+	args := []interface{}{grace.Seconds(), limit}
+	if mode == models.JanitorModeHardDelete {
+		args = append(args, quarantine.Seconds())
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка очистки истекших URL: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования URL: %w", err)
+		}
+		urls = append(urls, url)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка итерации по результатам: %w", err)
+	}
+
+	return urls, nil
+}
+
 // IncrementClicks увеличивает счетчик кликов
 func (r *urlRepository) IncrementClicks(ctx context.Context, id int64) error {
 	query := `
@@ -220,9 +686,13 @@ func (r *urlRepository) IncrementClicks(ctx context.Context, id int64) error {
 	return nil
 }
 
-// ShortCodeExists проверяет существование короткого кода
+// ShortCodeExists проверяет, занят ли короткий код: ссылкой (в том числе из корзины)
+// или карантином окончательно удаленной ссылки
 func (r *urlRepository) ShortCodeExists(ctx context.Context, shortCode string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = $1)`
+	query := `
+		SELECT EXISTS(SELECT 1 FROM urls WHERE short_code = $1)
+		    OR EXISTS(SELECT 1 FROM retired_codes WHERE short_code = $1 AND available_at > NOW())
+	`
 
 	var exists bool
 	err := r.db.QueryRowContext(ctx, query, shortCode).Scan(&exists)
@@ -232,3 +702,96 @@ func (r *urlRepository) ShortCodeExists(ctx context.Context, shortCode string) (
 
 	return exists, nil
 }
+
+// ClaimExpired отмечает истекшие (по сроку или лимиту переходов) ссылки с владельцем
+// как уведомленные и возвращает их. Отметка и выборка выполняются одним запросом,
+// поэтому каждая ссылка достается только одному инстансу
+func (r *urlRepository) ClaimExpired(ctx context.Context, limit int) ([]*models.URL, error) {
+	query := `
+		UPDATE urls
+		SET expiry_notified = TRUE
+		WHERE id IN (
+			SELECT id FROM urls
+			WHERE (expires_at <= NOW() OR used_clicks >= max_clicks)
+			  AND NOT expiry_notified AND user_id IS NOT NULL AND deleted_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + urlColumns + `
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки истекших URL: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования URL: %w", err)
+		}
+		urls = append(urls, url)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка итерации по результатам: %w", err)
+	}
+
+	return urls, nil
+}
+
+// ConsumeClick учитывает переход в лимите ссылки и возвращает новое число переходов.
+// Проверка и увеличение выполняются одним UPDATE, поэтому параллельные переходы
+// не превышают лимит. false - лимит уже исчерпан
+func (r *urlRepository) ConsumeClick(ctx context.Context, id int64) (int64, bool, error) {
+	query := `
+		UPDATE urls
+		SET used_clicks = used_clicks + 1
+		WHERE id = $1 AND (max_clicks IS NULL OR used_clicks < max_clicks)
+		RETURNING used_clicks
+	`
+
+	var used int64
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&used)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, fmt.Errorf("ошибка учета перехода: %w", err)
+	}
+
+	return used, true, nil
+}
+
+// scanURL читает ссылку из строки результата с колонками urlColumns
+func scanURL(row rowScanner) (*models.URL, error) {
+	url := &models.URL{}
+	err := row.Scan(
+		&url.ID,
+		&url.ShortCode,
+		&url.OriginalURL,
+		&url.CreatedAt,
+		&url.ExpiresAt,
+		&url.UserID,
+		&url.ClicksCount,
+		&url.LastClickedAt,
+		&url.PasswordHash,
+		&url.MaxClicks,
+		&url.UsedClicks,
+		&url.StartsAt,
+		&url.StickyVariants,
+		&url.Title,
+		&url.Notes,
+		&url.Folder,
+		&url.DeletedAt,
+		&url.ArchivedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return url, nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"url-short/internal/models"
+)
+
+// URLVariantRepository интерфейс для работы с вариантами A/B теста в БД
+type URLVariantRepository interface {
+	Create(ctx context.Context, variant *models.URLVariant) error
+	GetByID(ctx context.Context, urlID, id int64) (*models.URLVariant, error)
+	GetByURL(ctx context.Context, urlID int64) ([]*models.URLVariant, error)
+	Update(ctx context.Context, variant *models.URLVariant) error
+	Delete(ctx context.Context, urlID, id int64) error
+}
+
+// urlVariantRepository имплементация URLVariantRepository
+type urlVariantRepository struct {
+	db *sql.DB
+}
+
+// NewURLVariantRepository создает новый URLVariant repository
+func NewURLVariantRepository(db *sql.DB) URLVariantRepository {
+	return &urlVariantRepository{db: db}
+}
+
+// urlVariantColumns колонки варианта в порядке scanURLVariant
+const urlVariantColumns = `id, url_id, name, target_url, weight, created_at, updated_at`
+
+// Create сохраняет новый вариант
+func (r *urlVariantRepository) Create(ctx context.Context, variant *models.URLVariant) error {
+	query := `
+		INSERT INTO url_variants (url_id, name, target_url, weight)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		variant.URLID,
+		variant.Name,
+		variant.TargetURL,
+		variant.Weight,
+	).Scan(&variant.ID, &variant.CreatedAt, &variant.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("ошибка создания варианта: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID получает вариант ссылки по ID
+func (r *urlVariantRepository) GetByID(ctx context.Context, urlID, id int64) (*models.URLVariant, error) {
+	query := `SELECT ` + urlVariantColumns + ` FROM url_variants WHERE id = $1 AND url_id = $2`
+
+	variant, err := scanURLVariant(r.db.QueryRowContext(ctx, query, id, urlID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("вариант с ID %d не найден", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения варианта: %w", err)
+	}
+
+	return variant, nil
+}
+
+// GetByURL получает варианты ссылки в порядке создания
+func (r *urlVariantRepository) GetByURL(ctx context.Context, urlID int64) ([]*models.URLVariant, error) {
+	query := `SELECT ` + urlVariantColumns + ` FROM url_variants WHERE url_id = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения вариантов: %w", err)
+	}
+	defer rows.Close()
+
+	variants := make([]*models.URLVariant, 0)
+	for rows.Next() {
+		variant, err := scanURLVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования варианта: %w", err)
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка итерации по результатам: %w", err)
+	}
+
+	return variants, nil
+}
+
+// Update обновляет название, адрес и вес варианта
+func (r *urlVariantRepository) Update(ctx context.Context, variant *models.URLVariant) error {
+	query := `
+		UPDATE url_variants
+		SET name = $1, target_url = $2, weight = $3, updated_at = NOW()
+		WHERE id = $4 AND url_id = $5
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		variant.Name,
+		variant.TargetURL,
+		variant.Weight,
+		variant.ID,
+		variant.URLID,
+	).Scan(&variant.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("вариант с ID %d не найден", variant.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обновления варианта: %w", err)
+	}
+
+	return nil
+}
+
+// Delete удаляет вариант ссылки. Клики варианта в аналитике сохраняются
+func (r *urlVariantRepository) Delete(ctx context.Context, urlID, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM url_variants WHERE id = $1 AND url_id = $2`, id, urlID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления варианта: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка проверки удаления: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("вариант с ID %d не найден", id)
+	}
+
+	return nil
+}
+
+// scanURLVariant читает вариант из строки результата с колонками urlVariantColumns
+func scanURLVariant(row rowScanner) (*models.URLVariant, error) {
+	variant := &models.URLVariant{}
+	err := row.Scan(
+		&variant.ID,
+		&variant.URLID,
+		&variant.Name,
+		&variant.TargetURL,
+		&variant.Weight,
+		&variant.CreatedAt,
+		&variant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"url-short/internal/models"
+)
+
+// UserRepository интерфейс для работы с пользователями в БД
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id int64) (*models.User, error)
+}
+
+// userRepository имплементация UserRepository
+type userRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository создает новый User repository
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+// Create создает нового пользователя
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	query := `
+		INSERT INTO users (email, password_hash)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, user.Email, user.PasswordHash).Scan(&user.ID, &user.CreatedAt)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("email %s уже занят", user.Email)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка создания пользователя: %w", err)
+	}
+
+	return nil
+}
+
+// GetByEmail получает пользователя по email
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `
+		SELECT id, email, password_hash, is_admin, created_at
+		FROM users
+		WHERE email = $1
+	`
+
+	user := &models.User{}
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.IsAdmin,
+		&user.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("пользователь с email %s не найден", email)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetByID получает пользователя по ID
+func (r *userRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	query := `
+		SELECT id, email, password_hash, is_admin, created_at
+		FROM users
+		WHERE id = $1
+	`
+
+	user := &models.User{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.IsAdmin,
+		&user.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("пользователь с ID %d не найден", id)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	return user, nil
+}
+
+// isUniqueViolation проверяет, что ошибка - нарушение UNIQUE ограничения
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
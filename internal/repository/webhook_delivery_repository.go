@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// WebhookDeliveryRepository интерфейс для журнала доставок webhook
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	GetByID(ctx context.Context, webhookID, id int64) (*models.WebhookDelivery, error)
+	GetByWebhook(ctx context.Context, webhookID int64, limit int) ([]*models.WebhookDelivery, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	MarkSucceeded(ctx context.Context, id int64, responseStatus int) error
+	MarkFailed(ctx context.Context, id int64, responseStatus int, lastError string, nextAttemptAt *time.Time) error
+}
+
+// webhookDeliveryRepository имплементация WebhookDeliveryRepository
+type webhookDeliveryRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookDeliveryRepository создает новый WebhookDelivery repository
+func NewWebhookDeliveryRepository(db *sql.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db: db}
+}
+
+// deliveryColumns колонки доставки в порядке scanDelivery
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.response_status, COALESCE(d.last_error, ''), d.created_at, d.delivered_at`
+
+// Create сохраняет доставки одним запросом
+func (r *webhookDeliveryRepository) Create(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	const columnsPerRow = 4
+	values := make([]string, 0, len(deliveries))
+	args := make([]interface{}, 0, len(deliveries)*columnsPerRow)
+	for i, delivery := range deliveries {
+		n := i * columnsPerRow
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4))
+		args = append(args, delivery.WebhookID, delivery.EventID, delivery.EventType, delivery.Payload)
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		VALUES ` + strings.Join(values, ", ") + `
+		RETURNING id, status, attempts, next_attempt_at, created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("ошибка создания доставок webhook: %w", err)
+	}
+	defer rows.Close()
+
+	// RETURNING возвращает строки в порядке VALUES
+	for i := 0; rows.Next(); i++ {
+		d := deliveries[i]
+		if err := rows.Scan(&d.ID, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt); err != nil {
+			return fmt.Errorf("ошибка сканирования доставки webhook: %w", err)
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetByID получает доставку подписки по ID
+func (r *webhookDeliveryRepository) GetByID(ctx context.Context, webhookID, id int64) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d WHERE d.id = $1 AND d.webhook_id = $2`
+
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, query, id, webhookID), false)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("доставка с ID %d не найдена", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения доставки webhook: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// GetByWebhook получает последние доставки подписки
+func (r *webhookDeliveryRepository) GetByWebhook(ctx context.Context, webhookID int64, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.webhook_id = $1
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения доставок webhook: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows, false)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования доставки webhook: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// ClaimDue забирает доставки, время отправки которых наступило, и откладывает их
+// на lease, чтобы другие инстансы не отправили их повторно. Счетчик попыток
+// увеличивается сразу: упавший во время отправки процесс тоже тратит попытку
+func (r *webhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM due, webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id
+		RETURNING ` + deliveryColumns + `, w.url, w.secret
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, int64(lease/time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки доставок webhook: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows, true)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования доставки webhook: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// MarkSucceeded отмечает доставку успешной
+func (r *webhookDeliveryRepository) MarkSucceeded(ctx context.Context, id int64, responseStatus int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'succeeded', response_status = $1, last_error = NULL, delivered_at = NOW()
+		WHERE id = $2
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, responseStatus, id); err != nil {
+		return fmt.Errorf("ошибка обновления доставки webhook: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed сохраняет ошибку попытки. Если nextAttemptAt равен nil,
+// попытки исчерпаны и доставка помечается неуспешной
+func (r *webhookDeliveryRepository) MarkFailed(ctx context.Context, id int64, responseStatus int, lastError string, nextAttemptAt *time.Time) error {
+	status := models.WebhookDeliveryFailed
+	next := time.Now().UTC()
+	if nextAttemptAt != nil {
+		status = models.WebhookDeliveryPending
+		next = nextAttemptAt.UTC()
+	}
+
+	var response sql.NullInt64
+	if responseStatus != 0 {
+		response = sql.NullInt64{Int64: int64(responseStatus), Valid: true}
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, response_status = $2, last_error = $3, next_attempt_at = $4
+		WHERE id = $5
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, status, response, lastError, next, id); err != nil {
+		return fmt.Errorf("ошибка обновления доставки webhook: %w", err)
+	}
+	return nil
+}
+
+// scanDelivery читает доставку из строки результата. withTarget - строка
+// дополнительно содержит url и secret подписки
+func scanDelivery(row rowScanner, withTarget bool) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{}
+	dest := []interface{}{
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.ResponseStatus,
+		&d.LastError,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	}
+	if withTarget {
+		dest = append(dest, &d.URL, &d.Secret)
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"url-short/internal/models"
+)
+
+// WebhookRepository интерфейс для работы с подписками webhook в БД
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	GetByID(ctx context.Context, userID, id int64) (*models.Webhook, error)
+	GetByUser(ctx context.Context, userID int64) ([]*models.Webhook, error)
+	GetActiveByEvent(ctx context.Context, userID int64, eventType string) ([]*models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) error
+	Delete(ctx context.Context, userID, id int64) error
+}
+
+// webhookRepository имплементация WebhookRepository
+type webhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository создает новый Webhook repository
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// webhookColumns колонки подписки в порядке scanWebhook
+const webhookColumns = `id, user_id, url, secret, events, click_thresholds, active, created_at, updated_at`
+
+// Create сохраняет новую подписку
+func (r *webhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (user_id, url, secret, events, click_thresholds, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		webhook.UserID,
+		webhook.URL,
+		webhook.Secret,
+		pq.Array(webhook.Events),
+		pq.Array(webhook.ClickThresholds),
+		webhook.Active,
+	).Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("ошибка создания webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID получает подписку пользователя по ID
+func (r *webhookRepository) GetByID(ctx context.Context, userID, id int64) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND user_id = $2`
+
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook с ID %d не найден", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// GetByUser получает все подписки пользователя
+func (r *webhookRepository) GetByUser(ctx context.Context, userID int64) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = $1 ORDER BY created_at DESC`
+
+	return r.query(ctx, query, userID)
+}
+
+// GetActiveByEvent получает активные подписки пользователя на тип события
+func (r *webhookRepository) GetActiveByEvent(ctx context.Context, userID int64, eventType string) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = $1 AND active AND $2 = ANY(events)`
+
+	return r.query(ctx, query, userID, eventType)
+}
+
+// Update обновляет адрес, события, пороги кликов и активность подписки
+func (r *webhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $1, events = $2, click_thresholds = $3, active = $4, updated_at = NOW()
+		WHERE id = $5 AND user_id = $6
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		webhook.URL,
+		pq.Array(webhook.Events),
+		pq.Array(webhook.ClickThresholds),
+		webhook.Active,
+		webhook.ID,
+		webhook.UserID,
+	).Scan(&webhook.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("webhook с ID %d не найден", webhook.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обновления webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Delete удаляет подписку пользователя вместе с журналом доставок
+func (r *webhookRepository) Delete(ctx context.Context, userID, id int64) error {
+	query := `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка проверки удаления: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook с ID %d не найден", id)
+	}
+
+	return nil
+}
+
+// query выполняет запрос списка подписок
+func (r *webhookRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*models.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// rowScanner общий интерфейс sql.Row и sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhook читает подписку из строки результата
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		pq.Array(&webhook.Events),
+		pq.Array(&webhook.ClickThresholds),
+		&webhook.Active,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
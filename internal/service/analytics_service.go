@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"url-short/internal/models"
 	"url-short/internal/repository"
@@ -9,58 +11,105 @@ import (
 
 // AnalyticsService интерфейс для бизнес-логики аналитики
 type AnalyticsService interface {
-	RecordClick(ctx context.Context, urlID int64, ipAddress, userAgent, referer string) error
-	GetURLStats(ctx context.Context, urlID int64) (*models.URLStats, error)
+	RecordClick(ctx context.Context, event *models.ClickEvent) error
+	GetURLStats(ctx context.Context, userID, urlID int64, filter models.StatsFilter) (*models.URLStats, error)
+	ExportClicks(ctx context.Context, userID, urlID int64, filter models.StatsFilter, fn func(*models.Analytics) error) error
+	SubscribeClicks(ctx context.Context, userID, urlID int64) (*ClickSubscription, error)
+	SubscribeAllClicks() (*ClickSubscription, error)
+	PipelineStats() ClickPipelineStats
 }
 
 // analyticsService имплементация AnalyticsService
 type analyticsService struct {
 	analyticsRepo repository.AnalyticsRepository
 	urlRepo       repository.URLRepository
+	clicks        *ClickPipeline
+	stream        *ClickStream
 }
 
-// NewAnalyticsService создает новый Analytics service
+// NewAnalyticsService создает новый Analytics service.
+// Клики записываются асинхронно через конвейер clicks, а записанные клики
+// доступны в реальном времени через stream
 func NewAnalyticsService(
 	analyticsRepo repository.AnalyticsRepository,
 	urlRepo repository.URLRepository,
+	clicks *ClickPipeline,
+	stream *ClickStream,
 ) AnalyticsService {
 	return &analyticsService{
 		analyticsRepo: analyticsRepo,
 		urlRepo:       urlRepo,
+		clicks:        clicks,
+		stream:        stream,
 	}
 }
 
-// RecordClick записывает клик и увеличивает счетчик
-func (s *analyticsService) RecordClick(ctx context.Context, urlID int64, ipAddress, userAgent, referer string) error {
-	// Записываем клик в аналитику
-	event := &models.ClickEvent{
-		URLID:     urlID,
-		IPAddress: ipAddress,
-		UserAgent: userAgent,
-		Referer:   referer,
-		// Country и City можно определить через GeoIP сервис
-		// Для простоты пока оставим пустыми
+// RecordClick ставит клик в очередь на запись, не дожидаясь БД.
+// event содержит данные запроса; геолокация, разбор User-Agent и признак бота
+// заполняются enricher'ами в конвейере. Счетчик кликов URL увеличивается при записи пачки
+func (s *analyticsService) RecordClick(ctx context.Context, event *models.ClickEvent) error {
+	if event.ClickedAt.IsZero() {
+		event.ClickedAt = time.Now().UTC()
 	}
 
-	if err := s.analyticsRepo.RecordClick(ctx, event); err != nil {
-		return err
+	return s.clicks.Enqueue(event)
+}
+
+// GetURLStats получает статистику по URL (только для владельца).
+// Незаполненные параметры фильтра получают значения по умолчанию: последние 30 дней по дням в UTC
+func (s *analyticsService) GetURLStats(ctx context.Context, userID, urlID int64, filter models.StatsFilter) (*models.URLStats, error) {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
+		return nil, err
+	}
+
+	filter, err := resolveStatsFilter(filter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.analyticsRepo.GetStatsByURL(ctx, urlID, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	// Увеличиваем счетчик кликов в таблице URLs
-	if err := s.urlRepo.IncrementClicks(ctx, urlID); err != nil {
+	stats.From = filter.From.In(filter.Location)
+	stats.To = filter.To.In(filter.Location)
+	stats.Granularity = filter.Granularity
+	stats.Timezone = filter.Location.String()
+	stats.ClicksByDate = fillClickBuckets(stats.ClicksByDate, filter)
+
+	return stats, nil
+}
+
+// ExportClicks передает в fn все клики URL из окна фильтра (только для владельца).
+// Окно не ограничено, если from или to не указаны
+func (s *analyticsService) ExportClicks(ctx context.Context, userID, urlID int64, filter models.StatsFilter, fn func(*models.Analytics) error) error {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
 		return err
 	}
 
-	return nil
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return fmt.Errorf("невалидный период: from должен быть раньше to")
+	}
+
+	return s.analyticsRepo.StreamClicks(ctx, urlID, filter, fn)
 }
 
-// GetURLStats получает статистику по URL
-func (s *analyticsService) GetURLStats(ctx context.Context, urlID int64) (*models.URLStats, error) {
-	// Получаем статистику (последние 100 кликов)
-	stats, err := s.analyticsRepo.GetStatsByURL(ctx, urlID, 100)
-	if err != nil {
+// SubscribeClicks подписывает на клики URL в реальном времени (только для владельца)
+func (s *analyticsService) SubscribeClicks(ctx context.Context, userID, urlID int64) (*ClickSubscription, error) {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
 		return nil, err
 	}
 
-	return stats, nil
+	return s.stream.Subscribe(urlID)
+}
+
+// SubscribeAllClicks подписывает на клики всех ссылок (для администраторов)
+func (s *analyticsService) SubscribeAllClicks() (*ClickSubscription, error) {
+	return s.stream.Subscribe(0)
+}
+
+// PipelineStats возвращает метрики конвейера кликов
+func (s *analyticsService) PipelineStats() ClickPipelineStats {
+	return s.clicks.Stats()
 }
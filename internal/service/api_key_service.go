@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"url-short/internal/models"
+	"url-short/internal/repository"
+)
+
+// apiKeyDisplayPrefixLength длина видимой части ключа для списка ключей
+const apiKeyDisplayPrefixLength = 12
+
+// APIKeyService интерфейс для управления API ключами
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, principal *models.Principal, req *models.CreateAPIKeyRequest) (*models.APIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, userID int64) ([]*models.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, userID, id int64) error
+	Authenticate(ctx context.Context, key string) (*models.Principal, error)
+}
+
+// apiKeyService имплементация APIKeyService
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+}
+
+// NewAPIKeyService создает новый APIKey service
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+// CreateAPIKey создает ключ с запрошенными scopes.
+// Нельзя выдать ключу больше прав, чем есть у создающего
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, principal *models.Principal, req *models.CreateAPIKeyRequest) (*models.APIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("невалидное имя ключа")
+	}
+
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("невалидные scopes: нужен хотя бы один")
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !models.IsValidScope(scope) {
+			return nil, fmt.Errorf("невалидный scope: %s", scope)
+		}
+		if !principal.HasScope(scope) {
+			return nil, fmt.Errorf("недостаточно прав для scope %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации ключа: %w", err)
+	}
+	rawKey := models.APIKeyPrefix + token
+
+	key := &models.APIKey{
+		UserID:  principal.UserID,
+		Name:    name,
+		Prefix:  rawKey[:apiKeyDisplayPrefixLength],
+		KeyHash: hashToken(rawKey),
+		Scopes:  scopes,
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+
+	response := toAPIKeyResponse(key)
+	response.Key = rawKey
+
+	return response, nil
+}
+
+// ListAPIKeys получает ключи пользователя
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userID int64) ([]*models.APIKeyResponse, error) {
+	keys, err := s.apiKeyRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*models.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		responses = append(responses, toAPIKeyResponse(key))
+	}
+
+	return responses, nil
+}
+
+// RevokeAPIKey отзывает ключ пользователя
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID, id int64) error {
+	return s.apiKeyRepo.Revoke(ctx, userID, id)
+}
+
+// Authenticate проверяет API ключ и возвращает субъект с его scopes
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*models.Principal, error) {
+	if !strings.HasPrefix(rawKey, models.APIKeyPrefix) {
+		return nil, fmt.Errorf("API ключ не найден")
+	}
+
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashToken(rawKey))
+	if err != nil {
+		return nil, err
+	}
+
+	// Время последнего использования не критично (игнорируем ошибку)
+	s.apiKeyRepo.TouchLastUsed(ctx, key.ID) // nolint:errcheck
+
+	return &models.Principal{
+		UserID:   key.UserID,
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// toAPIKeyResponse конвертирует модель ключа в ответ API
+func toAPIKeyResponse(key *models.APIKey) *models.APIKeyResponse {
+	response := &models.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+	}
+
+	if key.LastUsedAt.Valid {
+		response.LastUsedAt = &key.LastUsedAt.Time
+	}
+	if key.RevokedAt.Valid {
+		response.RevokedAt = &key.RevokedAt.Time
+	}
+
+	return response
+}
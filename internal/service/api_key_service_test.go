@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// mockAPIKeyRepository мок хранилища API ключей
+type mockAPIKeyRepository struct {
+	keys   map[int64]*models.APIKey
+	nextID int64
+}
+
+func newMockAPIKeyRepository() *mockAPIKeyRepository {
+	return &mockAPIKeyRepository{
+		keys:   make(map[int64]*models.APIKey),
+		nextID: 1,
+	}
+}
+
+func (m *mockAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	key.ID = m.nextID
+	key.CreatedAt = time.Now()
+	m.nextID++
+	m.keys[key.ID] = key
+	return nil
+}
+
+func (m *mockAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	for _, key := range m.keys {
+		if key.KeyHash == keyHash && !key.RevokedAt.Valid {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("API ключ не найден")
+}
+
+func (m *mockAPIKeyRepository) GetByUser(ctx context.Context, userID int64) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	for _, key := range m.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeyRepository) Revoke(ctx context.Context, userID, id int64) error {
+	key, exists := m.keys[id]
+	if !exists || key.UserID != userID {
+		return fmt.Errorf("API ключ с ID %d не найден", id)
+	}
+	key.RevokedAt.Time = time.Now()
+	key.RevokedAt.Valid = true
+	return nil
+}
+
+func (m *mockAPIKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	return nil
+}
+
+// TestCreateAPIKey_Authenticate проверяет создание, проверку и отзыв ключа
+func TestCreateAPIKey_Authenticate(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	service := NewAPIKeyService(repo)
+	owner := &models.Principal{UserID: testUserID, Scopes: models.UserScopes}
+
+	created, err := service.CreateAPIKey(context.Background(), owner, &models.CreateAPIKeyRequest{
+		Name:   "ci",
+		Scopes: []string{models.ScopeLinksWrite},
+	})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	if !strings.HasPrefix(created.Key, models.APIKeyPrefix) {
+		t.Errorf("CreateAPIKey() Key = %s, want prefix %s", created.Key, models.APIKeyPrefix)
+	}
+	if repo.keys[created.ID].KeyHash == created.Key {
+		t.Error("CreateAPIKey() should store key hash, not key")
+	}
+
+	principal, err := service.Authenticate(context.Background(), created.Key)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if principal.UserID != testUserID || !principal.IsAPIKey() {
+		t.Errorf("Authenticate() principal = %+v", principal)
+	}
+	if !principal.HasScope(models.ScopeLinksWrite) || principal.HasScope(models.ScopeAnalyticsRead) {
+		t.Errorf("Authenticate() Scopes = %v, want only %s", principal.Scopes, models.ScopeLinksWrite)
+	}
+
+	if err := service.RevokeAPIKey(context.Background(), testUserID, created.ID); err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+	if _, err := service.Authenticate(context.Background(), created.Key); err == nil {
+		t.Error("Authenticate() should fail for revoked key")
+	}
+}
+
+// TestCreateAPIKey_Validation проверяет валидацию имени и scopes
+func TestCreateAPIKey_Validation(t *testing.T) {
+	service := NewAPIKeyService(newMockAPIKeyRepository())
+	owner := &models.Principal{UserID: testUserID, Scopes: models.UserScopes}
+
+	tests := []struct {
+		name string
+		req  *models.CreateAPIKeyRequest
+	}{
+		{"empty name", &models.CreateAPIKeyRequest{Name: " ", Scopes: []string{models.ScopeLinksRead}}},
+		{"no scopes", &models.CreateAPIKeyRequest{Name: "ci"}},
+		{"unknown scope", &models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"links:delete"}}},
+		{"scope escalation", &models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeAdmin}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.CreateAPIKey(context.Background(), owner, tt.req); err == nil {
+				t.Error("CreateAPIKey() should return error")
+			}
+		})
+	}
+}
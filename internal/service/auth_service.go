@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"url-short/internal/models"
+	"url-short/internal/repository"
+)
+
+// minPasswordLength минимальная длина пароля
+const minPasswordLength = 8
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash bcrypt хеш случайного пароля с той же стоимостью, что у пользователей.
+// Вход с неизвестным email сверяется с ним, чтобы время ответа не выдавало, есть ли аккаунт
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		password := make([]byte, 32)
+		rand.Read(password) // nolint:errcheck
+		dummyHash, _ = bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
+
+// AuthService интерфейс для регистрации, входа и проверки сессий
+type AuthService interface {
+	Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error)
+	Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error)
+	Logout(ctx context.Context, token string) error
+	Authenticate(ctx context.Context, token string) (*models.Principal, error)
+	GetUser(ctx context.Context, userID int64) (*models.User, error)
+}
+
+// authService имплементация AuthService
+type authService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	sessionTTL  time.Duration
+}
+
+// NewAuthService создает новый Auth service
+func NewAuthService(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	sessionTTL int,
+) AuthService {
+	return &authService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		sessionTTL:  time.Duration(sessionTTL) * time.Second,
+	}
+}
+
+// Register регистрирует нового пользователя
+func (s *authService) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Password) < minPasswordLength {
+		return nil, fmt.Errorf("невалидный пароль: минимум %d символов", minPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+
+	user := &models.User{
+		Email:        email,
+		PasswordHash: string(hash),
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Login проверяет email и пароль и создает новую сессию
+func (s *authService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("неверный email или пароль")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		// Не раскрываем, существует ли пользователь: ни текстом ошибки, ни временем ответа
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(req.Password)) // nolint:errcheck
+		return nil, fmt.Errorf("неверный email или пароль")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, fmt.Errorf("неверный email или пароль")
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации токена: %w", err)
+	}
+
+	session := &models.Session{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().UTC().Add(s.sessionTTL),
+	}
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		User:      user,
+	}, nil
+}
+
+// Logout завершает сессию
+func (s *authService) Logout(ctx context.Context, token string) error {
+	return s.sessionRepo.DeleteByTokenHash(ctx, hashToken(token))
+}
+
+// Authenticate возвращает субъект запроса по токену сессии
+func (s *authService) Authenticate(ctx context.Context, token string) (*models.Principal, error) {
+	if token == "" {
+		return nil, fmt.Errorf("токен не указан")
+	}
+
+	session, err := s.sessionRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Principal{
+		UserID: user.ID,
+		Scopes: user.Scopes(),
+	}, nil
+}
+
+// GetUser получает пользователя по ID
+func (s *authService) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+	return s.userRepo.GetByID(ctx, userID)
+}
+
+// normalizeEmail проверяет формат email и приводит его к нижнему регистру
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", fmt.Errorf("невалидный email")
+	}
+
+	return email, nil
+}
+
+// generateToken генерирует случайный токен (32 байта в hex)
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken возвращает SHA-256 хеш токена для хранения в БД
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"url-short/internal/models"
+)
+
+// mockUserRepository мок для тестирования AuthService
+type mockUserRepository struct {
+	users  map[int64]*models.User
+	nextID int64
+}
+
+func newMockUserRepository() *mockUserRepository {
+	return &mockUserRepository{
+		users:  make(map[int64]*models.User),
+		nextID: 1,
+	}
+}
+
+func (m *mockUserRepository) Create(ctx context.Context, user *models.User) error {
+	for _, u := range m.users {
+		if u.Email == user.Email {
+			return fmt.Errorf("email %s уже занят", user.Email)
+		}
+	}
+	user.ID = m.nextID
+	user.CreatedAt = time.Now()
+	m.nextID++
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *mockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, u := range m.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("пользователь с email %s не найден", email)
+}
+
+func (m *mockUserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	user, exists := m.users[id]
+	if !exists {
+		return nil, fmt.Errorf("пользователь с ID %d не найден", id)
+	}
+	return user, nil
+}
+
+// mockSessionRepository мок хранилища сессий
+type mockSessionRepository struct {
+	sessions map[string]*models.Session
+}
+
+func newMockSessionRepository() *mockSessionRepository {
+	return &mockSessionRepository{sessions: make(map[string]*models.Session)}
+}
+
+func (m *mockSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	session.CreatedAt = time.Now()
+	m.sessions[session.TokenHash] = session
+	return nil
+}
+
+func (m *mockSessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	session, exists := m.sessions[tokenHash]
+	if !exists || session.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("сессия не найдена")
+	}
+	return session, nil
+}
+
+func (m *mockSessionRepository) DeleteByTokenHash(ctx context.Context, tokenHash string) error {
+	delete(m.sessions, tokenHash)
+	return nil
+}
+
+// TestRegisterAndLogin проверяет регистрацию, вход и проверку токена
+func TestRegisterAndLogin(t *testing.T) {
+	sessions := newMockSessionRepository()
+	service := NewAuthService(newMockUserRepository(), sessions, 3600)
+
+	user, err := service.Register(context.Background(), &models.RegisterRequest{
+		Email:    "User@Example.com",
+		Password: "secret-password",
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if user.Email != "user@example.com" {
+		t.Errorf("Register() Email = %s, want user@example.com", user.Email)
+	}
+	if user.PasswordHash == "secret-password" {
+		t.Error("Register() should store password hash, not password")
+	}
+
+	auth, err := service.Login(context.Background(), &models.LoginRequest{
+		Email:    "user@example.com",
+		Password: "secret-password",
+	})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	if _, stored := sessions.sessions[auth.Token]; stored {
+		t.Error("Login() should store token hash, not token")
+	}
+
+	authenticated, err := service.Authenticate(context.Background(), auth.Token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if authenticated.UserID != user.ID {
+		t.Errorf("Authenticate() UserID = %d, want %d", authenticated.UserID, user.ID)
+	}
+	if authenticated.HasScope(models.ScopeAdmin) {
+		t.Error("Authenticate() regular user should not have admin scope")
+	}
+
+	if err := service.Logout(context.Background(), auth.Token); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if _, err := service.Authenticate(context.Background(), auth.Token); err == nil {
+		t.Error("Authenticate() should fail after Logout()")
+	}
+}
+
+// TestLogin_WrongPassword проверяет отказ при неверном пароле
+func TestLogin_WrongPassword(t *testing.T) {
+	service := NewAuthService(newMockUserRepository(), newMockSessionRepository(), 3600)
+
+	if _, err := service.Register(context.Background(), &models.RegisterRequest{
+		Email:    "user@example.com",
+		Password: "secret-password",
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	_, err := service.Login(context.Background(), &models.LoginRequest{
+		Email:    "user@example.com",
+		Password: "wrong-password",
+	})
+	if err == nil {
+		t.Error("Login() should return error for wrong password")
+	}
+}
+
+// TestLogin_UnknownEmail проверяет, что неизвестный email неотличим от неверного пароля
+func TestLogin_UnknownEmail(t *testing.T) {
+	service := NewAuthService(newMockUserRepository(), newMockSessionRepository(), 3600)
+
+	_, err := service.Login(context.Background(), &models.LoginRequest{
+		Email:    "nobody@example.com",
+		Password: "secret-password",
+	})
+	if err == nil || err.Error() != "неверный email или пароль" {
+		t.Errorf("Login() error = %v, want same error as wrong password", err)
+	}
+
+	// Сравнение с фиктивным хешем стоит столько же, сколько с настоящим
+	if cost, err := bcrypt.Cost(dummyPasswordHash()); err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash cost = %d, %v, want %d", cost, err, bcrypt.DefaultCost)
+	}
+}
+
+// TestRegister_Validation проверяет валидацию email и пароля
+func TestRegister_Validation(t *testing.T) {
+	service := NewAuthService(newMockUserRepository(), newMockSessionRepository(), 3600)
+
+	tests := []struct {
+		name string
+		req  *models.RegisterRequest
+	}{
+		{"invalid email", &models.RegisterRequest{Email: "not-an-email", Password: "secret-password"}},
+		{"short password", &models.RegisterRequest{Email: "user@example.com", Password: "short"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Register(context.Background(), tt.req); err == nil {
+				t.Error("Register() should return error")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"net"
+
+	"url-short/internal/models"
+	"url-short/pkg/geoip"
+	"url-short/pkg/useragent"
+)
+
+// maxCityLength ограничение колонки analytics.city
+const maxCityLength = 100
+
+// geoIPEnricher заполняет страну и город клика по IP адресу
+type geoIPEnricher struct {
+	locator geoip.Locator
+}
+
+// NewGeoIPEnricher создает enricher геолокации
+func NewGeoIPEnricher(locator geoip.Locator) ClickEnricher {
+	return &geoIPEnricher{locator: locator}
+}
+
+// Enrich определяет страну и город. Частные адреса и ошибки поиска пропускаются
+func (e *geoIPEnricher) Enrich(event *models.ClickEvent) {
+	if event.Country != "" || event.IPAddress == "" {
+		return
+	}
+
+	ip := net.ParseIP(event.IPAddress)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() {
+		return
+	}
+
+	location, err := e.locator.Lookup(event.IPAddress)
+	if err != nil {
+		return
+	}
+
+	if len(location.Country) == 2 {
+		event.Country = location.Country
+	}
+
+	city := []rune(location.City)
+	if len(city) > maxCityLength {
+		city = city[:maxCityLength]
+	}
+	event.City = string(city)
+}
+
+// userAgentEnricher заполняет браузер, ОС, класс устройства и признак бота по User-Agent
+type userAgentEnricher struct{}
+
+// NewUserAgentEnricher создает enricher User-Agent
+func NewUserAgentEnricher() ClickEnricher {
+	return &userAgentEnricher{}
+}
+
+// Enrich разбирает User-Agent. Признак бота, выставленный по запросу, сохраняется.
+// Клики без User-Agent считаются ботами и остаются без разбивки
+func (e *userAgentEnricher) Enrich(event *models.ClickEvent) {
+	event.IsBot = event.IsBot || useragent.IsBot(event.UserAgent)
+
+	if event.UserAgent == "" || event.Browser != "" {
+		return
+	}
+
+	info := useragent.Parse(event.UserAgent)
+	event.Browser = info.Browser
+	event.OS = info.OS
+	event.DeviceType = info.Device
+}
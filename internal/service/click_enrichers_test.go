@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"url-short/internal/models"
+	"url-short/pkg/geoip"
+)
+
+// mockLocator мок геолокации
+type mockLocator struct {
+	locations map[string]geoip.Location
+}
+
+func (m *mockLocator) Lookup(ip string) (geoip.Location, error) {
+	location, exists := m.locations[ip]
+	if !exists {
+		return geoip.Location{}, fmt.Errorf("IP %s не найден", ip)
+	}
+	return location, nil
+}
+
+func (m *mockLocator) Close() error {
+	return nil
+}
+
+// TestGeoIPEnricher проверяет заполнение страны и города
+func TestGeoIPEnricher(t *testing.T) {
+	enricher := NewGeoIPEnricher(&mockLocator{locations: map[string]geoip.Location{
+		"81.2.69.142": {Country: "GB", City: "London"},
+		"10.0.0.1":    {Country: "US", City: "Private"},
+	}})
+
+	tests := []struct {
+		name    string
+		ip      string
+		country string
+		city    string
+	}{
+		{"public IP", "81.2.69.142", "GB", "London"},
+		{"private IP skipped", "10.0.0.1", "", ""},
+		{"unknown IP", "1.1.1.1", "", ""},
+		{"invalid IP", "not-an-ip", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &models.ClickEvent{IPAddress: tt.ip}
+			enricher.Enrich(event)
+
+			if event.Country != tt.country || event.City != tt.city {
+				t.Errorf("Enrich() = %s/%s, want %s/%s", event.Country, event.City, tt.country, tt.city)
+			}
+		})
+	}
+}
+
+// TestUserAgentEnricher проверяет разбор User-Agent при записи клика
+func TestUserAgentEnricher(t *testing.T) {
+	enricher := NewUserAgentEnricher()
+
+	event := &models.ClickEvent{
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+	}
+	enricher.Enrich(event)
+
+	if event.Browser != "Safari" || event.OS != "iOS" || event.DeviceType != "mobile" {
+		t.Errorf("Enrich() = %s/%s/%s, want Safari/iOS/mobile", event.Browser, event.OS, event.DeviceType)
+	}
+
+	empty := &models.ClickEvent{}
+	enricher.Enrich(empty)
+	if empty.Browser != "" || empty.OS != "" || empty.DeviceType != "" {
+		t.Errorf("Enrich() без User-Agent должен оставлять поля пустыми, got %+v", empty)
+	}
+}
+
+// TestUserAgentEnricher_Bots проверяет пометку кликов ботов
+func TestUserAgentEnricher_Bots(t *testing.T) {
+	enricher := NewUserAgentEnricher()
+
+	tests := []struct {
+		name  string
+		event *models.ClickEvent
+		want  bool
+	}{
+		{"browser", &models.ClickEvent{UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"}, false},
+		{"slack preview", &models.ClickEvent{UserAgent: "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"}, true},
+		{"empty user agent", &models.ClickEvent{}, true},
+		{"flagged by request", &models.ClickEvent{UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", IsBot: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enricher.Enrich(tt.event)
+			if tt.event.IsBot != tt.want {
+				t.Errorf("IsBot = %v, want %v", tt.event.IsBot, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"url-short/internal/models"
+	"url-short/internal/repository"
+)
+
+// ErrClickQueueFull возвращается, когда очередь кликов заполнена и клик отброшен
+var ErrClickQueueFull = errors.New("очередь кликов переполнена")
+
+// ClickEnricher дополняет клик данными перед записью (геолокация, разбор User-Agent и т.п.).
+// Вызывается в воркерах конвейера, а не в обработчике редиректа
+type ClickEnricher interface {
+	Enrich(event *models.ClickEvent)
+}
+
+// ClickPublisher получает пачку кликов после успешной записи в БД
+type ClickPublisher interface {
+	PublishClicks(ctx context.Context, events []*models.ClickEvent)
+}
+
+// ClickCountObserver получает новые значения clicks_count ссылок после записи пачки
+type ClickCountObserver interface {
+	ClickCountsUpdated(ctx context.Context, counts []models.URLClickCount)
+}
+
+// ClickPipelineConfig настройки конвейера записи кликов
+type ClickPipelineConfig struct {
+	QueueSize     int
+	Workers       int
+	BatchSize     int
+	FlushInterval time.Duration
+	// FlushAttempts попыток записи пачки, прежде чем ее клики считаются потерянными
+	FlushAttempts int
+	// RetryBackoff пауза перед второй попыткой, дальше удваивается
+	RetryBackoff time.Duration
+	// Publisher рассылает записанные клики (nil - не рассылать)
+	Publisher ClickPublisher
+	// CountObserver получает счетчики кликов ссылок (nil - не отслеживать)
+	CountObserver ClickCountObserver
+}
+
+// ClickPipelineStats счетчики конвейера кликов
+type ClickPipelineStats struct {
+	Enqueued    int64 `json:"enqueued"`
+	Dropped     int64 `json:"dropped"`
+	Recorded    int64 `json:"recorded"`
+	Failed      int64 `json:"failed"`
+	QueueLength int   `json:"queue_length"`
+	QueueSize   int   `json:"queue_size"`
+}
+
+// ClickPipeline ограниченная очередь кликов с пулом воркеров,
+// которые пишут клики в БД пачками. При переполнении клики отбрасываются
+// (редирект никогда не ждет БД), а при остановке очередь дописывается до конца
+type ClickPipeline struct {
+	analyticsRepo repository.AnalyticsRepository
+	config        ClickPipelineConfig
+	enrichers     []ClickEnricher
+	events        chan *models.ClickEvent
+
+	startOnce sync.Once
+	wg        sync.WaitGroup
+
+	// mu защищает закрытие канала от параллельной отправки
+	mu     sync.RWMutex
+	closed bool
+
+	enqueued atomic.Int64
+	dropped  atomic.Int64
+	recorded atomic.Int64
+	failed   atomic.Int64
+
+	// Последнее залогированное значение dropped, чтобы не логировать каждый клик
+	reportedDrops atomic.Int64
+}
+
+// NewClickPipeline создает конвейер кликов. Нулевые значения настроек заменяются дефолтными.
+// enrichers применяются к каждому клику перед записью
+func NewClickPipeline(
+	analyticsRepo repository.AnalyticsRepository,
+	config ClickPipelineConfig,
+	enrichers ...ClickEnricher,
+) *ClickPipeline {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.Workers <= 0 {
+		config.Workers = 2
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	// Большая пачка не поместится в один INSERT, и все ее клики будут потеряны
+	if config.BatchSize > repository.MaxClickBatchSize {
+		config.BatchSize = repository.MaxClickBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.FlushAttempts <= 0 {
+		config.FlushAttempts = 3
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 500 * time.Millisecond
+	}
+
+	return &ClickPipeline{
+		analyticsRepo: analyticsRepo,
+		config:        config,
+		enrichers:     enrichers,
+		events:        make(chan *models.ClickEvent, config.QueueSize),
+	}
+}
+
+// Start запускает воркеры
+func (p *ClickPipeline) Start() {
+	p.startOnce.Do(func() {
+		for i := 0; i < p.config.Workers; i++ {
+			p.wg.Add(1)
+			go p.worker()
+		}
+	})
+}
+
+// Enqueue ставит клик в очередь без блокировки.
+// Если очередь заполнена, клик отбрасывается и возвращается ErrClickQueueFull
+func (p *ClickPipeline) Enqueue(event *models.ClickEvent) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	// Клики, пришедшие после остановки, тоже считаются отброшенными
+	if p.closed {
+		p.dropped.Add(1)
+		return ErrClickQueueFull
+	}
+
+	select {
+	case p.events <- event:
+		p.enqueued.Add(1)
+		return nil
+	default:
+		p.dropped.Add(1)
+		return ErrClickQueueFull
+	}
+}
+
+// Shutdown прекращает прием кликов и ждет, пока воркеры допишут очередь.
+// Возвращает ошибку контекста, если не успели до дедлайна
+func (p *ClickPipeline) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats возвращает текущие счетчики
+func (p *ClickPipeline) Stats() ClickPipelineStats {
+	return ClickPipelineStats{
+		Enqueued:    p.enqueued.Load(),
+		Dropped:     p.dropped.Load(),
+		Recorded:    p.recorded.Load(),
+		Failed:      p.failed.Load(),
+		QueueLength: len(p.events),
+		QueueSize:   cap(p.events),
+	}
+}
+
+// worker собирает клики в пачки по размеру или по таймеру
+func (p *ClickPipeline) worker() {
+	defer p.wg.Done()
+
+	batch := make([]*models.ClickEvent, 0, p.config.BatchSize)
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-p.events:
+			if !ok {
+				// Очередь закрыта и вычитана - дописываем остаток
+				p.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= p.config.BatchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			p.flush(batch)
+			batch = batch[:0]
+			p.reportDrops()
+		}
+	}
+}
+
+// flush записывает пачку кликов в БД и публикует ее
+func (p *ClickPipeline) flush(batch []*models.ClickEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	for _, event := range batch {
+		for _, enricher := range p.enrichers {
+			enricher.Enrich(event)
+		}
+	}
+
+	counts, err := p.record(batch)
+	if err != nil {
+		p.failed.Add(int64(len(batch)))
+		log.Printf("Ошибка записи пачки кликов (%d шт.): %v", len(batch), err)
+		return
+	}
+
+	p.recorded.Add(int64(len(batch)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if p.config.Publisher != nil {
+		p.config.Publisher.PublishClicks(ctx, batch)
+	}
+	if p.config.CountObserver != nil && len(counts) > 0 {
+		p.config.CountObserver.ClickCountsUpdated(ctx, counts)
+	}
+}
+
+// record записывает пачку, повторяя попытку после временной ошибки БД (обрыв соединения,
+// failover) с удваивающейся паузой. Пачка пишется одной транзакцией, поэтому неудачная
+// попытка не оставляет в БД части кликов
+func (p *ClickPipeline) record(batch []*models.ClickEvent) ([]models.URLClickCount, error) {
+	backoff := p.config.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		// Запись не зависит от запросов, поэтому свой контекст с таймаутом
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		counts, err := p.analyticsRepo.RecordClicks(ctx, batch)
+		cancel()
+		if err == nil || attempt >= p.config.FlushAttempts {
+			return counts, err
+		}
+
+		log.Printf("Ошибка записи пачки кликов (%d шт., попытка %d из %d): %v", len(batch), attempt, p.config.FlushAttempts, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// reportDrops логирует новые отброшенные клики
+func (p *ClickPipeline) reportDrops() {
+	dropped := p.dropped.Load()
+	reported := p.reportedDrops.Load()
+	if dropped > reported && p.reportedDrops.CompareAndSwap(reported, dropped) {
+		log.Printf("Очередь кликов переполнена: отброшено %d кликов (всего %d)", dropped-reported, dropped)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+	"url-short/internal/repository"
+)
+
+// mockAnalyticsRepository мок для тестирования конвейера кликов
+type mockAnalyticsRepository struct {
+	mu      sync.Mutex
+	batches [][]*models.ClickEvent
+	block   chan struct{}
+	// failures вызовов RecordClicks подряд завершаются ошибкой
+	failures int
+	calls    int
+}
+
+func (m *mockAnalyticsRepository) RecordClicks(ctx context.Context, events []*models.ClickEvent) ([]models.URLClickCount, error) {
+	if m.block != nil {
+		<-m.block
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if m.failures > 0 {
+		m.failures--
+		return nil, errors.New("connection reset by peer")
+	}
+
+	batch := make([]*models.ClickEvent, len(events))
+	copy(batch, events)
+	m.batches = append(m.batches, batch)
+	return nil, nil
+}
+
+func (m *mockAnalyticsRepository) GetStatsByURL(ctx context.Context, urlID int64, filter models.StatsFilter) (*models.URLStats, error) {
+	return &models.URLStats{}, nil
+}
+
+func (m *mockAnalyticsRepository) StreamClicks(ctx context.Context, urlID int64, filter models.StatsFilter, fn func(*models.Analytics) error) error {
+	return nil
+}
+
+func (m *mockAnalyticsRepository) recordedCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	total := 0
+	for _, batch := range m.batches {
+		total += len(batch)
+	}
+	return total
+}
+
+// TestClickPipeline_BatchAndDrain проверяет запись пачками и дозапись при остановке
+func TestClickPipeline_BatchAndDrain(t *testing.T) {
+	repo := &mockAnalyticsRepository{}
+	pipeline := NewClickPipeline(repo, ClickPipelineConfig{
+		QueueSize:     100,
+		Workers:       1,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+	})
+	pipeline.Start()
+
+	for i := 0; i < 25; i++ {
+		if err := pipeline.Enqueue(&models.ClickEvent{URLID: 1}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pipeline.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if got := repo.recordedCount(); got != 25 {
+		t.Errorf("recorded = %d, want 25", got)
+	}
+	for _, batch := range repo.batches {
+		if len(batch) > 10 {
+			t.Errorf("batch size = %d, want <= 10", len(batch))
+		}
+	}
+
+	stats := pipeline.Stats()
+	if stats.Recorded != 25 || stats.Dropped != 0 {
+		t.Errorf("Stats() = %+v, want 25 recorded, 0 dropped", stats)
+	}
+
+	// После остановки клики отбрасываются
+	if err := pipeline.Enqueue(&models.ClickEvent{URLID: 1}); err != ErrClickQueueFull {
+		t.Errorf("Enqueue() after Shutdown error = %v, want ErrClickQueueFull", err)
+	}
+}
+
+// TestClickPipeline_Retry проверяет повтор записи пачки после ошибки БД
+func TestClickPipeline_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		wantRecorded int64
+		wantFailed   int64
+		wantCalls    int
+	}{
+		{"recovers", 2, 5, 0, 3},
+		{"gives up", 5, 0, 5, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockAnalyticsRepository{failures: tt.failures}
+			pipeline := NewClickPipeline(repo, ClickPipelineConfig{
+				Workers:       1,
+				BatchSize:     10,
+				FlushInterval: time.Hour,
+				FlushAttempts: 3,
+				RetryBackoff:  time.Millisecond,
+			})
+			pipeline.Start()
+
+			for i := 0; i < 5; i++ {
+				pipeline.Enqueue(&models.ClickEvent{URLID: 1}) // nolint:errcheck
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := pipeline.Shutdown(ctx); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+
+			stats := pipeline.Stats()
+			if stats.Recorded != tt.wantRecorded || stats.Failed != tt.wantFailed || repo.calls != tt.wantCalls {
+				t.Errorf("Stats() = %+v after %d calls, want %d recorded, %d failed after %d calls",
+					stats, repo.calls, tt.wantRecorded, tt.wantFailed, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// TestClickPipeline_DropWhenFull проверяет отбрасывание кликов при заполненной очереди
+func TestClickPipeline_DropWhenFull(t *testing.T) {
+	repo := &mockAnalyticsRepository{block: make(chan struct{})}
+	pipeline := NewClickPipeline(repo, ClickPipelineConfig{
+		QueueSize:     2,
+		Workers:       1,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+	pipeline.Start()
+
+	// Воркер заберет первый клик и зависнет на записи, очередь вместит еще 2
+	dropped := 0
+	for i := 0; i < 10; i++ {
+		if err := pipeline.Enqueue(&models.ClickEvent{URLID: 1}); err == ErrClickQueueFull {
+			dropped++
+		}
+	}
+
+	if dropped == 0 {
+		t.Error("Enqueue() should drop clicks when queue is full")
+	}
+	if stats := pipeline.Stats(); stats.Dropped != int64(dropped) {
+		t.Errorf("Stats().Dropped = %d, want %d", stats.Dropped, dropped)
+	}
+
+	close(repo.block)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pipeline.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if got := repo.recordedCount(); got != 10-dropped {
+		t.Errorf("recorded = %d, want %d", got, 10-dropped)
+	}
+}
+
+// TestNewClickPipeline_BatchSizeLimit проверяет, что пачка не превышает лимит параметров запроса
+func TestNewClickPipeline_BatchSizeLimit(t *testing.T) {
+	pipeline := NewClickPipeline(&mockAnalyticsRepository{}, ClickPipelineConfig{BatchSize: 10000})
+	if pipeline.config.BatchSize != repository.MaxClickBatchSize {
+		t.Errorf("BatchSize = %d, want %d", pipeline.config.BatchSize, repository.MaxClickBatchSize)
+	}
+
+	pipeline = NewClickPipeline(&mockAnalyticsRepository{}, ClickPipelineConfig{BatchSize: 500})
+	if pipeline.config.BatchSize != 500 {
+		t.Errorf("BatchSize = %d, want 500", pipeline.config.BatchSize)
+	}
+}
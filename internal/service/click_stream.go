@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"url-short/internal/models"
+)
+
+const (
+	// clickStreamChannel канал Redis pub/sub с записанными кликами всех инстансов
+	clickStreamChannel = "clicks:stream"
+	// clickSubscriberBuffer буфер событий одного подписчика. Медленный клиент теряет
+	// события сверх буфера, а не тормозит остальных
+	clickSubscriberBuffer = 64
+)
+
+// ErrClickStreamClosed возвращается при подписке после остановки потока
+var ErrClickStreamClosed = errors.New("поток кликов остановлен")
+
+// ClickStreamEvent клик, отправляемый подписчикам в реальном времени
+type ClickStreamEvent struct {
+	URLID      int64     `json:"url_id"`
+	ClickedAt  time.Time `json:"clicked_at"`
+	Referer    string    `json:"referer,omitempty"`
+	Country    string    `json:"country,omitempty"`
+	City       string    `json:"city,omitempty"`
+	Browser    string    `json:"browser,omitempty"`
+	OS         string    `json:"os,omitempty"`
+	DeviceType string    `json:"device_type,omitempty"`
+	IsBot      bool      `json:"is_bot"`
+}
+
+// ClickSubscription подписка на клики одной ссылки или всех ссылок
+type ClickSubscription struct {
+	// Events закрывается при отписке или остановке потока
+	Events <-chan ClickStreamEvent
+
+	urlID  int64
+	events chan ClickStreamEvent
+	stream *ClickStream
+}
+
+// Close отменяет подписку
+func (s *ClickSubscription) Close() {
+	s.stream.unsubscribe(s)
+}
+
+// ClickStream рассылает записанные клики подписчикам. Клики публикуются в Redis,
+// каждый инстанс держит одну подписку на канал и раздает события своим клиентам.
+// Без Redis события раздаются только внутри процесса
+type ClickStream struct {
+	redis *redis.Client
+
+	mu          sync.RWMutex
+	subscribers map[*ClickSubscription]struct{}
+	closed      bool
+	pubsub      *redis.PubSub
+}
+
+// NewClickStream создает поток кликов. redisClient может быть nil
+func NewClickStream(redisClient *redis.Client) *ClickStream {
+	return &ClickStream{
+		redis:       redisClient,
+		subscribers: make(map[*ClickSubscription]struct{}),
+	}
+}
+
+// Start подписывается на канал Redis. go-redis переподключается к каналу сам
+func (s *ClickStream) Start() {
+	if s.redis == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.pubsub = s.redis.Subscribe(context.Background(), clickStreamChannel)
+	messages := s.pubsub.Channel()
+	s.mu.Unlock()
+
+	go func() {
+		for msg := range messages {
+			var events []ClickStreamEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &events); err != nil {
+				log.Printf("Ошибка разбора события кликов: %v", err)
+				continue
+			}
+			s.dispatch(events)
+		}
+	}()
+}
+
+// PublishClicks публикует записанную пачку кликов одним сообщением
+func (s *ClickStream) PublishClicks(ctx context.Context, clicks []*models.ClickEvent) {
+	events := make([]ClickStreamEvent, 0, len(clicks))
+	for _, click := range clicks {
+		events = append(events, ClickStreamEvent{
+			URLID:      click.URLID,
+			ClickedAt:  click.ClickedAt,
+			Referer:    click.Referer,
+			Country:    click.Country,
+			City:       click.City,
+			Browser:    click.Browser,
+			OS:         click.OS,
+			DeviceType: click.DeviceType,
+			IsBot:      click.IsBot,
+		})
+	}
+
+	if s.redis == nil {
+		s.dispatch(events)
+		return
+	}
+
+	payload, err := json.Marshal(events)
+	if err != nil {
+		log.Printf("Ошибка сериализации событий кликов: %v", err)
+		return
+	}
+	if err := s.redis.Publish(ctx, clickStreamChannel, payload).Err(); err != nil {
+		log.Printf("Ошибка публикации кликов в Redis: %v", err)
+	}
+}
+
+// Subscribe подписывается на клики ссылки urlID (0 - все ссылки)
+func (s *ClickStream) Subscribe(urlID int64) (*ClickSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrClickStreamClosed
+	}
+
+	events := make(chan ClickStreamEvent, clickSubscriberBuffer)
+	sub := &ClickSubscription{
+		Events: events,
+		urlID:  urlID,
+		events: events,
+		stream: s,
+	}
+	s.subscribers[sub] = struct{}{}
+
+	return sub, nil
+}
+
+// Close закрывает все подписки и отписывается от Redis. Вызывается при остановке
+// сервера, чтобы открытые SSE соединения завершились
+func (s *ClickStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+
+	for sub := range s.subscribers {
+		close(sub.events)
+	}
+	s.subscribers = make(map[*ClickSubscription]struct{})
+
+	if s.pubsub != nil {
+		if err := s.pubsub.Close(); err != nil {
+			log.Printf("Ошибка закрытия подписки Redis: %v", err)
+		}
+	}
+}
+
+// unsubscribe удаляет подписку, если она еще активна
+func (s *ClickStream) unsubscribe(sub *ClickSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.subscribers[sub]; exists {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// dispatch раздает события подписчикам без блокировки
+func (s *ClickStream) dispatch(events []ClickStreamEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for sub := range s.subscribers {
+		for _, event := range events {
+			if sub.urlID != 0 && sub.urlID != event.URLID {
+				continue
+			}
+			select {
+			case sub.events <- event:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// TestClickStream_Dispatch проверяет раздачу кликов подписчикам ссылки и всех ссылок
+func TestClickStream_Dispatch(t *testing.T) {
+	stream := NewClickStream(nil)
+
+	link, err := stream.Subscribe(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	all, _ := stream.Subscribe(0)
+
+	stream.PublishClicks(context.Background(), []*models.ClickEvent{
+		{URLID: 1, Country: "DE"},
+		{URLID: 2, Country: "US"},
+	})
+
+	if len(link.Events) != 1 {
+		t.Errorf("подписка ссылки получила %d событий, want 1", len(link.Events))
+	}
+	if len(all.Events) != 2 {
+		t.Errorf("подписка всех ссылок получила %d событий, want 2", len(all.Events))
+	}
+
+	// После отписки канал закрыт
+	link.Close()
+	for range link.Events {
+	}
+
+	stream.Close()
+	for range all.Events {
+	}
+	if _, err := stream.Subscribe(1); err != ErrClickStreamClosed {
+		t.Errorf("Subscribe после Close = %v, want ErrClickStreamClosed", err)
+	}
+}
+
+// TestClickPipeline_Publish проверяет публикацию кликов после записи
+func TestClickPipeline_Publish(t *testing.T) {
+	stream := NewClickStream(nil)
+	sub, _ := stream.Subscribe(1)
+
+	pipeline := NewClickPipeline(&mockAnalyticsRepository{}, ClickPipelineConfig{
+		Workers:   1,
+		BatchSize: 1,
+		Publisher: stream,
+	})
+	pipeline.Start()
+
+	if err := pipeline.Enqueue(&models.ClickEvent{URLID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case event := <-sub.Events:
+		if event.URLID != 1 {
+			t.Errorf("URLID = %d, want 1", event.URLID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("клик не опубликован")
+	}
+
+	if err := pipeline.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
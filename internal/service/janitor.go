@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"url-short/internal/config"
+	"url-short/internal/models"
+	"url-short/internal/repository"
+)
+
+const (
+	// janitorLockKey ключ advisory lock очистки, общий для всех инстансов ("janitor" в ASCII)
+	janitorLockKey int64 = 0x6a616e69746f72
+	// janitorBatch ссылок, обрабатываемых одним запросом
+	janitorBatch = 100
+	// janitorRunTimeout ограничение одного прохода по расписанию
+	janitorRunTimeout = 5 * time.Minute
+)
+
+// ErrJanitorLocked проход пропущен: очистку сейчас выполняет другой инстанс
+var ErrJanitorLocked = errors.New("очистку уже выполняет другой инстанс")
+
+// JanitorReport результат одного прохода очистки
+type JanitorReport struct {
+	Mode       string    `json:"mode"`
+	Processed  int       `json:"processed"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// JanitorStats метрики очистки этого инстанса с момента запуска
+type JanitorStats struct {
+	Mode string `json:"mode"`
+	// Runs проходов под lock, Skipped - пропущенных, потому что lock держал другой инстанс
+	Runs    int64 `json:"runs"`
+	Skipped int64 `json:"skipped"`
+	Failed  int64 `json:"failed"`
+	// Processed ссылок, обработанных всеми проходами
+	Processed      int64      `json:"processed"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastProcessed  int        `json:"last_processed"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+// Janitor по расписанию архивирует или удаляет ссылки, срок которых истек больше grace назад.
+// Инстансы договариваются через advisory lock: проход выполняет тот, кто взял lock,
+// остальные его пропускают
+type Janitor struct {
+	urlRepo    repository.URLRepository
+	locks      repository.LockRepository
+	redis      *redis.Client
+	mode       string
+	grace      time.Duration
+	interval   time.Duration
+	quarantine time.Duration
+
+	mu    sync.Mutex
+	stats JanitorStats
+
+	startOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewJanitor создает очистку истекших ссылок. quarantine - карантин кодов окончательно
+// удаленных ссылок, как у корзины
+func NewJanitor(urlRepo repository.URLRepository, locks repository.LockRepository, redisClient *redis.Client, cfg config.JanitorConfig, quarantine time.Duration) (*Janitor, error) {
+	switch cfg.Mode {
+	case models.JanitorModeOff, models.JanitorModeArchive, models.JanitorModeSoftDelete, models.JanitorModeHardDelete:
+	default:
+		return nil, fmt.Errorf("невалидный режим очистки: %s (допустимо: %s, %s, %s, %s)", cfg.Mode,
+			models.JanitorModeOff, models.JanitorModeArchive, models.JanitorModeSoftDelete, models.JanitorModeHardDelete)
+	}
+
+	grace := cfg.GetGrace()
+	if grace < 0 {
+		grace = 0
+	}
+	interval := cfg.GetInterval()
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &Janitor{
+		urlRepo:    urlRepo,
+		locks:      locks,
+		redis:      redisClient,
+		mode:       cfg.Mode,
+		grace:      grace,
+		interval:   interval,
+		quarantine: quarantine,
+		stats:      JanitorStats{Mode: cfg.Mode},
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}, nil
+}
+
+// Start запускает очистку по расписанию: сразу и затем раз в interval.
+// В режиме off ничего не делает
+func (j *Janitor) Start() {
+	j.startOnce.Do(func() {
+		go j.run()
+	})
+}
+
+// Shutdown останавливает расписание и ждет завершения текущего прохода
+func (j *Janitor) Shutdown(ctx context.Context) error {
+	j.Start()
+	select {
+	case <-j.stop:
+	default:
+		close(j.stop)
+	}
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats возвращает метрики очистки
+func (j *Janitor) Stats() JanitorStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.stats
+}
+
+// RunOnce выполняет один проход очистки, если lock свободен (иначе ErrJanitorLocked).
+// Ссылки обрабатываются пачками, пока не кончатся
+func (j *Janitor) RunOnce(ctx context.Context) (*JanitorReport, error) {
+	if j.mode == models.JanitorModeOff {
+		return nil, fmt.Errorf("очистка отключена (режим %s)", models.JanitorModeOff)
+	}
+
+	unlock, ok, err := j.locks.TryLock(ctx, janitorLockKey)
+	if err != nil {
+		j.record(nil, err)
+		return nil, err
+	}
+	if !ok {
+		j.mu.Lock()
+		j.stats.Skipped++
+		j.mu.Unlock()
+		return nil, ErrJanitorLocked
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			log.Printf("Ошибка очистки истекших ссылок: %v", err)
+		}
+	}()
+
+	report := &JanitorReport{Mode: j.mode, StartedAt: time.Now()}
+	for {
+		urls, err := j.urlRepo.CleanupExpired(ctx, j.mode, j.grace, j.quarantine, janitorBatch)
+		j.evict(ctx, urls)
+		report.Processed += len(urls)
+
+		if err != nil || len(urls) < janitorBatch {
+			report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+			j.record(report, err)
+			return report, err
+		}
+	}
+}
+
+// run выполняет проходы по таймеру до остановки
+func (j *Janitor) run() {
+	defer close(j.done)
+
+	if j.mode == models.JanitorModeOff {
+		return
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	// Первый проход сразу: при частых перезапусках таймер мог бы не сработать ни разу
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), janitorRunTimeout)
+		report, err := j.RunOnce(ctx)
+		cancel()
+		switch {
+		case errors.Is(err, ErrJanitorLocked):
+		case err != nil:
+			log.Printf("Ошибка очистки истекших ссылок: %v", err)
+		case report.Processed > 0:
+			log.Printf("Очистка истекших ссылок (%s): обработано %d за %d мс", report.Mode, report.Processed, report.DurationMs)
+		}
+
+		select {
+		case <-j.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// evict удаляет из Redis кеши обработанных ссылок: редирект, правила и A/B варианты
+// (игнорируем ошибку, записи кеша все равно ограничены TTL)
+func (j *Janitor) evict(ctx context.Context, urls []*models.URL) {
+	if j.redis == nil || len(urls) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(urls)*3)
+	for _, url := range urls {
+		keys = append(keys,
+			fmt.Sprintf("url:%s", url.ShortCode),
+			fmt.Sprintf("rules:%d", url.ID),
+			fmt.Sprintf("variants:%d", url.ID))
+	}
+	j.redis.Del(ctx, keys...) // nolint:errcheck
+}
+
+// record учитывает проход в метриках. report nil - проход не начался (ошибка lock)
+func (j *Janitor) record(report *JanitorReport, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if report != nil {
+		startedAt := report.StartedAt
+		j.stats.Runs++
+		j.stats.Processed += int64(report.Processed)
+		j.stats.LastRunAt = &startedAt
+		j.stats.LastProcessed = report.Processed
+		j.stats.LastDurationMs = report.DurationMs
+	}
+
+	j.stats.LastError = ""
+	if err != nil {
+		j.stats.Failed++
+		j.stats.LastError = err.Error()
+	}
+}
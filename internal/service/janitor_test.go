@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"url-short/internal/config"
+	"url-short/internal/models"
+)
+
+// mockLockRepository мок advisory lock: held - lock держит другой инстанс
+type mockLockRepository struct {
+	held     bool
+	unlocked int
+}
+
+func (m *mockLockRepository) TryLock(ctx context.Context, key int64) (func() error, bool, error) {
+	if m.held {
+		return nil, false, nil
+	}
+	m.held = true
+	return func() error {
+		m.held = false
+		m.unlocked++
+		return nil
+	}, true, nil
+}
+
+// addExpiredURL добавляет в мок ссылку, срок которой истек expiredAgo назад
+func addExpiredURL(repo *mockURLRepository, code string, expiredAgo time.Duration) *models.URL {
+	url := &models.URL{
+		ID:          repo.nextID,
+		ShortCode:   code,
+		OriginalURL: "https://example.com/" + code,
+		UserID:      sql.NullInt64{Int64: testUserID, Valid: true},
+		ExpiresAt:   sql.NullTime{Time: time.Now().Add(-expiredAgo), Valid: true},
+	}
+	repo.nextID++
+	repo.urls[code] = url
+	repo.urlsByID[url.ID] = url
+	return url
+}
+
+// TestJanitor_RunOnce проверяет режимы очистки: трогаются только ссылки, истекшие раньше grace
+func TestJanitor_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.JanitorConfig{GraceDays: 30, IntervalMinutes: 60}
+	quarantine := 365 * 24 * time.Hour
+
+	tests := []struct {
+		mode  string
+		check func(t *testing.T, repo *mockURLRepository, old *models.URL)
+	}{
+		{
+			mode: models.JanitorModeArchive,
+			check: func(t *testing.T, repo *mockURLRepository, old *models.URL) {
+				if !old.ArchivedAt.Valid || old.DeletedAt.Valid {
+					t.Errorf("old link = %+v, want archived", old)
+				}
+			},
+		},
+		{
+			mode: models.JanitorModeSoftDelete,
+			check: func(t *testing.T, repo *mockURLRepository, old *models.URL) {
+				if !old.DeletedAt.Valid {
+					t.Errorf("old link = %+v, want moved to trash", old)
+				}
+			},
+		},
+		{
+			mode: models.JanitorModeHardDelete,
+			check: func(t *testing.T, repo *mockURLRepository, old *models.URL) {
+				if _, exists := repo.urlsByID[old.ID]; exists {
+					t.Error("old link should be deleted")
+				}
+				if !repo.retired[old.ShortCode].After(time.Now()) {
+					t.Error("code of deleted link should be quarantined")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			repo := newMockURLRepository()
+			locks := &mockLockRepository{}
+			old := addExpiredURL(repo, "old", 40*24*time.Hour)
+			recent := addExpiredURL(repo, "recent", 24*time.Hour)
+
+			cfg.Mode = tt.mode
+			janitor, err := NewJanitor(repo, locks, nil, cfg, quarantine)
+			if err != nil {
+				t.Fatalf("NewJanitor() error = %v", err)
+			}
+
+			report, err := janitor.RunOnce(ctx)
+			if err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+			if report.Processed != 1 || report.Mode != tt.mode {
+				t.Errorf("report = %+v, want 1 link in mode %s", report, tt.mode)
+			}
+			tt.check(t, repo, old)
+			if recent.ArchivedAt.Valid || recent.DeletedAt.Valid || repo.urlsByID[recent.ID] == nil {
+				t.Errorf("recent link = %+v, want untouched within grace period", recent)
+			}
+			if locks.held || locks.unlocked != 1 {
+				t.Errorf("lock held = %v, unlocked %d times, want released once", locks.held, locks.unlocked)
+			}
+
+			stats := janitor.Stats()
+			if stats.Runs != 1 || stats.Processed != 1 || stats.LastRunAt == nil || stats.Failed != 0 {
+				t.Errorf("stats = %+v, want one successful run", stats)
+			}
+		})
+	}
+}
+
+// TestJanitor_Batches проверяет, что проход обрабатывает все пачки
+func TestJanitor_Batches(t *testing.T) {
+	repo := newMockURLRepository()
+	for i := 0; i < janitorBatch*2+5; i++ {
+		addExpiredURL(repo, fmt.Sprintf("code%d", i), 40*24*time.Hour)
+	}
+
+	janitor, err := NewJanitor(repo, &mockLockRepository{}, nil, config.JanitorConfig{Mode: models.JanitorModeArchive, GraceDays: 30}, 0)
+	if err != nil {
+		t.Fatalf("NewJanitor() error = %v", err)
+	}
+
+	report, err := janitor.RunOnce(context.Background())
+	if err != nil || report.Processed != janitorBatch*2+5 {
+		t.Fatalf("RunOnce() = %+v, %v, want all %d links", report, err, janitorBatch*2+5)
+	}
+
+	report, err = janitor.RunOnce(context.Background())
+	if err != nil || report.Processed != 0 {
+		t.Errorf("second RunOnce() = %+v, %v, want nothing left", report, err)
+	}
+}
+
+// TestJanitor_Locked проверяет, что проход пропускается, пока lock держит другой инстанс
+func TestJanitor_Locked(t *testing.T) {
+	repo := newMockURLRepository()
+	old := addExpiredURL(repo, "old", 40*24*time.Hour)
+
+	janitor, err := NewJanitor(repo, &mockLockRepository{held: true}, nil, config.JanitorConfig{Mode: models.JanitorModeHardDelete, GraceDays: 30}, 0)
+	if err != nil {
+		t.Fatalf("NewJanitor() error = %v", err)
+	}
+
+	if _, err := janitor.RunOnce(context.Background()); !errors.Is(err, ErrJanitorLocked) {
+		t.Fatalf("RunOnce() error = %v, want ErrJanitorLocked", err)
+	}
+	if repo.urlsByID[old.ID] == nil {
+		t.Error("link should not be deleted without lock")
+	}
+	if stats := janitor.Stats(); stats.Skipped != 1 || stats.Runs != 0 {
+		t.Errorf("stats = %+v, want one skipped run", stats)
+	}
+}
+
+// TestNewJanitor_Mode проверяет режимы из конфигурации
+func TestNewJanitor_Mode(t *testing.T) {
+	if _, err := NewJanitor(newMockURLRepository(), &mockLockRepository{}, nil, config.JanitorConfig{Mode: "purge"}, 0); err == nil {
+		t.Error("NewJanitor() should reject unknown mode")
+	}
+
+	janitor, err := NewJanitor(newMockURLRepository(), &mockLockRepository{}, nil, config.JanitorConfig{Mode: models.JanitorModeOff}, 0)
+	if err != nil {
+		t.Fatalf("NewJanitor() error = %v", err)
+	}
+	if _, err := janitor.RunOnce(context.Background()); err == nil {
+		t.Error("RunOnce() should fail when janitor is off")
+	}
+
+	// В режиме off расписание не запускается, но остановка не блокируется
+	janitor.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := janitor.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
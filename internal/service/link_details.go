@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"url-short/internal/models"
+)
+
+// Ограничения полей, по которым ссылки ищутся в списке (в символах)
+const (
+	maxLinkTitleLength  = 255
+	maxLinkNotesLength  = 2000
+	maxLinkFolderLength = 100
+	maxLinkTagLength    = 50
+	maxLinkTags         = 20
+	maxLinkQueryLength  = 200
+	// Ограничения размера страницы списка ссылок
+	defaultURLListLimit = 50
+	maxURLListLimit     = 100
+)
+
+// normalizeLinkDetails проверяет название, заметки, папку и теги ссылки и приводит их
+// к каноническому виду: пробелы по краям убираются, теги - в нижнем регистре без дубликатов.
+// Tags == nil означает, что теги не меняются
+func normalizeLinkDetails(url *models.URL) error {
+	url.Title = strings.TrimSpace(url.Title)
+	if utf8.RuneCountInString(url.Title) > maxLinkTitleLength {
+		return fmt.Errorf("невалидное название: не больше %d символов", maxLinkTitleLength)
+	}
+
+	url.Notes = strings.TrimSpace(url.Notes)
+	if utf8.RuneCountInString(url.Notes) > maxLinkNotesLength {
+		return fmt.Errorf("невалидные заметки: не больше %d символов", maxLinkNotesLength)
+	}
+
+	url.Folder = strings.TrimSpace(url.Folder)
+	if utf8.RuneCountInString(url.Folder) > maxLinkFolderLength {
+		return fmt.Errorf("невалидная папка: не больше %d символов", maxLinkFolderLength)
+	}
+
+	if url.Tags == nil {
+		return nil
+	}
+	if len(url.Tags) > 0 && !url.UserID.Valid {
+		return fmt.Errorf("невалидные теги: теги доступны только для ссылок пользователя")
+	}
+
+	tags := make([]string, 0, len(url.Tags))
+	seen := make(map[string]bool, len(url.Tags))
+	for _, tag := range url.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > maxLinkTagLength || strings.Contains(tag, ",") {
+			return fmt.Errorf("невалидный тег %q: от 1 до %d символов без запятых", tag, maxLinkTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxLinkTags {
+		return fmt.Errorf("невалидные теги: у ссылки не больше %d тегов", maxLinkTags)
+	}
+	url.Tags = tags
+
+	return nil
+}
+
+// normalizeURLFilter подставляет размер страницы по умолчанию, проверяет состояние
+// и приводит значения фильтра к виду, в котором они хранятся
+func normalizeURLFilter(filter *models.URLFilter) error {
+	if filter.Limit <= 0 || filter.Limit > maxURLListLimit {
+		filter.Limit = defaultURLListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	filter.Folder = strings.TrimSpace(filter.Folder)
+	filter.Domain = strings.TrimSpace(filter.Domain)
+	filter.Query = strings.TrimSpace(filter.Query)
+
+	if utf8.RuneCountInString(filter.Query) > maxLinkQueryLength {
+		return fmt.Errorf("невалидный q: не больше %d символов", maxLinkQueryLength)
+	}
+
+	switch filter.State {
+	case "", models.LinkStateActive, models.LinkStateScheduled, models.LinkStateExpired, models.LinkStateArchived:
+	default:
+		return fmt.Errorf("невалидный state: %s (допустимо: %s, %s, %s, %s)", filter.State,
+			models.LinkStateActive, models.LinkStateScheduled, models.LinkStateExpired, models.LinkStateArchived)
+	}
+
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedFrom.Before(filter.CreatedTo) {
+		return fmt.Errorf("невалидный период создания: created_from должен быть раньше created_to")
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// LinkUnlocker выдает и проверяет подписанные токены разблокировки ссылок с паролем.
+// Токен хранится в cookie и действует ttl
+type LinkUnlocker struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewLinkUnlocker создает LinkUnlocker. Если secret пустой, генерируется случайный:
+// токены тогда не переживают перезапуск и не подходят для других инстансов
+func NewLinkUnlocker(secret string, ttl int) *LinkUnlocker {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("ошибка генерации секрета разблокировки: %v", err))
+		}
+		log.Println("LINK_UNLOCK_SECRET не задан: cookie разблокировки ссылок действуют до перезапуска")
+	}
+
+	return &LinkUnlocker{
+		secret: key,
+		ttl:    time.Duration(ttl) * time.Second,
+	}
+}
+
+// TTL время действия токена
+func (u *LinkUnlocker) TTL() time.Duration {
+	return u.ttl
+}
+
+// CookieName имя cookie разблокировки ссылки
+func (u *LinkUnlocker) CookieName(url *models.URL) string {
+	return fmt.Sprintf("link_unlock_%d", url.ID)
+}
+
+// Token создает токен вида <unix время истечения>.<hex HMAC-SHA256>
+func (u *LinkUnlocker) Token(url *models.URL, now time.Time) string {
+	expires := strconv.FormatInt(now.Add(u.ttl).Unix(), 10)
+	return expires + "." + u.sign(url, expires)
+}
+
+// Verify проверяет подпись и срок токена. Подпись включает хеш пароля,
+// поэтому смена пароля отзывает все выданные токены
+func (u *LinkUnlocker) Verify(url *models.URL, token string, now time.Time) bool {
+	expires, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() >= unix {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(u.sign(url, expires)))
+}
+
+// sign подписывает ID ссылки, хеш пароля и время истечения
+func (u *LinkUnlocker) sign(url *models.URL, expires string) string {
+	mac := hmac.New(sha256.New, u.secret)
+	fmt.Fprintf(mac, "%d|%s|%s", url.ID, url.PasswordHash.String, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// TestLinkUnlocker проверяет подпись, срок и отзыв токена сменой пароля
+func TestLinkUnlocker(t *testing.T) {
+	unlocker := NewLinkUnlocker("secret", 3600)
+	url := &models.URL{ID: 1, PasswordHash: sql.NullString{String: "hash-1", Valid: true}}
+	now := time.Now()
+
+	token := unlocker.Token(url, now)
+	if !unlocker.Verify(url, token, now) {
+		t.Fatal("Verify() fresh token = false")
+	}
+
+	if unlocker.Verify(url, token, now.Add(2*time.Hour)) {
+		t.Error("Verify() expired token = true")
+	}
+	if unlocker.Verify(&models.URL{ID: 2, PasswordHash: url.PasswordHash}, token, now) {
+		t.Error("Verify() token of another link = true")
+	}
+	if unlocker.Verify(url, token+"0", now) || unlocker.Verify(url, "garbage", now) {
+		t.Error("Verify() tampered token = true")
+	}
+	if NewLinkUnlocker("other", 3600).Verify(url, token, now) {
+		t.Error("Verify() token signed with another secret = true")
+	}
+
+	changed := &models.URL{ID: 1, PasswordHash: sql.NullString{String: "hash-2", Valid: true}}
+	if unlocker.Verify(changed, token, now) {
+		t.Error("Verify() after password change = true")
+	}
+}
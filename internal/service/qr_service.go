@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"url-short/pkg/qrcode"
+)
+
+// QRCode готовое изображение QR кода
+type QRCode struct {
+	Data        []byte
+	ContentType string
+	// ETag зависит только от содержимого и параметров, поэтому одинаков на всех инстансах
+	ETag string
+}
+
+// QRService интерфейс для генерации QR кодов коротких ссылок
+type QRService interface {
+	QRCodeByID(ctx context.Context, userID, id int64, opts qrcode.Options, withLogo bool) (*QRCode, error)
+	QRCodeByShortCode(ctx context.Context, shortCode string, opts qrcode.Options, withLogo bool) (*QRCode, error)
+}
+
+// qrService имплементация QRService
+type qrService struct {
+	urlService URLService
+	redis      *redis.Client
+	cacheTTL   time.Duration
+	logo       image.Image
+}
+
+// NewQRService создает новый QR service. logo - логотип по центру кода (nil - без логотипа)
+func NewQRService(urlService URLService, redis *redis.Client, cacheTTL int, logo image.Image) QRService {
+	return &qrService{
+		urlService: urlService,
+		redis:      redis,
+		cacheTTL:   time.Duration(cacheTTL) * time.Second,
+		logo:       logo,
+	}
+}
+
+// QRCodeByID рисует QR код ссылки владельца
+func (s *qrService) QRCodeByID(ctx context.Context, userID, id int64, opts qrcode.Options, withLogo bool) (*QRCode, error) {
+	url, err := s.urlService.GetURLByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.render(ctx, url.ShortURL, opts, withLogo)
+}
+
+// QRCodeByShortCode рисует QR код действующей ссылки по короткому коду
+func (s *qrService) QRCodeByShortCode(ctx context.Context, shortCode string, opts qrcode.Options, withLogo bool) (*QRCode, error) {
+	url, err := s.urlService.GetURLByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.render(ctx, s.urlService.ShortURL(url.ShortCode), opts, withLogo)
+}
+
+// render берет изображение из кеша или рисует и кеширует его.
+// Ключ кеша - хеш содержимого и параметров, поэтому смена кода ссылки
+// не требует сброса кеша
+func (s *qrService) render(ctx context.Context, content string, opts qrcode.Options, withLogo bool) (*QRCode, error) {
+	if withLogo {
+		if s.logo == nil {
+			return nil, fmt.Errorf("невалидный параметр logo: логотип не настроен")
+		}
+		opts.Logo = s.logo
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s|%v|%v|%t",
+		content, opts.Format, opts.Size, opts.Level, opts.Foreground, opts.Background, withLogo)))
+	etag := `"` + hex.EncodeToString(hash[:16]) + `"`
+	cacheKey := "qr:" + hex.EncodeToString(hash[:])
+
+	result := &QRCode{ContentType: opts.ContentType(), ETag: etag}
+
+	if s.redis != nil {
+		if data, err := s.redis.Get(ctx, cacheKey).Bytes(); err == nil {
+			result.Data = data
+			return result, nil
+		}
+	}
+
+	data, err := qrcode.Encode(content, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Data = data
+
+	// Кешируем в Redis (игнорируем ошибку кеширования)
+	if s.redis != nil {
+		s.redis.Set(ctx, cacheKey, data, s.cacheTTL) // nolint:errcheck
+	}
+
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"image"
+	"strings"
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+	"url-short/pkg/qrcode"
+)
+
+// TestQRCodeByShortCode проверяет QR код по короткому коду
+func TestQRCodeByShortCode(t *testing.T) {
+	repo := newMockURLRepository()
+	urlService := NewURLService(repo, &mockGenerator{}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	qrService := NewQRService(urlService, nil, 3600, nil)
+
+	expired := time.Now().Add(-time.Hour)
+	for _, req := range []*models.CreateURLRequest{
+		{OriginalURL: "https://example.com", CustomCode: "active"},
+		{OriginalURL: "https://example.com", CustomCode: "old", ExpiresAt: &expired},
+	} {
+		if _, err := urlService.CreateShortURL(context.Background(), testUserID, req); err != nil {
+			t.Fatalf("CreateShortURL() error = %v", err)
+		}
+	}
+
+	code, err := qrService.QRCodeByShortCode(context.Background(), "active", qrcode.DefaultOptions(), false)
+	if err != nil {
+		t.Fatalf("QRCodeByShortCode() error = %v", err)
+	}
+	if code.ContentType != "image/png" || len(code.Data) == 0 || code.ETag == "" {
+		t.Errorf("QRCodeByShortCode() = %+v", code)
+	}
+
+	if _, err := qrService.QRCodeByShortCode(context.Background(), "old", qrcode.DefaultOptions(), false); err == nil || !strings.Contains(err.Error(), "истекла") {
+		t.Errorf("QRCodeByShortCode() expired error = %v", err)
+	}
+	if _, err := qrService.QRCodeByShortCode(context.Background(), "missing", qrcode.DefaultOptions(), false); err == nil {
+		t.Error("QRCodeByShortCode() missing error = nil")
+	}
+}
+
+// TestQRCode_Logo проверяет логотип: без настройки запрос отклоняется, с настройкой меняется ETag
+func TestQRCode_Logo(t *testing.T) {
+	repo := newMockURLRepository()
+	urlService := NewURLService(repo, &mockGenerator{}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	urlService.CreateShortURL(context.Background(), testUserID, &models.CreateURLRequest{OriginalURL: "https://example.com", CustomCode: "logo"}) // nolint:errcheck
+
+	if _, err := NewQRService(urlService, nil, 3600, nil).QRCodeByShortCode(context.Background(), "logo", qrcode.DefaultOptions(), true); err == nil || !strings.Contains(err.Error(), "невалидный") {
+		t.Errorf("QRCodeByShortCode() without configured logo error = %v", err)
+	}
+
+	qrService := NewQRService(urlService, nil, 3600, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	plain, err := qrService.QRCodeByShortCode(context.Background(), "logo", qrcode.DefaultOptions(), false)
+	if err != nil {
+		t.Fatalf("QRCodeByShortCode() error = %v", err)
+	}
+	withLogo, err := qrService.QRCodeByShortCode(context.Background(), "logo", qrcode.DefaultOptions(), true)
+	if err != nil {
+		t.Fatalf("QRCodeByShortCode() with logo error = %v", err)
+	}
+	if plain.ETag == withLogo.ETag {
+		t.Error("ETag does not depend on logo")
+	}
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"url-short/internal/models"
+	"url-short/internal/repository"
+	"url-short/pkg/geoip"
+	"url-short/pkg/useragent"
+)
+
+const (
+	// maxRedirectRules ограничение количества правил редиректа одной ссылки
+	maxRedirectRules = 50
+	// maxDeepLinkLength ограничение длины адреса приложения
+	maxDeepLinkLength = 2048
+)
+
+// forbiddenDeepLinkSchemes схемы, которые нельзя открывать со страницы перехода
+var forbiddenDeepLinkSchemes = map[string]bool{
+	"http": true, "https": true, "javascript": true, "vbscript": true,
+	"data": true, "file": true, "blob": true, "about": true,
+}
+
+// RedirectTarget адрес перехода, выбранный правилами ссылки
+type RedirectTarget struct {
+	URL string
+	// DeepLink адрес приложения, который пробуется до перехода на URL (пусто - обычный редирект)
+	DeepLink string
+	// RuleID правило, выбравшее адрес (0 - ни одно правило не подошло, адрес - original_url)
+	RuleID int64
+}
+
+// RedirectRuleService интерфейс для управления правилами редиректа и выбора адреса перехода
+type RedirectRuleService interface {
+	CreateRule(ctx context.Context, userID, urlID int64, req *models.CreateRedirectRuleRequest) (*models.RedirectRule, error)
+	ListRules(ctx context.Context, userID, urlID int64) ([]*models.RedirectRule, error)
+	UpdateRule(ctx context.Context, userID, urlID, id int64, req *models.UpdateRedirectRuleRequest) (*models.RedirectRule, error)
+	DeleteRule(ctx context.Context, userID, urlID, id int64) error
+	Destination(ctx context.Context, url *models.URL, ip, userAgent string) RedirectTarget
+}
+
+// redirectRuleService имплементация RedirectRuleService
+type redirectRuleService struct {
+	ruleRepo repository.RedirectRuleRepository
+	urlRepo  repository.URLRepository
+	locator  geoip.Locator
+	redis    *redis.Client
+	cacheTTL time.Duration
+}
+
+// NewRedirectRuleService создает новый RedirectRule service.
+// Правила ссылок кешируются в Redis на cacheTTL секунд и сбрасываются при изменении
+func NewRedirectRuleService(
+	ruleRepo repository.RedirectRuleRepository,
+	urlRepo repository.URLRepository,
+	locator geoip.Locator,
+	redis *redis.Client,
+	cacheTTL int,
+) RedirectRuleService {
+	return &redirectRuleService{
+		ruleRepo: ruleRepo,
+		urlRepo:  urlRepo,
+		locator:  locator,
+		redis:    redis,
+		cacheTTL: time.Duration(cacheTTL) * time.Second,
+	}
+}
+
+// CreateRule добавляет правило ссылке владельца
+func (s *redirectRuleService) CreateRule(ctx context.Context, userID, urlID int64, req *models.CreateRedirectRuleRequest) (*models.RedirectRule, error) {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
+		return nil, err
+	}
+
+	rules, err := s.ruleRepo.GetByURL(ctx, urlID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) >= maxRedirectRules {
+		return nil, fmt.Errorf("невалидное правило: у ссылки не больше %d правил", maxRedirectRules)
+	}
+
+	rule := &models.RedirectRule{
+		URLID:     urlID,
+		Priority:  req.Priority,
+		Countries: req.Countries,
+		OS:        req.OS,
+		Devices:   req.Devices,
+		TargetURL: req.TargetURL,
+		DeepLink:  req.DeepLink,
+	}
+	if err := normalizeRedirectRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, urlID)
+
+	return rule, nil
+}
+
+// ListRules получает правила ссылки владельца в порядке проверки
+func (s *redirectRuleService) ListRules(ctx context.Context, userID, urlID int64) ([]*models.RedirectRule, error) {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
+		return nil, err
+	}
+
+	return s.ruleRepo.GetByURL(ctx, urlID)
+}
+
+// UpdateRule частично обновляет правило ссылки владельца
+func (s *redirectRuleService) UpdateRule(ctx context.Context, userID, urlID, id int64, req *models.UpdateRedirectRuleRequest) (*models.RedirectRule, error) {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.ruleRepo.GetByID(ctx, urlID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Countries != nil {
+		rule.Countries = *req.Countries
+	}
+	if req.OS != nil {
+		rule.OS = *req.OS
+	}
+	if req.Devices != nil {
+		rule.Devices = *req.Devices
+	}
+	if req.TargetURL != nil {
+		rule.TargetURL = *req.TargetURL
+	}
+	if req.DeepLink != nil {
+		rule.DeepLink = *req.DeepLink
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+
+	if err := normalizeRedirectRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, urlID)
+
+	return rule, nil
+}
+
+// DeleteRule удаляет правило ссылки владельца
+func (s *redirectRuleService) DeleteRule(ctx context.Context, userID, urlID, id int64) error {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
+		return err
+	}
+
+	if err := s.ruleRepo.Delete(ctx, urlID, id); err != nil {
+		return err
+	}
+	s.invalidate(ctx, urlID)
+
+	return nil
+}
+
+// Destination выбирает адрес перехода: первое правило, подходящее посетителю,
+// иначе original_url. Ошибки правил и геолокации не ломают редирект
+func (s *redirectRuleService) Destination(ctx context.Context, url *models.URL, ip, userAgent string) RedirectTarget {
+	rules := s.rules(ctx, url.ID)
+	if len(rules) == 0 {
+		return RedirectTarget{URL: url.OriginalURL}
+	}
+
+	visitor := s.visitor(rules, ip, userAgent)
+	for _, rule := range rules {
+		if rule.Matches(visitor) {
+			return RedirectTarget{URL: rule.TargetURL, DeepLink: rule.DeepLink, RuleID: rule.ID}
+		}
+	}
+
+	return RedirectTarget{URL: url.OriginalURL}
+}
+
+// visitor определяет признаки посетителя. GeoIP поиск и разбор User-Agent
+// выполняются, только если их проверяет хотя бы одно правило ссылки
+func (s *redirectRuleService) visitor(rules []*models.RedirectRule, ip, userAgent string) models.Visitor {
+	needCountry, needAgent := false, false
+	for _, rule := range rules {
+		needCountry = needCountry || len(rule.Countries) > 0
+		needAgent = needAgent || len(rule.OS) > 0 || len(rule.Devices) > 0
+	}
+
+	var visitor models.Visitor
+	if needCountry {
+		visitor.Country = s.country(ip)
+	}
+	if needAgent && userAgent != "" {
+		info := useragent.Parse(userAgent)
+		visitor.OS = info.OS
+		visitor.Device = info.Device
+	}
+
+	return visitor
+}
+
+// rules получает правила ссылки из кеша или БД. Пустой список тоже кешируется,
+// поэтому ссылки без правил не делают лишний запрос к БД на каждый переход
+func (s *redirectRuleService) rules(ctx context.Context, urlID int64) []*models.RedirectRule {
+	cacheKey := fmt.Sprintf("rules:%d", urlID)
+
+	if s.redis != nil {
+		if data, err := s.redis.Get(ctx, cacheKey).Bytes(); err == nil {
+			var rules []*models.RedirectRule
+			if err := json.Unmarshal(data, &rules); err == nil {
+				return rules
+			}
+		}
+	}
+
+	rules, err := s.ruleRepo.GetByURL(ctx, urlID)
+	if err != nil {
+		log.Printf("Ошибка получения правил редиректа ссылки %d: %v", urlID, err)
+		return nil
+	}
+
+	// Кешируем в Redis (игнорируем ошибку кеширования)
+	if s.redis != nil {
+		if data, err := json.Marshal(rules); err == nil {
+			s.redis.Set(ctx, cacheKey, data, s.cacheTTL) // nolint:errcheck
+		}
+	}
+
+	return rules
+}
+
+// country определяет страну посетителя по локальной GeoIP базе.
+// Для частных адресов и при ошибке поиска возвращается пустая строка
+func (s *redirectRuleService) country(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsPrivate() || parsed.IsLoopback() || parsed.IsUnspecified() {
+		return ""
+	}
+
+	location, err := s.locator.Lookup(ip)
+	if err != nil {
+		return ""
+	}
+
+	return location.Country
+}
+
+// invalidate сбрасывает кеш правил ссылки (игнорируем ошибку)
+func (s *redirectRuleService) invalidate(ctx context.Context, urlID int64) {
+	if s.redis != nil {
+		s.redis.Del(ctx, fmt.Sprintf("rules:%d", urlID)) // nolint:errcheck
+	}
+}
+
+// normalizeRedirectRule проверяет адреса и условия правила и приводит условия
+// к каноническому виду (коды стран в верхнем регистре, названия ОС как у useragent.Parse)
+func normalizeRedirectRule(rule *models.RedirectRule) error {
+	target, err := url.Parse(rule.TargetURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("невалидный адрес правила: нужен http(s) адрес")
+	}
+
+	if err := validateDeepLink(rule.DeepLink); err != nil {
+		return err
+	}
+
+	if len(rule.Countries) == 0 && len(rule.OS) == 0 && len(rule.Devices) == 0 {
+		return fmt.Errorf("невалидное правило: нужно хотя бы одно условие (countries, os или devices)")
+	}
+
+	countries, invalid, ok := normalizeConditions(rule.Countries, func(value string) (string, bool) {
+		code := strings.ToUpper(value)
+		return code, isCountryCode(code)
+	})
+	if !ok {
+		return fmt.Errorf("невалидный код страны: %s", invalid)
+	}
+	rule.Countries = countries
+
+	osFamilies, invalid, ok := normalizeConditions(rule.OS, useragent.CanonicalOS)
+	if !ok {
+		return fmt.Errorf("невалидная ОС: %s (допустимо: %s)", invalid, strings.Join(useragent.OSFamilies, ", "))
+	}
+	rule.OS = osFamilies
+
+	devices, invalid, ok := normalizeConditions(rule.Devices, func(value string) (string, bool) {
+		device := strings.ToLower(value)
+		return device, isDevice(device)
+	})
+	if !ok {
+		return fmt.Errorf("невалидное устройство: %s (допустимо: %s)", invalid, strings.Join(useragent.Devices, ", "))
+	}
+	rule.Devices = devices
+
+	return nil
+}
+
+// normalizeConditions приводит значения условия к каноническому виду и убирает дубликаты.
+// При недопустимом значении возвращает его и false
+func normalizeConditions(values []string, canonical func(string) (string, bool)) ([]string, string, bool) {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		normalized, ok := canonical(strings.TrimSpace(value))
+		if !ok {
+			return nil, value, false
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			result = append(result, normalized)
+		}
+	}
+	return result, "", true
+}
+
+// validateDeepLink проверяет адрес приложения: нужна собственная схема приложения,
+// веб-адреса и схемы, исполняющие код в браузере, запрещены
+func validateDeepLink(deepLink string) error {
+	if deepLink == "" {
+		return nil
+	}
+
+	link, err := url.Parse(deepLink)
+	if err != nil || link.Scheme == "" || forbiddenDeepLinkSchemes[strings.ToLower(link.Scheme)] || len(deepLink) > maxDeepLinkLength {
+		return fmt.Errorf("невалидный deep link: нужен адрес со схемой приложения (например, myapp://path)")
+	}
+
+	return nil
+}
+
+// isDevice проверяет, что класс устройства есть в useragent.Devices
+func isDevice(device string) bool {
+	for _, d := range useragent.Devices {
+		if d == device {
+			return true
+		}
+	}
+	return false
+}
+
+// isCountryCode проверяет формат ISO 3166-1 alpha-2 кода
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"url-short/internal/models"
+	"url-short/pkg/geoip"
+)
+
+// mockRedirectRuleRepository мок для тестирования RedirectRuleService
+type mockRedirectRuleRepository struct {
+	rules  map[int64]*models.RedirectRule
+	nextID int64
+}
+
+func newMockRedirectRuleRepository() *mockRedirectRuleRepository {
+	return &mockRedirectRuleRepository{rules: make(map[int64]*models.RedirectRule), nextID: 1}
+}
+
+func (m *mockRedirectRuleRepository) Create(ctx context.Context, rule *models.RedirectRule) error {
+	rule.ID = m.nextID
+	m.nextID++
+	stored := *rule
+	m.rules[rule.ID] = &stored
+	return nil
+}
+
+func (m *mockRedirectRuleRepository) GetByID(ctx context.Context, urlID, id int64) (*models.RedirectRule, error) {
+	rule, ok := m.rules[id]
+	if !ok || rule.URLID != urlID {
+		return nil, fmt.Errorf("правило с ID %d не найдено", id)
+	}
+	copied := *rule
+	return &copied, nil
+}
+
+func (m *mockRedirectRuleRepository) GetByURL(ctx context.Context, urlID int64) ([]*models.RedirectRule, error) {
+	rules := make([]*models.RedirectRule, 0)
+	for _, rule := range m.rules {
+		if rule.URLID == urlID {
+			rules = append(rules, rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return rules[i].ID < rules[j].ID
+	})
+	return rules, nil
+}
+
+func (m *mockRedirectRuleRepository) Update(ctx context.Context, rule *models.RedirectRule) error {
+	stored := *rule
+	m.rules[rule.ID] = &stored
+	return nil
+}
+
+func (m *mockRedirectRuleRepository) Delete(ctx context.Context, urlID, id int64) error {
+	if rule, ok := m.rules[id]; !ok || rule.URLID != urlID {
+		return fmt.Errorf("правило с ID %d не найдено", id)
+	}
+	delete(m.rules, id)
+	return nil
+}
+
+// newTestRuleService создает сервис правил со ссылкой владельца testUserID
+func newTestRuleService(t *testing.T) (RedirectRuleService, *models.URL) {
+	t.Helper()
+
+	urlRepo := newMockURLRepository()
+	url := &models.URL{ShortCode: "promo", OriginalURL: "https://example.com"}
+	url.UserID.Int64, url.UserID.Valid = testUserID, true
+	if err := urlRepo.Create(context.Background(), url); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	locator := &mockLocator{locations: map[string]geoip.Location{
+		"81.2.69.142":  {Country: "DE"},
+		"2.16.0.1":     {Country: "FR"},
+		"3.3.3.3":      {Country: "US"},
+		"200.200.1.10": {Country: "BR"},
+	}}
+
+	return NewRedirectRuleService(newMockRedirectRuleRepository(), urlRepo, locator, nil, 3600), url
+}
+
+// TestRedirectRuleService_Destination проверяет выбор адреса по стране посетителя
+func TestRedirectRuleService_Destination(t *testing.T) {
+	service, url := newTestRuleService(t)
+	ctx := context.Background()
+
+	for _, req := range []*models.CreateRedirectRuleRequest{
+		{Countries: []string{"de", " at "}, TargetURL: "https://example.com/de"},
+		{Countries: []string{"FR"}, TargetURL: "https://example.com/fr"},
+		{Countries: []string{"US"}, TargetURL: "https://example.com/us", Priority: 10},
+		{Countries: []string{"US"}, TargetURL: "https://example.com/us-first", Priority: 1},
+	} {
+		if _, err := service.CreateRule(ctx, testUserID, url.ID, req); err != nil {
+			t.Fatalf("CreateRule() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"81.2.69.142", "https://example.com/de"},
+		{"2.16.0.1", "https://example.com/fr"},
+		{"3.3.3.3", "https://example.com/us-first"},
+		{"200.200.1.10", "https://example.com"},
+		{"192.168.1.1", "https://example.com"},
+		{"", "https://example.com"},
+	}
+
+	for _, tt := range tests {
+		if got := service.Destination(ctx, url, tt.ip, "").URL; got != tt.want {
+			t.Errorf("Destination(%q) = %s, want %s", tt.ip, got, tt.want)
+		}
+	}
+}
+
+// TestRedirectRuleService_Manage проверяет валидацию, владельца и изменение правил
+func TestRedirectRuleService_Manage(t *testing.T) {
+	service, url := newTestRuleService(t)
+	ctx := context.Background()
+
+	invalid := []*models.CreateRedirectRuleRequest{
+		{Countries: []string{"DE"}, TargetURL: "ftp://example.com"},
+		{Countries: []string{"DE"}, TargetURL: "example.com/de"},
+		{Countries: nil, TargetURL: "https://example.com/de"},
+		{Countries: []string{"DEU"}, TargetURL: "https://example.com/de"},
+		{OS: []string{"symbian"}, TargetURL: "https://example.com/app"},
+		{Devices: []string{"watch"}, TargetURL: "https://example.com/app"},
+		{OS: []string{"iOS"}, TargetURL: "https://example.com/app", DeepLink: "javascript:alert(1)"},
+		{OS: []string{"iOS"}, TargetURL: "https://example.com/app", DeepLink: "https://example.com/app"},
+		{OS: []string{"iOS"}, TargetURL: "https://example.com/app", DeepLink: "no-scheme"},
+	}
+	for _, req := range invalid {
+		if _, err := service.CreateRule(ctx, testUserID, url.ID, req); err == nil || !strings.Contains(err.Error(), "невалидн") {
+			t.Errorf("CreateRule(%+v) error = %v, want validation error", req, err)
+		}
+	}
+
+	req := &models.CreateRedirectRuleRequest{Countries: []string{"de", "DE"}, TargetURL: "https://example.com/de"}
+	if _, err := service.CreateRule(ctx, testUserID+1, url.ID, req); err == nil || !strings.Contains(err.Error(), "не найден") {
+		t.Errorf("CreateRule() for foreign link error = %v, want not found", err)
+	}
+
+	rule, err := service.CreateRule(ctx, testUserID, url.ID, req)
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	if len(rule.Countries) != 1 || rule.Countries[0] != "DE" {
+		t.Errorf("Countries = %v, want [DE]", rule.Countries)
+	}
+
+	target := "https://example.com/de-new"
+	updated, err := service.UpdateRule(ctx, testUserID, url.ID, rule.ID, &models.UpdateRedirectRuleRequest{TargetURL: &target})
+	if err != nil {
+		t.Fatalf("UpdateRule() error = %v", err)
+	}
+	if updated.TargetURL != target || len(updated.Countries) != 1 {
+		t.Errorf("UpdateRule() = %+v", updated)
+	}
+	if got := service.Destination(ctx, url, "81.2.69.142", "").URL; got != target {
+		t.Errorf("Destination() after update = %s, want %s", got, target)
+	}
+
+	if err := service.DeleteRule(ctx, testUserID, url.ID, rule.ID); err != nil {
+		t.Fatalf("DeleteRule() error = %v", err)
+	}
+	rules, err := service.ListRules(ctx, testUserID, url.ID)
+	if err != nil || len(rules) != 0 {
+		t.Errorf("ListRules() = %v, %v, want empty", rules, err)
+	}
+	if got := service.Destination(ctx, url, "81.2.69.142", "").URL; got != url.OriginalURL {
+		t.Errorf("Destination() after delete = %s, want original", got)
+	}
+}
+
+// TestRedirectRuleService_DeviceTargeting проверяет выбор адреса по ОС и устройству
+func TestRedirectRuleService_DeviceTargeting(t *testing.T) {
+	service, url := newTestRuleService(t)
+	ctx := context.Background()
+
+	for _, req := range []*models.CreateRedirectRuleRequest{
+		{OS: []string{"ios"}, TargetURL: "https://apps.apple.com/app/id1", DeepLink: "myapp://home"},
+		{OS: []string{"Android"}, TargetURL: "https://play.google.com/store/apps/details?id=app"},
+		{Countries: []string{"DE"}, Devices: []string{"Desktop"}, TargetURL: "https://example.com/de-desktop"},
+	} {
+		if _, err := service.CreateRule(ctx, testUserID, url.ID, req); err != nil {
+			t.Fatalf("CreateRule(%+v) error = %v", req, err)
+		}
+	}
+
+	const (
+		iphone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1"
+		android = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"
+		windows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	)
+
+	tests := []struct {
+		name         string
+		ip           string
+		userAgent    string
+		wantURL      string
+		wantDeepLink string
+	}{
+		{"iPhone", "3.3.3.3", iphone, "https://apps.apple.com/app/id1", "myapp://home"},
+		{"Android", "3.3.3.3", android, "https://play.google.com/store/apps/details?id=app", ""},
+		{"desktop из DE", "81.2.69.142", windows, "https://example.com/de-desktop", ""},
+		{"desktop из US", "3.3.3.3", windows, "https://example.com", ""},
+		{"без User-Agent", "81.2.69.142", "", "https://example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := service.Destination(ctx, url, tt.ip, tt.userAgent)
+			if got.URL != tt.wantURL || got.DeepLink != tt.wantDeepLink {
+				t.Errorf("Destination() = %+v, want %s / %s", got, tt.wantURL, tt.wantDeepLink)
+			}
+		})
+	}
+}
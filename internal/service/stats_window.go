@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"url-short/internal/models"
+)
+
+const (
+	// defaultRecentLimit количество последних кликов в статистике по умолчанию
+	defaultRecentLimit = 100
+	// maxRecentLimit максимальное количество последних кликов в статистике
+	maxRecentLimit = 1000
+	// maxStatsBuckets ограничение числа интервалов в clicks_by_date
+	maxStatsBuckets = 1000
+)
+
+// defaultStatsPeriods период статистики по умолчанию (если from не указан)
+var defaultStatsPeriods = map[string]func(to time.Time) time.Time{
+	models.GranularityHour:  func(to time.Time) time.Time { return to.Add(-24 * time.Hour) },
+	models.GranularityDay:   func(to time.Time) time.Time { return to.AddDate(0, 0, -30) },
+	models.GranularityWeek:  func(to time.Time) time.Time { return to.AddDate(0, 0, -7*12) },
+	models.GranularityMonth: func(to time.Time) time.Time { return to.AddDate(-1, 0, 0) },
+}
+
+// resolveStatsFilter заполняет значения по умолчанию и проверяет фильтр статистики
+func resolveStatsFilter(filter models.StatsFilter, now time.Time) (models.StatsFilter, error) {
+	if filter.Granularity == "" {
+		filter.Granularity = models.GranularityDay
+	}
+	defaultFrom, ok := defaultStatsPeriods[filter.Granularity]
+	if !ok {
+		return filter, fmt.Errorf("невалидный granularity: %s (допустимо hour, day, week, month)", filter.Granularity)
+	}
+
+	if filter.Location == nil {
+		filter.Location = time.UTC
+	}
+	if filter.To.IsZero() {
+		filter.To = now
+	}
+	if filter.From.IsZero() {
+		filter.From = defaultFrom(filter.To)
+	}
+	if !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("невалидный период: from должен быть раньше to")
+	}
+
+	if filter.RecentLimit == 0 {
+		filter.RecentLimit = defaultRecentLimit
+	}
+	if filter.RecentLimit < 0 || filter.RecentLimit > maxRecentLimit {
+		return filter, fmt.Errorf("невалидный recent_limit: допустимо от 1 до %d", maxRecentLimit)
+	}
+
+	if countBuckets(filter) > maxStatsBuckets {
+		return filter, fmt.Errorf("невалидный период: больше %d интервалов %s", maxStatsBuckets, filter.Granularity)
+	}
+
+	return filter, nil
+}
+
+// truncateBucket возвращает начало интервала, содержащего t, в часовом поясе loc.
+// Недели начинаются с понедельника, как date_trunc в PostgreSQL
+func truncateBucket(t time.Time, granularity string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch granularity {
+	case models.GranularityHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	case models.GranularityWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case models.GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+// nextBucket возвращает начало следующего интервала
+func nextBucket(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case models.GranularityHour:
+		return t.Add(time.Hour)
+	case models.GranularityWeek:
+		return t.AddDate(0, 0, 7)
+	case models.GranularityMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// countBuckets считает интервалы окна фильтра (с остановкой после maxStatsBuckets)
+func countBuckets(filter models.StatsFilter) int {
+	count := 0
+	for t := truncateBucket(filter.From, filter.Granularity, filter.Location); t.Before(filter.To); t = nextBucket(t, filter.Granularity) {
+		count++
+		if count > maxStatsBuckets {
+			break
+		}
+	}
+	return count
+}
+
+// fillClickBuckets дополняет клики по интервалам нулевыми значениями,
+// чтобы в ответе был каждый интервал окна по порядку
+func fillClickBuckets(clicks []models.ClicksByDate, filter models.StatsFilter) []models.ClicksByDate {
+	counts := make(map[string]int64, len(clicks))
+	for _, item := range clicks {
+		counts[item.Date] += item.Count
+	}
+
+	filled := make([]models.ClicksByDate, 0, countBuckets(filter))
+	for t := truncateBucket(filter.From, filter.Granularity, filter.Location); t.Before(filter.To); t = nextBucket(t, filter.Granularity) {
+		date := t.Format(time.RFC3339)
+		filled = append(filled, models.ClicksByDate{Date: date, Count: counts[date]})
+	}
+
+	return filled
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// TestResolveStatsFilter проверяет значения по умолчанию и валидацию фильтра
+func TestResolveStatsFilter(t *testing.T) {
+	now := time.Date(2025, 12, 15, 10, 30, 0, 0, time.UTC)
+
+	filter, err := resolveStatsFilter(models.StatsFilter{}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Granularity != models.GranularityDay || filter.Location != time.UTC || filter.RecentLimit != defaultRecentLimit {
+		t.Errorf("defaults = %s/%s/%d", filter.Granularity, filter.Location, filter.RecentLimit)
+	}
+	if !filter.To.Equal(now) || !filter.From.Equal(now.AddDate(0, 0, -30)) {
+		t.Errorf("window = %s..%s", filter.From, filter.To)
+	}
+
+	invalid := []models.StatsFilter{
+		{Granularity: "minute"},
+		{From: now, To: now.Add(-time.Hour)},
+		{RecentLimit: maxRecentLimit + 1},
+		{Granularity: models.GranularityHour, From: now.AddDate(-1, 0, 0), To: now},
+	}
+	for _, f := range invalid {
+		if _, err := resolveStatsFilter(f, now); err == nil {
+			t.Errorf("resolveStatsFilter(%+v) expected error", f)
+		}
+	}
+}
+
+// TestFillClickBuckets проверяет заполнение пропусков нулями
+func TestFillClickBuckets(t *testing.T) {
+	filter := models.StatsFilter{
+		From:        time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2025, 12, 4, 0, 0, 0, 0, time.UTC),
+		Granularity: models.GranularityDay,
+		Location:    time.UTC,
+	}
+
+	filled := fillClickBuckets([]models.ClicksByDate{
+		{Date: "2025-12-02T00:00:00Z", Count: 5},
+	}, filter)
+
+	want := []models.ClicksByDate{
+		{Date: "2025-12-01T00:00:00Z", Count: 0},
+		{Date: "2025-12-02T00:00:00Z", Count: 5},
+		{Date: "2025-12-03T00:00:00Z", Count: 0},
+	}
+	if len(filled) != len(want) {
+		t.Fatalf("len = %d, want %d", len(filled), len(want))
+	}
+	for i := range want {
+		if filled[i] != want[i] {
+			t.Errorf("bucket %d = %+v, want %+v", i, filled[i], want[i])
+		}
+	}
+}
+
+// TestTruncateBucket проверяет границы интервалов в часовом поясе
+func TestTruncateBucket(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skipf("нет базы часовых поясов: %v", err)
+	}
+
+	// 2025-12-17 22:30 UTC = 2025-12-18 01:30 MSK (четверг)
+	clicked := time.Date(2025, 12, 17, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		granularity string
+		want        string
+	}{
+		{models.GranularityHour, "2025-12-18T01:00:00+03:00"},
+		{models.GranularityDay, "2025-12-18T00:00:00+03:00"},
+		{models.GranularityWeek, "2025-12-15T00:00:00+03:00"},
+		{models.GranularityMonth, "2025-12-01T00:00:00+03:00"},
+	}
+
+	for _, tt := range tests {
+		got := truncateBucket(clicked, tt.granularity, moscow).Format(time.RFC3339)
+		if got != tt.want {
+			t.Errorf("truncateBucket(%s) = %s, want %s", tt.granularity, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"url-short/internal/models"
+)
+
+// Ограничения пакетных операций
+const (
+	// maxBulkLinks строк или ID в одном запросе
+	maxBulkLinks = 1000
+	// bulkCreateBatch ссылок в одной транзакции пакетного создания
+	bulkCreateBatch = 100
+)
+
+// BulkCreate создает ссылки пакетом. Строки проверяются по одной и сохраняются транзакциями
+// по bulkCreateBatch ссылок; ошибка строки (занятый код, невалидный срок) не мешает остальным
+func (s *urlService) BulkCreate(ctx context.Context, userID int64, rows []models.BulkCreateRow) (*models.BulkCreateResponse, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("невалидный пакет: нет ссылок")
+	}
+	if len(rows) > maxBulkLinks {
+		return nil, fmt.Errorf("невалидный пакет: не больше %d ссылок", maxBulkLinks)
+	}
+
+	response := &models.BulkCreateResponse{Results: make([]models.BulkCreateResult, len(rows))}
+
+	var batch []*models.URL
+	var batchRows []int
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		errs, err := s.urlRepo.CreateBatch(ctx, batch)
+		for i, url := range batch {
+			result := &response.Results[batchRows[i]]
+			switch {
+			case err != nil:
+				result.Error = err.Error()
+			case errs[i] != nil:
+				result.Error = errs[i].Error()
+			default:
+				result.URL = s.created(ctx, url)
+			}
+		}
+		batch, batchRows = nil, nil
+	}
+
+	for i, row := range rows {
+		response.Results[i].Row = i + 1
+
+		url, err := s.prepareBulkURL(ctx, userID, row)
+		if err != nil {
+			response.Results[i].Error = err.Error()
+			continue
+		}
+
+		batch = append(batch, url)
+		batchRows = append(batchRows, i)
+		if len(batch) == bulkCreateBatch {
+			flush()
+		}
+	}
+	flush()
+
+	for _, result := range response.Results {
+		if result.Error != "" {
+			response.Failed++
+		} else {
+			response.Created++
+		}
+	}
+
+	return response, nil
+}
+
+// prepareBulkURL проверяет строку пакета так же, как запрос на создание одной ссылки.
+// Повтор кода внутри пакета отклоняется при вставке
+func (s *urlService) prepareBulkURL(ctx context.Context, userID int64, row models.BulkCreateRow) (*models.URL, error) {
+	if row.ParseError != "" {
+		return nil, errors.New(row.ParseError)
+	}
+	if row.Request.OriginalURL == "" {
+		return nil, fmt.Errorf("URL обязателен")
+	}
+
+	req := row.Request
+	return s.prepareURL(ctx, userID, &req)
+}
+
+// BulkDelete удаляет ссылки пользователя по ID
+func (s *urlService) BulkDelete(ctx context.Context, userID int64, ids []int64) (*models.BulkResponse, error) {
+	ids, err := bulkIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return runBulk(ids, func(id int64) error {
+		return s.DeleteURL(ctx, userID, id)
+	}), nil
+}
+
+// BulkRetag заменяет теги ссылок или добавляет и снимает отдельные теги
+func (s *urlService) BulkRetag(ctx context.Context, userID int64, req *models.BulkTagsRequest) (*models.BulkResponse, error) {
+	ids, err := bulkIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	modify := len(req.Add) > 0 || len(req.Remove) > 0
+	if req.Tags != nil && modify {
+		return nil, fmt.Errorf("невалидный запрос: tags не совмещается с add и remove")
+	}
+	if req.Tags == nil && !modify {
+		return nil, fmt.Errorf("невалидный запрос: нужны tags или add/remove")
+	}
+
+	return runBulk(ids, func(id int64) error {
+		if req.Tags != nil {
+			_, err := s.UpdateURL(ctx, userID, id, &models.UpdateURLRequest{Tags: req.Tags})
+			return err
+		}
+
+		url, err := getOwnedURL(ctx, s.urlRepo, userID, id)
+		if err != nil {
+			return err
+		}
+		tags := retag(url.Tags, req.Add, req.Remove)
+		_, err = s.updateURL(ctx, userID, url, &models.UpdateURLRequest{Tags: &tags})
+		return err
+	}), nil
+}
+
+// BulkSetExpiry задает или снимает срок действия ссылок
+func (s *urlService) BulkSetExpiry(ctx context.Context, userID int64, req *models.BulkExpiryRequest) (*models.BulkResponse, error) {
+	ids, err := bulkIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if (req.ExpiresAt != nil) == req.ClearExpiresAt {
+		return nil, fmt.Errorf("невалидный запрос: нужен expires_at или clear_expires_at")
+	}
+
+	update := &models.UpdateURLRequest{ExpiresAt: req.ExpiresAt, ClearExpiresAt: req.ClearExpiresAt}
+	return runBulk(ids, func(id int64) error {
+		_, err := s.UpdateURL(ctx, userID, id, update)
+		return err
+	}), nil
+}
+
+// bulkIDs проверяет список ID пакетной операции и убирает повторы, сохраняя порядок
+func bulkIDs(ids []int64) ([]int64, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("невалидный запрос: нет ids")
+	}
+	if len(ids) > maxBulkLinks {
+		return nil, fmt.Errorf("невалидный запрос: не больше %d ids", maxBulkLinks)
+	}
+
+	unique := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique, nil
+}
+
+// runBulk выполняет операцию для каждой ссылки. Ошибка одной ссылки не прерывает остальные
+func runBulk(ids []int64, op func(id int64) error) *models.BulkResponse {
+	response := &models.BulkResponse{Results: make([]models.BulkResult, 0, len(ids))}
+	for _, id := range ids {
+		result := models.BulkResult{ID: id}
+		if err := op(id); err != nil {
+			result.Error = err.Error()
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+		response.Results = append(response.Results, result)
+	}
+	return response
+}
+
+// retag добавляет и снимает теги. Снимаемые теги сравниваются без учета регистра,
+// повторы убирает normalizeLinkDetails
+func retag(tags, add, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, tag := range remove {
+		removed[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+
+	result := make([]string, 0, len(tags)+len(add))
+	for _, list := range [][]string{tags, add} {
+		for _, tag := range list {
+			if !removed[strings.ToLower(strings.TrimSpace(tag))] {
+				result = append(result, tag)
+			}
+		}
+	}
+	return result
+}
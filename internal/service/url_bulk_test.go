@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// TestBulkCreate проверяет результаты по строкам и сохранение пакетами
+func TestBulkCreate(t *testing.T) {
+	repo := newMockURLRepository()
+	service := NewURLService(repo, &mockGenerator{}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	ctx := context.Background()
+
+	repo.Create(ctx, &models.URL{ShortCode: "taken", OriginalURL: "https://example.com"}) // nolint:errcheck
+
+	rows := []models.BulkCreateRow{
+		{Request: models.CreateURLRequest{OriginalURL: "https://example.com/1", CustomCode: "promo1"}},
+		{Request: models.CreateURLRequest{OriginalURL: "https://example.com/2", CustomCode: "promo1"}},
+		{Request: models.CreateURLRequest{CustomCode: "nourl"}},
+		{ParseError: "невалидное значение max_clicks: ожидается целое число"},
+		{Request: models.CreateURLRequest{OriginalURL: "https://example.com/5", CustomCode: "taken"}},
+		{Request: models.CreateURLRequest{OriginalURL: "https://example.com/6", CustomCode: "promo2", Tags: []string{"Spring"}}},
+	}
+	response, err := service.BulkCreate(ctx, testUserID, rows)
+	if err != nil {
+		t.Fatalf("BulkCreate() error = %v", err)
+	}
+	if response.Created != 2 || response.Failed != 4 {
+		t.Errorf("created = %d, failed = %d, want 2 and 4", response.Created, response.Failed)
+	}
+
+	wantErrors := []string{"", "занят", "URL обязателен", "max_clicks", "занят", ""}
+	for i, result := range response.Results {
+		if result.Row != i+1 {
+			t.Errorf("results[%d].Row = %d, want %d", i, result.Row, i+1)
+		}
+		if wantErrors[i] == "" {
+			if result.Error != "" || result.URL == nil {
+				t.Errorf("row %d: error = %q, want created link", result.Row, result.Error)
+			}
+			continue
+		}
+		if !strings.Contains(result.Error, wantErrors[i]) || result.URL != nil {
+			t.Errorf("row %d: error = %q, want %q", result.Row, result.Error, wantErrors[i])
+		}
+	}
+	if got := response.Results[5].URL; got.ShortCode != "promo2" || len(got.Tags) != 1 || got.Tags[0] != "spring" {
+		t.Errorf("row 6 = %+v, want promo2 with normalized tag", got)
+	}
+
+	repo.batchSizes = nil
+	rows = make([]models.BulkCreateRow, 250)
+	for i := range rows {
+		rows[i].Request = models.CreateURLRequest{OriginalURL: "https://example.com", CustomCode: fmt.Sprintf("batch%03d", i)}
+	}
+	if _, err := service.BulkCreate(ctx, testUserID, rows); err != nil {
+		t.Fatalf("BulkCreate() error = %v", err)
+	}
+	if fmt.Sprint(repo.batchSizes) != "[100 100 50]" {
+		t.Errorf("batch sizes = %v, want [100 100 50]", repo.batchSizes)
+	}
+
+	for _, rows := range [][]models.BulkCreateRow{nil, make([]models.BulkCreateRow, maxBulkLinks+1)} {
+		if _, err := service.BulkCreate(ctx, testUserID, rows); err == nil || !strings.Contains(err.Error(), "невалидн") {
+			t.Errorf("BulkCreate(%d rows) error = %v, want validation error", len(rows), err)
+		}
+	}
+}
+
+// TestBulkOperations проверяет удаление, теги и срок действия по списку ID
+func TestBulkOperations(t *testing.T) {
+	repo := newMockURLRepository()
+	service := NewURLService(repo, &mockGenerator{}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	ctx := context.Background()
+
+	owner := sql.NullInt64{Int64: testUserID, Valid: true}
+	for i := 1; i <= 3; i++ {
+		repo.Create(ctx, &models.URL{ShortCode: fmt.Sprintf("own%d", i), UserID: owner, Tags: []string{"old", "keep"}}) // nolint:errcheck
+	}
+	repo.Create(ctx, &models.URL{ShortCode: "foreign", UserID: sql.NullInt64{Int64: 99, Valid: true}}) // nolint:errcheck
+
+	response, err := service.BulkRetag(ctx, testUserID, &models.BulkTagsRequest{
+		IDs:    []int64{1, 2, 2, 4},
+		Add:    []string{"New"},
+		Remove: []string{"OLD"},
+	})
+	if err != nil {
+		t.Fatalf("BulkRetag() error = %v", err)
+	}
+	if response.Succeeded != 2 || response.Failed != 1 || len(response.Results) != 3 {
+		t.Errorf("response = %+v, want 2 succeeded and foreign link failed", response)
+	}
+	if got := fmt.Sprint(repo.urlsByID[1].Tags); got != "[keep new]" {
+		t.Errorf("tags = %s, want [keep new]", got)
+	}
+	if got := fmt.Sprint(repo.urlsByID[3].Tags); got != "[old keep]" {
+		t.Errorf("untouched link tags = %s, want [old keep]", got)
+	}
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+	response, err = service.BulkSetExpiry(ctx, testUserID, &models.BulkExpiryRequest{IDs: []int64{1, 3}, ExpiresAt: &expiresAt})
+	if err != nil || response.Succeeded != 2 {
+		t.Fatalf("BulkSetExpiry() = %+v, %v, want 2 succeeded", response, err)
+	}
+	if !repo.urlsByID[3].ExpiresAt.Valid || !repo.urlsByID[3].ExpiresAt.Time.Equal(expiresAt) {
+		t.Errorf("expires_at = %v, want %v", repo.urlsByID[3].ExpiresAt, expiresAt)
+	}
+
+	response, err = service.BulkDelete(ctx, testUserID, []int64{1, 4, 100})
+	if err != nil {
+		t.Fatalf("BulkDelete() error = %v", err)
+	}
+	if response.Succeeded != 1 || response.Failed != 2 || !repo.urlsByID[1].DeletedAt.Valid || repo.urlsByID[4].DeletedAt.Valid {
+		t.Errorf("response = %+v, want only own link deleted", response)
+	}
+
+	tags := []string{"x"}
+	invalid := []func() error{
+		func() error { _, err := service.BulkDelete(ctx, testUserID, nil); return err },
+		func() error {
+			_, err := service.BulkRetag(ctx, testUserID, &models.BulkTagsRequest{IDs: []int64{2}})
+			return err
+		},
+		func() error {
+			_, err := service.BulkRetag(ctx, testUserID, &models.BulkTagsRequest{IDs: []int64{2}, Tags: &tags, Add: tags})
+			return err
+		},
+		func() error {
+			_, err := service.BulkSetExpiry(ctx, testUserID, &models.BulkExpiryRequest{IDs: []int64{2}})
+			return err
+		},
+	}
+	for i, call := range invalid {
+		if err := call(); err == nil || !strings.Contains(err.Error(), "невалидн") {
+			t.Errorf("invalid request %d: error = %v, want validation error", i, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// cursorTimeLayout формат времени в курсоре: время хранится без часового пояса
+// с точностью до микросекунд, как в PostgreSQL
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+// neverClickedValue значение last_clicked_at в курсоре для ссылок без переходов
+const neverClickedValue = "1970-01-01 00:00:00"
+
+// defaultURLSort сортировка списка ссылок по умолчанию: новые первыми
+const defaultURLSort = "-" + models.URLSortCreatedAt
+
+// parseURLSort разбирает параметр sort: имя поля с необязательным префиксом "-" (по убыванию)
+func parseURLSort(sort string) (string, bool, error) {
+	if sort == "" {
+		sort = defaultURLSort
+	}
+	field := strings.TrimPrefix(sort, "-")
+	switch field {
+	case models.URLSortCreatedAt, models.URLSortClicksCount, models.URLSortLastClicked:
+		return field, field != sort, nil
+	default:
+		return "", false, fmt.Errorf("невалидный sort: %s (допустимо: %s, %s, %s, с префиксом - по убыванию)",
+			sort, models.URLSortCreatedAt, models.URLSortClicksCount, models.URLSortLastClicked)
+	}
+}
+
+// encodeURLCursor кодирует позицию после ссылки url в непрозрачную строку
+func encodeURLCursor(sort string, url *models.URL) string {
+	cursor := models.URLCursor{Sort: sort, ID: url.ID}
+	switch strings.TrimPrefix(sort, "-") {
+	case models.URLSortClicksCount:
+		cursor.Value = strconv.FormatInt(url.ClicksCount, 10)
+	case models.URLSortLastClicked:
+		cursor.Value = neverClickedValue
+		if url.LastClickedAt.Valid {
+			cursor.Value = url.LastClickedAt.Time.Format(cursorTimeLayout)
+		}
+	default:
+		cursor.Value = url.CreatedAt.Format(cursorTimeLayout)
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeURLCursor разбирает курсор и проверяет, что он выдан для той же сортировки
+func decodeURLCursor(value, sort string) (*models.URLCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("невалидный cursor")
+	}
+
+	var cursor models.URLCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 || cursor.Value == "" {
+		return nil, fmt.Errorf("невалидный cursor")
+	}
+	if cursor.Sort != sort {
+		return nil, fmt.Errorf("невалидный cursor: выдан для sort=%s, запрошен sort=%s", cursor.Sort, sort)
+	}
+
+	// Значение подставляется в запрос с приведением типа, поэтому проверяется заранее
+	if strings.TrimPrefix(sort, "-") == models.URLSortClicksCount {
+		_, err = strconv.ParseInt(cursor.Value, 10, 64)
+	} else {
+		_, err = time.Parse(cursorTimeLayout, cursor.Value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("невалидный cursor")
+	}
+
+	return &cursor, nil
+}
@@ -2,27 +2,62 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"golang.org/x/crypto/bcrypt"
 
 	"url-short/internal/models"
 	"url-short/internal/repository"
 	"url-short/pkg/shortener"
 )
 
+// Форматы коротких ссылок
+const (
+	// ShortURLStylePath ссылка вида {baseURL}/{code}
+	ShortURLStylePath = "path"
+	// ShortURLStyleQuery ссылка вида {baseURL}/api/r?code={code}
+	ShortURLStyleQuery = "query"
+)
+
 // URLService интерфейс для бизнес-логики работы с URL
 type URLService interface {
-	CreateShortURL(ctx context.Context, req *models.CreateURLRequest) (*models.URLResponse, error)
+	CreateShortURL(ctx context.Context, userID int64, req *models.CreateURLRequest) (*models.URLResponse, error)
 	GetOriginalURL(ctx context.Context, shortCode string) (string, error)
 	GetURLByShortCode(ctx context.Context, shortCode string) (*models.URL, error)
-	GetURLByID(ctx context.Context, id int64) (*models.URLResponse, error)
-	GetAllURLs(ctx context.Context, limit, offset int) ([]*models.URLResponse, error)
-	DeleteURL(ctx context.Context, id int64) error
+	GetURLByID(ctx context.Context, userID, id int64) (*models.URLResponse, error)
+	GetAllURLs(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error)
+	ListURLs(ctx context.Context, userID int64, req models.URLListRequest) (*models.URLPage, error)
+	BulkCreate(ctx context.Context, userID int64, rows []models.BulkCreateRow) (*models.BulkCreateResponse, error)
+	BulkDelete(ctx context.Context, userID int64, ids []int64) (*models.BulkResponse, error)
+	BulkRetag(ctx context.Context, userID int64, req *models.BulkTagsRequest) (*models.BulkResponse, error)
+	BulkSetExpiry(ctx context.Context, userID int64, req *models.BulkExpiryRequest) (*models.BulkResponse, error)
+	ListTags(ctx context.Context, userID int64) ([]models.GroupCount, error)
+	ListFolders(ctx context.Context, userID int64) ([]models.GroupCount, error)
+	UpdateURL(ctx context.Context, userID, id int64, req *models.UpdateURLRequest) (*models.URLResponse, error)
+	DeleteURL(ctx context.Context, userID, id int64) error
+	ListTrash(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error)
+	RestoreURL(ctx context.Context, userID, id int64) (*models.URLResponse, error)
+	PurgeDeletedLinks(ctx context.Context, retention, quarantine time.Duration) (int, error)
 	IncrementClicks(ctx context.Context, id int64) error
+	NotifyExpiredLinks(ctx context.Context) (int, error)
+	ShortURL(shortCode string) string
+	VerifyPassword(url *models.URL, password string) bool
+	ConsumeClick(ctx context.Context, url *models.URL) error
 }
 
+// expiredLinksBatch количество истекших ссылок, обрабатываемых за один проход
+const expiredLinksBatch = 100
+
+// Ограничения длины пароля ссылки (bcrypt учитывает только первые 72 байта)
+const (
+	minLinkPasswordLength = 4
+	maxLinkPasswordLength = 72
+)
+
 // urlService имплементация URLService
 type urlService struct {
 	urlRepo   repository.URLRepository
@@ -30,6 +65,8 @@ type urlService struct {
 	redis     *redis.Client
 	baseURL   string
 	cacheTTL  time.Duration
+	urlStyle  string
+	notifier  WebhookNotifier
 }
 
 // NewURLService создает новый URL service
@@ -39,35 +76,50 @@ func NewURLService(
 	redis *redis.Client,
 	baseURL string,
 	cacheTTL int,
+	urlStyle string,
+	notifier WebhookNotifier,
 ) URLService {
+	// По умолчанию формат прежний, чтобы short_url существующих установок не менялся
+	if urlStyle != ShortURLStylePath {
+		urlStyle = ShortURLStyleQuery
+	}
+
 	return &urlService{
 		urlRepo:   urlRepo,
 		generator: generator,
 		redis:     redis,
-		baseURL:   baseURL,
+		baseURL:   strings.TrimRight(baseURL, "/"),
 		cacheTTL:  time.Duration(cacheTTL) * time.Second,
+		urlStyle:  urlStyle,
+		notifier:  notifier,
 	}
 }
 
-// CreateShortURL создает короткую ссылку
-func (s *urlService) CreateShortURL(ctx context.Context, req *models.CreateURLRequest) (*models.URLResponse, error) {
+// CreateShortURL создает короткую ссылку.
+// userID - владелец ссылки (0 для анонимной ссылки)
+func (s *urlService) CreateShortURL(ctx context.Context, userID int64, req *models.CreateURLRequest) (*models.URLResponse, error) {
+	url, err := s.prepareURL(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.urlRepo.Create(ctx, url); err != nil {
+		return nil, fmt.Errorf("ошибка создания URL: %w", err)
+	}
+
+	return s.created(ctx, url), nil
+}
+
+// prepareURL проверяет запрос на создание ссылки и собирает ссылку для сохранения:
+// выбирает короткий код, проверяет расписание, детали, пароль и лимит переходов
+func (s *urlService) prepareURL(ctx context.Context, userID int64, req *models.CreateURLRequest) (*models.URL, error) {
 	var shortCode string
 	var err error
 
 	// Если пользователь предоставил свой код
 	if req.CustomCode != "" {
-		// Проверяем валидность кода
-		if !shortener.IsValidShortCode(req.CustomCode) {
-			return nil, fmt.Errorf("невалидный короткий код")
-		}
-
-		// Проверяем что код еще не занят
-		exists, err := s.urlRepo.ShortCodeExists(ctx, req.CustomCode)
-		if err != nil {
-			return nil, fmt.Errorf("ошибка проверки кода: %w", err)
-		}
-		if exists {
-			return nil, fmt.Errorf("короткий код уже занят")
+		if err := s.validateCustomCode(ctx, req.CustomCode); err != nil {
+			return nil, err
 		}
 
 		shortCode = req.CustomCode
@@ -86,7 +138,7 @@ func (s *urlService) CreateShortURL(ctx context.Context, req *models.CreateURLRe
 				return nil, fmt.Errorf("ошибка проверки кода: %w", err)
 			}
 
-			if !exists {
+			if !exists && !shortener.IsReservedCode(shortCode) {
 				break
 			}
 
@@ -99,8 +151,23 @@ func (s *urlService) CreateShortURL(ctx context.Context, req *models.CreateURLRe
 
 	// Создаем URL в БД
 	url := &models.URL{
-		ShortCode:   shortCode,
-		OriginalURL: req.OriginalURL,
+		ShortCode:      shortCode,
+		OriginalURL:    req.OriginalURL,
+		StickyVariants: req.StickyVariants,
+		Title:          req.Title,
+		Notes:          req.Notes,
+		Folder:         req.Folder,
+		Tags:           req.Tags,
+	}
+
+	if userID != 0 {
+		url.UserID.Int64 = userID
+		url.UserID.Valid = true
+	}
+
+	if req.StartsAt != nil {
+		url.StartsAt.Time = *req.StartsAt
+		url.StartsAt.Valid = true
 	}
 
 	if req.ExpiresAt != nil {
@@ -108,31 +175,38 @@ func (s *urlService) CreateShortURL(ctx context.Context, req *models.CreateURLRe
 		url.ExpiresAt.Valid = true
 	}
 
-	if err := s.urlRepo.Create(ctx, url); err != nil {
-		return nil, fmt.Errorf("ошибка создания URL: %w", err)
+	if err := validateSchedule(url); err != nil {
+		return nil, err
 	}
 
-	// Кешируем в Redis (игнорируем ошибку кеширования, основные данные уже в БД)
-	if s.redis != nil {
-		cacheKey := fmt.Sprintf("url:%s", shortCode)
-		s.redis.Set(ctx, cacheKey, url.OriginalURL, s.cacheTTL) // nolint:errcheck
+	if err := normalizeLinkDetails(url); err != nil {
+		return nil, err
 	}
 
-	// Формируем ответ
-	response := &models.URLResponse{
-		ID:          url.ID,
-		ShortCode:   url.ShortCode,
-		ShortURL:    fmt.Sprintf("%s/api/r?code=%s", s.baseURL, url.ShortCode),
-		OriginalURL: url.OriginalURL,
-		CreatedAt:   url.CreatedAt,
-		ClicksCount: url.ClicksCount,
+	if req.Password != "" {
+		if err := setLinkPassword(url, req.Password); err != nil {
+			return nil, err
+		}
 	}
 
-	if url.ExpiresAt.Valid {
-		response.ExpiresAt = &url.ExpiresAt.Time
+	if req.MaxClicks != nil {
+		if err := setMaxClicks(url, *req.MaxClicks); err != nil {
+			return nil, err
+		}
 	}
 
-	return response, nil
+	return url, nil
+}
+
+// created кеширует сохраненную ссылку и уведомляет о ее создании
+func (s *urlService) created(ctx context.Context, url *models.URL) *models.URLResponse {
+	// Кешируем в Redis (игнорируем ошибку кеширования, основные данные уже в БД)
+	s.cacheURL(ctx, url)
+
+	response := s.toResponse(url)
+	s.notify(ctx, url.UserID.Int64, models.WebhookEventLinkCreated, response)
+
+	return response
 }
 
 // GetOriginalURL получает оригинальный URL по короткому коду
@@ -152,77 +226,109 @@ func (s *urlService) GetOriginalURL(ctx context.Context, shortCode string) (stri
 		return "", err
 	}
 
-	// Проверяем не истекла ли ссылка
-	if url.ExpiresAt.Valid && url.ExpiresAt.Time.Before(time.Now()) {
-		return "", fmt.Errorf("ссылка истекла")
+	if err := checkURLActive(url); err != nil {
+		return "", err
 	}
 
 	// Кешируем в Redis (игнорируем ошибку кеширования)
-	if s.redis != nil {
-		cacheKey := fmt.Sprintf("url:%s", shortCode)
-		s.redis.Set(ctx, cacheKey, url.OriginalURL, s.cacheTTL) // nolint:errcheck
-	}
+	s.cacheURL(ctx, url)
 
 	return url.OriginalURL, nil
 }
 
-// GetURLByID получает информацию о URL по ID
-func (s *urlService) GetURLByID(ctx context.Context, id int64) (*models.URLResponse, error) {
-	url, err := s.urlRepo.GetByID(ctx, id)
+// GetURLByID получает информацию о URL по ID (только для владельца)
+func (s *urlService) GetURLByID(ctx context.Context, userID, id int64) (*models.URLResponse, error) {
+	url, err := getOwnedURL(ctx, s.urlRepo, userID, id)
 	if err != nil {
 		return nil, err
 	}
 
-	response := &models.URLResponse{
-		ID:          url.ID,
-		ShortCode:   url.ShortCode,
-		ShortURL:    fmt.Sprintf("%s/api/r?code=%s", s.baseURL, url.ShortCode),
-		OriginalURL: url.OriginalURL,
-		CreatedAt:   url.CreatedAt,
-		ClicksCount: url.ClicksCount,
+	return s.toResponse(url), nil
+}
+
+// GetAllURLs получает список URL пользователя по фильтру с пагинацией
+func (s *urlService) GetAllURLs(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error) {
+	if err := normalizeURLFilter(&filter); err != nil {
+		return nil, err
 	}
 
-	if url.ExpiresAt.Valid {
-		response.ExpiresAt = &url.ExpiresAt.Time
+	urls, err := s.urlRepo.GetAll(ctx, userID, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	return response, nil
+	responses := make([]*models.URLResponse, 0, len(urls))
+	for _, url := range urls {
+		responses = append(responses, s.toResponse(url))
+	}
+
+	return responses, nil
 }
 
-// GetAllURLs получает список всех URL с пагинацией
-func (s *urlService) GetAllURLs(ctx context.Context, limit, offset int) ([]*models.URLResponse, error) {
-	// Устанавливаем разумные лимиты
-	if limit <= 0 || limit > 100 {
-		limit = 50
+// ListURLs получает страницу URL пользователя с keyset-пагинацией. В отличие от GetAllURLs
+// размер страницы вне допустимого диапазона - ошибка, а не значение по умолчанию
+func (s *urlService) ListURLs(ctx context.Context, userID int64, req models.URLListRequest) (*models.URLPage, error) {
+	filter := req.Filter
+	if filter.Limit < 0 || filter.Limit > maxURLListLimit {
+		return nil, fmt.Errorf("невалидный limit: от 1 до %d", maxURLListLimit)
 	}
-	if offset < 0 {
-		offset = 0
+	filter.Offset = 0
+	if err := normalizeURLFilter(&filter); err != nil {
+		return nil, err
 	}
 
-	urls, err := s.urlRepo.GetAll(ctx, limit, offset)
+	field, descending, err := parseURLSort(req.Sort)
 	if err != nil {
 		return nil, err
 	}
+	sort := field
+	if descending {
+		sort = "-" + field
+	}
+	filter.Sort, filter.Descending = field, descending
 
-	responses := make([]*models.URLResponse, 0, len(urls))
-	for _, url := range urls {
-		response := &models.URLResponse{
-			ID:          url.ID,
-			ShortCode:   url.ShortCode,
-			ShortURL:    fmt.Sprintf("%s/api/r?code=%s", s.baseURL, url.ShortCode),
-			OriginalURL: url.OriginalURL,
-			CreatedAt:   url.CreatedAt,
-			ClicksCount: url.ClicksCount,
+	if req.Cursor != "" {
+		if filter.After, err = decodeURLCursor(req.Cursor, sort); err != nil {
+			return nil, err
 		}
+	}
 
-		if url.ExpiresAt.Valid {
-			response.ExpiresAt = &url.ExpiresAt.Time
-		}
+	// Лишняя ссылка показывает, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	urls, err := s.urlRepo.GetAll(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
 
-		responses = append(responses, response)
+	page := &models.URLPage{Items: make([]*models.URLResponse, 0, len(urls))}
+	if len(urls) > limit {
+		urls = urls[:limit]
+		page.NextCursor = encodeURLCursor(sort, urls[len(urls)-1])
+	}
+	for _, url := range urls {
+		page.Items = append(page.Items, s.toResponse(url))
 	}
 
-	return responses, nil
+	if req.IncludeTotal {
+		total, err := s.urlRepo.CountAll(ctx, userID, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.TotalCount = &total
+	}
+
+	return page, nil
+}
+
+// ListTags получает теги пользователя с количеством ссылок
+func (s *urlService) ListTags(ctx context.Context, userID int64) ([]models.GroupCount, error) {
+	return s.urlRepo.ListTags(ctx, userID)
+}
+
+// ListFolders получает папки пользователя с количеством ссылок
+func (s *urlService) ListFolders(ctx context.Context, userID int64) ([]models.GroupCount, error) {
+	return s.urlRepo.ListFolders(ctx, userID)
 }
 
 // GetURLByShortCode получает полный объект URL по короткому коду
@@ -232,28 +338,145 @@ func (s *urlService) GetURLByShortCode(ctx context.Context, shortCode string) (*
 		return nil, err
 	}
 
-	// Проверяем не истекла ли ссылка
-	if url.ExpiresAt.Valid && url.ExpiresAt.Time.Before(time.Now()) {
-		return nil, fmt.Errorf("ссылка истекла")
+	if err := checkURLActive(url); err != nil {
+		return nil, err
 	}
 
 	return url, nil
 }
 
+// ConsumeClick учитывает переход в лимите ссылки. Лимит проверяется атомарно в БД,
+// поэтому параллельные переходы не превышают его. Ссылки без лимита не затрагиваются
+func (s *urlService) ConsumeClick(ctx context.Context, url *models.URL) error {
+	if !url.HasClickLimit() {
+		return nil
+	}
+
+	used, ok, err := s.urlRepo.ConsumeClick(ctx, url.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errClicksExhausted
+	}
+
+	url.UsedClicks = used
+	return nil
+}
+
 // IncrementClicks увеличивает счетчик кликов
 func (s *urlService) IncrementClicks(ctx context.Context, id int64) error {
 	return s.urlRepo.IncrementClicks(ctx, id)
 }
 
-// DeleteURL удаляет URL
-func (s *urlService) DeleteURL(ctx context.Context, id int64) error {
-	// Получаем URL чтобы узнать short_code
-	url, err := s.urlRepo.GetByID(ctx, id)
+// UpdateURL частично обновляет ссылку: адрес назначения, срок действия и короткий код
+func (s *urlService) UpdateURL(ctx context.Context, userID, id int64, req *models.UpdateURLRequest) (*models.URLResponse, error) {
+	url, err := getOwnedURL(ctx, s.urlRepo, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateURL(ctx, userID, url, req)
+}
+
+// updateURL применяет изменения к загруженной ссылке владельца и сохраняет ее
+func (s *urlService) updateURL(ctx context.Context, userID int64, url *models.URL, req *models.UpdateURLRequest) (*models.URLResponse, error) {
+	oldShortCode := url.ShortCode
+
+	if req.OriginalURL != nil {
+		if *req.OriginalURL == "" {
+			return nil, fmt.Errorf("URL обязателен")
+		}
+		url.OriginalURL = *req.OriginalURL
+	}
+
+	if req.CustomCode != nil && *req.CustomCode != url.ShortCode {
+		if err := s.validateCustomCode(ctx, *req.CustomCode); err != nil {
+			return nil, err
+		}
+		url.ShortCode = *req.CustomCode
+	}
+
+	if req.ClearStartsAt {
+		url.StartsAt.Valid = false
+	} else if req.StartsAt != nil {
+		url.StartsAt.Time = *req.StartsAt
+		url.StartsAt.Valid = true
+	}
+
+	if req.ClearExpiresAt {
+		url.ExpiresAt.Valid = false
+	} else if req.ExpiresAt != nil {
+		url.ExpiresAt.Time = *req.ExpiresAt
+		url.ExpiresAt.Valid = true
+	}
+
+	if err := validateSchedule(url); err != nil {
+		return nil, err
+	}
+
+	// Смена пароля меняет хеш, поэтому ранее выданные cookie разблокировки перестают действовать
+	if req.ClearPassword {
+		url.PasswordHash.Valid = false
+	} else if req.Password != nil {
+		if err := setLinkPassword(url, *req.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	// Учтенные переходы не сбрасываются: увеличение лимита снова открывает ссылку
+	if req.ClearMaxClicks {
+		url.MaxClicks.Valid = false
+	} else if req.MaxClicks != nil {
+		if err := setMaxClicks(url, *req.MaxClicks); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.StickyVariants != nil {
+		url.StickyVariants = *req.StickyVariants
+	}
+
+	if req.Title != nil {
+		url.Title = *req.Title
+	}
+	if req.Notes != nil {
+		url.Notes = *req.Notes
+	}
+	if req.Folder != nil {
+		url.Folder = *req.Folder
+	}
+	if req.Tags != nil {
+		url.Tags = *req.Tags
+	}
+	if err := normalizeLinkDetails(url); err != nil {
+		return nil, err
+	}
+
+	if err := s.urlRepo.Update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	// Сбрасываем кеш, чтобы редирект сразу увидел изменения (игнорируем ошибку)
+	if s.redis != nil {
+		s.redis.Del(ctx, fmt.Sprintf("url:%s", oldShortCode), fmt.Sprintf("url:%s", url.ShortCode)) // nolint:errcheck
+	}
+
+	response := s.toResponse(url)
+	s.notify(ctx, userID, models.WebhookEventLinkUpdated, response)
+
+	return response, nil
+}
+
+// DeleteURL перемещает URL в корзину (только для владельца). Редирект перестает работать сразу,
+// код остается занятым, аналитика сохраняется до окончательного удаления
+func (s *urlService) DeleteURL(ctx context.Context, userID, id int64) error {
+	// Получаем URL чтобы узнать short_code и проверить владельца
+	url, err := getOwnedURL(ctx, s.urlRepo, userID, id)
 	if err != nil {
 		return err
 	}
 
-	// Удаляем из БД
 	if err := s.urlRepo.Delete(ctx, id); err != nil {
 		return err
 	}
@@ -264,5 +487,240 @@ func (s *urlService) DeleteURL(ctx context.Context, id int64) error {
 		s.redis.Del(ctx, cacheKey) // nolint:errcheck
 	}
 
+	s.notify(ctx, userID, models.WebhookEventLinkDeleted, s.toResponse(url))
+
+	return nil
+}
+
+// NotifyExpiredLinks отправляет link.expired по ссылкам, срок которых истек.
+// Каждая ссылка уведомляется один раз, продление срока сбрасывает отметку
+func (s *urlService) NotifyExpiredLinks(ctx context.Context) (int, error) {
+	urls, err := s.urlRepo.ClaimExpired(ctx, expiredLinksBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, url := range urls {
+		s.notify(ctx, url.UserID.Int64, models.WebhookEventLinkExpired, s.toResponse(url))
+	}
+
+	return len(urls), nil
+}
+
+// notify отправляет событие ссылки подписчикам, если webhooks подключены
+func (s *urlService) notify(ctx context.Context, userID int64, eventType string, data interface{}) {
+	if s.notifier != nil {
+		s.notifier.Notify(ctx, userID, eventType, data)
+	}
+}
+
+// VerifyPassword проверяет пароль защищенной ссылки
+func (s *urlService) VerifyPassword(url *models.URL, password string) bool {
+	if !url.HasPassword() {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(url.PasswordHash.String), []byte(password)) == nil
+}
+
+// LinkNotStartedError ошибка перехода по ссылке до времени ее активации
+type LinkNotStartedError struct {
+	StartsAt time.Time
+}
+
+func (e *LinkNotStartedError) Error() string {
+	return "ссылка еще не активна"
+}
+
+// errClicksExhausted ошибка исчерпанного лимита переходов (как и истекший срок, отдается с 410)
+var errClicksExhausted = errors.New("ссылка истекла: лимит переходов исчерпан")
+
+// checkURLActive проверяет, что ссылка уже активна, срок ее не истек и лимит переходов не исчерпан
+func checkURLActive(url *models.URL) error {
+	now := time.Now()
+
+	if url.StartsAt.Valid && now.Before(url.StartsAt.Time) {
+		return &LinkNotStartedError{StartsAt: url.StartsAt.Time}
+	}
+
+	if url.ExpiresAt.Valid && url.ExpiresAt.Time.Before(now) {
+		return fmt.Errorf("ссылка истекла")
+	}
+
+	if url.ClicksExhausted() {
+		return errClicksExhausted
+	}
+
 	return nil
 }
+
+// validateSchedule проверяет, что время активации раньше времени истечения
+func validateSchedule(url *models.URL) error {
+	if url.StartsAt.Valid && url.ExpiresAt.Valid && !url.StartsAt.Time.Before(url.ExpiresAt.Time) {
+		return fmt.Errorf("невалидный период действия: starts_at должен быть раньше expires_at")
+	}
+	return nil
+}
+
+// cacheURL кеширует адрес назначения для редиректа (игнорируем ошибку кеширования)
+func (s *urlService) cacheURL(ctx context.Context, url *models.URL) {
+	if s.redis == nil {
+		return
+	}
+
+	ttl, ok := redirectCacheTTL(url, s.cacheTTL, time.Now())
+	if !ok {
+		return
+	}
+
+	cacheKey := fmt.Sprintf("url:%s", url.ShortCode)
+	s.redis.Set(ctx, cacheKey, url.OriginalURL, ttl) // nolint:errcheck
+}
+
+// redirectCacheTTL возвращает TTL записи кеша, не выходящий за expires_at.
+// Ссылки до активации и с лимитом переходов не кешируются (false):
+// кеш не знает ни о времени активации, ни об оставшихся переходах
+func redirectCacheTTL(url *models.URL, ttl time.Duration, now time.Time) (time.Duration, bool) {
+	if url.HasClickLimit() {
+		return 0, false
+	}
+
+	if url.StartsAt.Valid && now.Before(url.StartsAt.Time) {
+		return 0, false
+	}
+
+	if url.ExpiresAt.Valid {
+		left := url.ExpiresAt.Time.Sub(now)
+		if left <= 0 {
+			return 0, false
+		}
+		if left < ttl {
+			ttl = left
+		}
+	}
+
+	return ttl, true
+}
+
+// setMaxClicks проверяет и устанавливает лимит переходов
+func setMaxClicks(url *models.URL, maxClicks int64) error {
+	if maxClicks < 1 {
+		return fmt.Errorf("невалидный лимит переходов: должен быть больше 0")
+	}
+
+	url.MaxClicks.Int64 = maxClicks
+	url.MaxClicks.Valid = true
+	return nil
+}
+
+// setLinkPassword проверяет длину пароля и сохраняет его bcrypt хеш
+func setLinkPassword(url *models.URL, password string) error {
+	if len(password) < minLinkPasswordLength || len(password) > maxLinkPasswordLength {
+		return fmt.Errorf("невалидный пароль ссылки: от %d до %d символов", minLinkPasswordLength, maxLinkPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+
+	url.PasswordHash.String = string(hash)
+	url.PasswordHash.Valid = true
+	return nil
+}
+
+// validateCustomCode проверяет кастомный код: формат, зарезервированные имена и уникальность
+func (s *urlService) validateCustomCode(ctx context.Context, code string) error {
+	// Проверяем валидность кода
+	if !shortener.IsValidShortCode(code) {
+		return fmt.Errorf("невалидный короткий код: от 1 до %d символов, латиница, цифры, - и _", shortener.MaxShortCodeLength)
+	}
+
+	// Код не должен перекрывать служебные маршруты
+	if shortener.IsReservedCode(code) {
+		return fmt.Errorf("короткий код зарезервирован")
+	}
+
+	// Проверяем что код еще не занят
+	exists, err := s.urlRepo.ShortCodeExists(ctx, code)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки кода: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("короткий код уже занят")
+	}
+
+	return nil
+}
+
+// getOwnedURL получает URL по ID и проверяет, что он принадлежит пользователю.
+// Чужие ссылки выглядят как несуществующие, чтобы не раскрывать их наличие
+func getOwnedURL(ctx context.Context, urlRepo repository.URLRepository, userID, id int64) (*models.URL, error) {
+	url, err := urlRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if url == nil || !url.UserID.Valid || url.UserID.Int64 != userID {
+		return nil, fmt.Errorf("URL с ID %d не найден", id)
+	}
+
+	return url, nil
+}
+
+// ShortURL формирует короткую ссылку в настроенном формате
+func (s *urlService) ShortURL(shortCode string) string {
+	if s.urlStyle == ShortURLStyleQuery {
+		return fmt.Sprintf("%s/api/r?code=%s", s.baseURL, shortCode)
+	}
+	return fmt.Sprintf("%s/%s", s.baseURL, shortCode)
+}
+
+// toResponse конвертирует модель URL в ответ API
+func (s *urlService) toResponse(url *models.URL) *models.URLResponse {
+	response := &models.URLResponse{
+		ID:                url.ID,
+		ShortCode:         url.ShortCode,
+		ShortURL:          s.ShortURL(url.ShortCode),
+		OriginalURL:       url.OriginalURL,
+		CreatedAt:         url.CreatedAt,
+		ClicksCount:       url.ClicksCount,
+		PasswordProtected: url.HasPassword(),
+		StickyVariants:    url.StickyVariants,
+		Title:             url.Title,
+		Notes:             url.Notes,
+		Folder:            url.Folder,
+		Tags:              url.Tags,
+	}
+
+	if response.Tags == nil {
+		response.Tags = []string{}
+	}
+
+	if url.StartsAt.Valid {
+		response.StartsAt = &url.StartsAt.Time
+	}
+
+	if url.ExpiresAt.Valid {
+		response.ExpiresAt = &url.ExpiresAt.Time
+	}
+
+	if url.DeletedAt.Valid {
+		response.DeletedAt = &url.DeletedAt.Time
+	}
+
+	if url.ArchivedAt.Valid {
+		response.ArchivedAt = &url.ArchivedAt.Time
+	}
+
+	if url.HasClickLimit() {
+		maxClicks := url.MaxClicks.Int64
+		remaining := maxClicks - url.UsedClicks
+		if remaining < 0 {
+			remaining = 0
+		}
+		response.MaxClicks = &maxClicks
+		response.RemainingClicks = &remaining
+	}
+
+	return response
+}
@@ -2,25 +2,39 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"url-short/internal/models"
 )
 
+// testUserID владелец ссылок в тестах
+const testUserID int64 = 1
+
 // mockURLRepository мок для тестирования URLService
 type mockURLRepository struct {
 	urls      map[string]*models.URL
 	urlsByID  map[int64]*models.URL
 	nextID    int64
 	codeExist func(string) bool
+	// lastFilter фильтр последнего вызова GetAll
+	lastFilter models.URLFilter
+	// batchSizes размеры пакетов CreateBatch
+	batchSizes []int
+	// retired коды окончательно удаленных ссылок и конец их карантина
+	retired map[string]time.Time
 }
 
 func newMockURLRepository() *mockURLRepository {
 	return &mockURLRepository{
 		urls:     make(map[string]*models.URL),
 		urlsByID: make(map[int64]*models.URL),
+		retired:  make(map[string]time.Time),
 		nextID:   1,
 	}
 }
@@ -36,9 +50,22 @@ func (m *mockURLRepository) Create(ctx context.Context, url *models.URL) error {
 	return nil
 }
 
+func (m *mockURLRepository) CreateBatch(ctx context.Context, urls []*models.URL) ([]error, error) {
+	m.batchSizes = append(m.batchSizes, len(urls))
+	errs := make([]error, len(urls))
+	for i, url := range urls {
+		if _, exists := m.urls[url.ShortCode]; exists {
+			errs[i] = fmt.Errorf("короткий код уже занят")
+			continue
+		}
+		m.Create(ctx, url) // nolint:errcheck
+	}
+	return errs, nil
+}
+
 func (m *mockURLRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
 	url, exists := m.urls[shortCode]
-	if !exists {
+	if !exists || url.DeletedAt.Valid {
 		return nil, fmt.Errorf("URL с кодом %s не найден", shortCode)
 	}
 	return url, nil
@@ -46,20 +73,38 @@ func (m *mockURLRepository) GetByShortCode(ctx context.Context, shortCode string
 
 func (m *mockURLRepository) GetByID(ctx context.Context, id int64) (*models.URL, error) {
 	url, exists := m.urlsByID[id]
-	if !exists {
+	if !exists || url.DeletedAt.Valid {
 		return nil, nil
 	}
 	return url, nil
 }
 
-func (m *mockURLRepository) GetAll(ctx context.Context, limit, offset int) ([]*models.URL, error) {
+func (m *mockURLRepository) GetAll(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URL, error) {
+	m.lastFilter = filter
+	// Порядок по убыванию ID совпадает с сортировкой по умолчанию (новые первыми)
 	var urls []*models.URL
 	for _, url := range m.urlsByID {
-		urls = append(urls, url)
+		if url.UserID.Int64 == userID && url.DeletedAt.Valid == filter.Deleted && (filter.After == nil || url.ID < filter.After.ID) {
+			urls = append(urls, url)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool { return urls[i].ID > urls[j].ID })
+	if filter.Limit > 0 && len(urls) > filter.Limit {
+		urls = urls[:filter.Limit]
 	}
 	return urls, nil
 }
 
+func (m *mockURLRepository) CountAll(ctx context.Context, userID int64, filter models.URLFilter) (int64, error) {
+	var count int64
+	for _, url := range m.urlsByID {
+		if url.UserID.Int64 == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
 func (m *mockURLRepository) Update(ctx context.Context, url *models.URL) error {
 	m.urls[url.ShortCode] = url
 	m.urlsByID[url.ID] = url
@@ -69,10 +114,60 @@ func (m *mockURLRepository) Update(ctx context.Context, url *models.URL) error {
 func (m *mockURLRepository) Delete(ctx context.Context, id int64) error {
 	url := m.urlsByID[id]
 	if url != nil {
+		url.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	return nil
+}
+
+func (m *mockURLRepository) Restore(ctx context.Context, userID, id int64) (*models.URL, error) {
+	url := m.urlsByID[id]
+	if url == nil || !url.DeletedAt.Valid || url.UserID.Int64 != userID {
+		return nil, fmt.Errorf("URL с ID %d не найден в корзине", id)
+	}
+	url.DeletedAt = sql.NullTime{}
+	return url, nil
+}
+
+func (m *mockURLRepository) PurgeDeleted(ctx context.Context, retention, quarantine time.Duration, limit int) (int, error) {
+	purged := 0
+	for id, url := range m.urlsByID {
+		if purged == limit || !url.DeletedAt.Valid || time.Since(url.DeletedAt.Time) <= retention {
+			continue
+		}
+		if until := url.DeletedAt.Time.Add(quarantine); until.After(time.Now()) {
+			m.retired[url.ShortCode] = until
+		}
 		delete(m.urls, url.ShortCode)
 		delete(m.urlsByID, id)
+		purged++
 	}
-	return nil
+	return purged, nil
+}
+
+func (m *mockURLRepository) CleanupExpired(ctx context.Context, mode string, grace, quarantine time.Duration, limit int) ([]*models.URL, error) {
+	var urls []*models.URL
+	for id, url := range m.urlsByID {
+		if len(urls) == limit || !url.ExpiresAt.Valid || time.Since(url.ExpiresAt.Time) <= grace || url.DeletedAt.Valid {
+			continue
+		}
+		switch mode {
+		case models.JanitorModeArchive:
+			if url.ArchivedAt.Valid {
+				continue
+			}
+			url.ArchivedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		case models.JanitorModeSoftDelete:
+			url.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		case models.JanitorModeHardDelete:
+			if quarantine > 0 {
+				m.retired[url.ShortCode] = time.Now().Add(quarantine)
+			}
+			delete(m.urls, url.ShortCode)
+			delete(m.urlsByID, id)
+		}
+		urls = append(urls, url)
+	}
+	return urls, nil
 }
 
 func (m *mockURLRepository) IncrementClicks(ctx context.Context, id int64) error {
@@ -87,7 +182,34 @@ func (m *mockURLRepository) ShortCodeExists(ctx context.Context, shortCode strin
 		return m.codeExist(shortCode), nil
 	}
 	_, exists := m.urls[shortCode]
-	return exists, nil
+	return exists || m.retired[shortCode].After(time.Now()), nil
+}
+
+func (m *mockURLRepository) ClaimExpired(ctx context.Context, limit int) ([]*models.URL, error) {
+	var urls []*models.URL
+	for _, url := range m.urlsByID {
+		if url.ExpiresAt.Valid && url.ExpiresAt.Time.Before(time.Now()) && url.UserID.Valid {
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
+func (m *mockURLRepository) ListTags(ctx context.Context, userID int64) ([]models.GroupCount, error) {
+	return []models.GroupCount{}, nil
+}
+
+func (m *mockURLRepository) ListFolders(ctx context.Context, userID int64) ([]models.GroupCount, error) {
+	return []models.GroupCount{}, nil
+}
+
+func (m *mockURLRepository) ConsumeClick(ctx context.Context, id int64) (int64, bool, error) {
+	url, exists := m.urlsByID[id]
+	if !exists || url.ClicksExhausted() {
+		return 0, false, nil
+	}
+	url.UsedClicks++
+	return url.UsedClicks, true, nil
 }
 
 // mockGenerator мок генератор для предсказуемых тестов
@@ -110,13 +232,13 @@ func (m *mockGenerator) EncodeID(id int64) string {
 func TestCreateShortURL_Success(t *testing.T) {
 	repo := newMockURLRepository()
 	gen := &mockGenerator{}
-	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600)
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
 
 	req := &models.CreateURLRequest{
 		OriginalURL: "https://example.com",
 	}
 
-	result, err := service.CreateShortURL(context.Background(), req)
+	result, err := service.CreateShortURL(context.Background(), testUserID, req)
 	if err != nil {
 		t.Fatalf("CreateShortURL() error = %v", err)
 	}
@@ -142,14 +264,14 @@ func TestCreateShortURL_Success(t *testing.T) {
 func TestCreateShortURL_CustomCode(t *testing.T) {
 	repo := newMockURLRepository()
 	gen := &mockGenerator{}
-	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600)
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
 
 	req := &models.CreateURLRequest{
 		OriginalURL: "https://example.com",
 		CustomCode:  "mycode",
 	}
 
-	result, err := service.CreateShortURL(context.Background(), req)
+	result, err := service.CreateShortURL(context.Background(), testUserID, req)
 	if err != nil {
 		t.Fatalf("CreateShortURL() error = %v", err)
 	}
@@ -163,14 +285,14 @@ func TestCreateShortURL_CustomCode(t *testing.T) {
 func TestCreateShortURL_DuplicateCustomCode(t *testing.T) {
 	repo := newMockURLRepository()
 	gen := &mockGenerator{}
-	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600)
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
 
 	// Создаем первую ссылку
 	req1 := &models.CreateURLRequest{
 		OriginalURL: "https://example.com",
 		CustomCode:  "mycode",
 	}
-	_, err := service.CreateShortURL(context.Background(), req1)
+	_, err := service.CreateShortURL(context.Background(), testUserID, req1)
 	if err != nil {
 		t.Fatalf("First CreateShortURL() error = %v", err)
 	}
@@ -180,24 +302,61 @@ func TestCreateShortURL_DuplicateCustomCode(t *testing.T) {
 		OriginalURL: "https://another.com",
 		CustomCode:  "mycode",
 	}
-	_, err = service.CreateShortURL(context.Background(), req2)
+	_, err = service.CreateShortURL(context.Background(), testUserID, req2)
 	if err == nil {
 		t.Error("CreateShortURL() should return error for duplicate code")
 	}
 }
 
+// TestCreateShortURL_QueryStyle проверяет формат ссылки через query параметр
+func TestCreateShortURL_QueryStyle(t *testing.T) {
+	repo := newMockURLRepository()
+	gen := &mockGenerator{}
+	service := NewURLService(repo, gen, nil, "http://localhost:8080/", 3600, ShortURLStyleQuery, nil)
+
+	req := &models.CreateURLRequest{
+		OriginalURL: "https://example.com",
+	}
+
+	result, err := service.CreateShortURL(context.Background(), testUserID, req)
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+
+	if result.ShortURL != "http://localhost:8080/api/r?code=ABC123" {
+		t.Errorf("CreateShortURL() ShortURL = %s, want http://localhost:8080/api/r?code=ABC123", result.ShortURL)
+	}
+}
+
+// TestCreateShortURL_ReservedCustomCode проверяет запрет кодов, совпадающих с маршрутами
+func TestCreateShortURL_ReservedCustomCode(t *testing.T) {
+	repo := newMockURLRepository()
+	gen := &mockGenerator{}
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+
+	req := &models.CreateURLRequest{
+		OriginalURL: "https://example.com",
+		CustomCode:  "links",
+	}
+
+	_, err := service.CreateShortURL(context.Background(), testUserID, req)
+	if err == nil {
+		t.Error("CreateShortURL() should return error for reserved code")
+	}
+}
+
 // TestGetOriginalURL_Success проверяет получение оригинального URL
 func TestGetOriginalURL_Success(t *testing.T) {
 	repo := newMockURLRepository()
 	gen := &mockGenerator{}
-	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600)
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
 
 	// Создаем ссылку
 	req := &models.CreateURLRequest{
 		OriginalURL: "https://example.com",
 		CustomCode:  "test123",
 	}
-	_, err := service.CreateShortURL(context.Background(), req)
+	_, err := service.CreateShortURL(context.Background(), testUserID, req)
 	if err != nil {
 		t.Fatalf("CreateShortURL() error = %v", err)
 	}
@@ -236,7 +395,7 @@ func TestGetOriginalURL_NotFound(t *testing.T) {
 func TestGetAllURLs_Pagination(t *testing.T) {
 	repo := newMockURLRepository()
 	gen := &mockGenerator{code: "CODE"}
-	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600)
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
 
 	// Создаем несколько ссылок
 	for i := 1; i <= 5; i++ {
@@ -244,14 +403,14 @@ func TestGetAllURLs_Pagination(t *testing.T) {
 		req := &models.CreateURLRequest{
 			OriginalURL: "https://example.com",
 		}
-		_, err := service.CreateShortURL(context.Background(), req)
+		_, err := service.CreateShortURL(context.Background(), testUserID, req)
 		if err != nil {
 			t.Fatalf("CreateShortURL() error = %v", err)
 		}
 	}
 
 	// Получаем с лимитом
-	urls, err := service.GetAllURLs(context.Background(), 3, 0)
+	urls, err := service.GetAllURLs(context.Background(), testUserID, models.URLFilter{Limit: 3})
 	if err != nil {
 		t.Fatalf("GetAllURLs() error = %v", err)
 	}
@@ -265,33 +424,314 @@ func TestGetAllURLs_Pagination(t *testing.T) {
 func TestGetAllURLs_DefaultLimit(t *testing.T) {
 	repo := newMockURLRepository()
 	gen := &mockGenerator{}
-	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600)
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
 
 	// Тестируем с некорректными параметрами
-	_, err := service.GetAllURLs(context.Background(), 0, 0)
+	_, err := service.GetAllURLs(context.Background(), testUserID, models.URLFilter{})
 	if err != nil {
 		t.Fatalf("GetAllURLs() error = %v", err)
 	}
 
 	// Limit 0 должен стать 50 (по умолчанию)
-	_, err = service.GetAllURLs(context.Background(), -1, -1)
+	_, err = service.GetAllURLs(context.Background(), testUserID, models.URLFilter{Limit: -1, Offset: -1})
+	if err != nil {
+		t.Fatalf("GetAllURLs() error = %v", err)
+	}
+}
+
+// TestGetAllURLs_Filter проверяет нормализацию и валидацию фильтров списка
+func TestGetAllURLs_Filter(t *testing.T) {
+	repo := newMockURLRepository()
+	service := NewURLService(repo, &mockGenerator{}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	ctx := context.Background()
+
+	_, err := service.GetAllURLs(ctx, testUserID, models.URLFilter{
+		Limit:  500,
+		Tag:    " Promo ",
+		Folder: " Marketing ",
+		Query:  " spring sale ",
+		State:  models.LinkStateActive,
+	})
+	if err != nil {
+		t.Fatalf("GetAllURLs() error = %v", err)
+	}
+	got := repo.lastFilter
+	if got.Limit != 50 || got.Tag != "promo" || got.Folder != "Marketing" || got.Query != "spring sale" {
+		t.Errorf("filter = %+v, want normalized values", got)
+	}
+
+	now := time.Now()
+	invalid := []models.URLFilter{
+		{State: "deleted"},
+		{Query: strings.Repeat("q", maxLinkQueryLength+1)},
+		{CreatedFrom: now, CreatedTo: now.Add(-time.Hour)},
+	}
+	for _, filter := range invalid {
+		if _, err := service.GetAllURLs(ctx, testUserID, filter); err == nil || !strings.Contains(err.Error(), "невалидн") {
+			t.Errorf("GetAllURLs(%+v) error = %v, want validation error", filter, err)
+		}
+	}
+}
+
+// TestListURLs_Cursor проверяет обход списка по курсорам, сортировку и размер страницы
+func TestListURLs_Cursor(t *testing.T) {
+	repo := newMockURLRepository()
+	service := NewURLService(repo, &mockGenerator{}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	ctx := context.Background()
+
+	created := time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	for i := int64(1); i <= 5; i++ {
+		url := &models.URL{
+			ID:        i,
+			ShortCode: fmt.Sprintf("code%d", i),
+			UserID:    sql.NullInt64{Int64: testUserID, Valid: true},
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		}
+		repo.urls[url.ShortCode] = url
+		repo.urlsByID[url.ID] = url
+	}
+
+	var ids []int64
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := service.ListURLs(ctx, testUserID, models.URLListRequest{
+			Filter:       models.URLFilter{Limit: 2},
+			Cursor:       cursor,
+			IncludeTotal: true,
+		})
+		if err != nil {
+			t.Fatalf("ListURLs() error = %v", err)
+		}
+		if page.TotalCount == nil || *page.TotalCount != 5 {
+			t.Errorf("TotalCount = %v, want 5", page.TotalCount)
+		}
+		for _, item := range page.Items {
+			ids = append(ids, item.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if fmt.Sprint(ids) != "[5 4 3 2 1]" {
+		t.Errorf("ids = %v, want [5 4 3 2 1]", ids)
+	}
+
+	// Курсор хранит значение сортировки с точностью до микросекунд и ID последней ссылки
+	after, err := decodeURLCursor(encodeURLCursor("-created_at", repo.urlsByID[3]), "-created_at")
+	if err != nil {
+		t.Fatalf("decodeURLCursor() error = %v", err)
+	}
+	if after.Value != "2026-03-01 12:03:00.123456" || after.ID != 3 {
+		t.Errorf("cursor = %+v, want value 2026-03-01 12:03:00.123456 and id 3", after)
+	}
+
+	if _, err := service.ListURLs(ctx, testUserID, models.URLListRequest{Sort: "clicks_count"}); err != nil {
+		t.Fatalf("ListURLs(sort=clicks_count) error = %v", err)
+	}
+	if repo.lastFilter.Sort != models.URLSortClicksCount || repo.lastFilter.Descending || repo.lastFilter.Limit != defaultURLListLimit+1 {
+		t.Errorf("filter = %+v, want ascending clicks_count with default limit", repo.lastFilter)
+	}
+
+	invalid := []models.URLListRequest{
+		{Filter: models.URLFilter{Limit: maxURLListLimit + 1}},
+		{Sort: "short_code"},
+		{Cursor: "not a cursor"},
+		{Sort: "clicks_count", Cursor: cursor},
+	}
+	for _, req := range invalid {
+		if _, err := service.ListURLs(ctx, testUserID, req); err == nil || !strings.Contains(err.Error(), "невалидн") {
+			t.Errorf("ListURLs(%+v) error = %v, want validation error", req, err)
+		}
+	}
+}
+
+// TestURLService_Details проверяет название, заметки, папку и теги ссылки
+func TestURLService_Details(t *testing.T) {
+	repo := newMockURLRepository()
+	gen := &mockGenerator{code: "tagged"}
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	ctx := context.Background()
+
+	created, err := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{
+		OriginalURL: "https://example.com",
+		Title:       " Весенняя акция ",
+		Folder:      " Marketing ",
+		Tags:        []string{"Promo", "promo ", "Spring"},
+	})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	if created.Title != "Весенняя акция" || created.Folder != "Marketing" {
+		t.Errorf("created = %+v, want trimmed title and folder", created)
+	}
+	if strings.Join(created.Tags, ",") != "promo,spring" {
+		t.Errorf("Tags = %v, want lowercase tags without duplicates", created.Tags)
+	}
+
+	// Анонимной ссылке теги не назначаются
+	gen.code = "anon"
+	if _, err := service.CreateShortURL(ctx, 0, &models.CreateURLRequest{
+		OriginalURL: "https://example.com",
+		Tags:        []string{"promo"},
+	}); err == nil || !strings.Contains(err.Error(), "невалидн") {
+		t.Errorf("CreateShortURL(anonymous with tags) error = %v, want validation error", err)
+	}
+
+	tooMany := make([]string, maxLinkTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+	for _, tags := range [][]string{tooMany, {"a,b"}, {" "}, {strings.Repeat("t", maxLinkTagLength+1)}} {
+		if _, err := service.UpdateURL(ctx, testUserID, created.ID, &models.UpdateURLRequest{Tags: &tags}); err == nil || !strings.Contains(err.Error(), "невалидн") {
+			t.Errorf("UpdateURL(tags %v) error = %v, want validation error", tags, err)
+		}
+	}
+
+	// Пустой список снимает теги, пустая папка убирает ссылку из папки
+	empty, noFolder := []string{}, ""
+	updated, err := service.UpdateURL(ctx, testUserID, created.ID, &models.UpdateURLRequest{Tags: &empty, Folder: &noFolder})
+	if err != nil {
+		t.Fatalf("UpdateURL() error = %v", err)
+	}
+	if len(updated.Tags) != 0 || updated.Folder != "" || updated.Title != "Весенняя акция" {
+		t.Errorf("updated = %+v, want cleared tags and folder with title unchanged", updated)
+	}
+}
+
+// TestUpdateURL_Partial проверяет частичное обновление ссылки
+func TestUpdateURL_Partial(t *testing.T) {
+	repo := newMockURLRepository()
+	gen := &mockGenerator{}
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+	created, err := service.CreateShortURL(context.Background(), testUserID, &models.CreateURLRequest{
+		OriginalURL: "https://example.com",
+		CustomCode:  "old",
+		ExpiresAt:   &expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+
+	newCode := "new"
+	result, err := service.UpdateURL(context.Background(), testUserID, created.ID, &models.UpdateURLRequest{
+		CustomCode: &newCode,
+	})
+	if err != nil {
+		t.Fatalf("UpdateURL() error = %v", err)
+	}
+
+	if result.ShortCode != "new" {
+		t.Errorf("UpdateURL() ShortCode = %s, want new", result.ShortCode)
+	}
+	if result.OriginalURL != "https://example.com" {
+		t.Errorf("UpdateURL() OriginalURL = %s, want https://example.com", result.OriginalURL)
+	}
+	if result.ExpiresAt == nil {
+		t.Error("UpdateURL() ExpiresAt should be kept")
+	}
+
+	result, err = service.UpdateURL(context.Background(), testUserID, created.ID, &models.UpdateURLRequest{
+		ClearExpiresAt: true,
+	})
+	if err != nil {
+		t.Fatalf("UpdateURL() error = %v", err)
+	}
+	if result.ExpiresAt != nil {
+		t.Error("UpdateURL() ExpiresAt should be cleared")
+	}
+}
+
+// TestUpdateURL_Validation проверяет валидацию при обновлении
+func TestUpdateURL_Validation(t *testing.T) {
+	repo := newMockURLRepository()
+	gen := &mockGenerator{}
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+
+	first, err := service.CreateShortURL(context.Background(), testUserID, &models.CreateURLRequest{
+		OriginalURL: "https://example.com",
+		CustomCode:  "first",
+	})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	if _, err := service.CreateShortURL(context.Background(), testUserID, &models.CreateURLRequest{
+		OriginalURL: "https://example.com",
+		CustomCode:  "second",
+	}); err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  *models.UpdateURLRequest
+	}{
+		{"duplicate code", &models.UpdateURLRequest{CustomCode: stringPtr("second")}},
+		{"invalid code", &models.UpdateURLRequest{CustomCode: stringPtr("bad code")}},
+		{"reserved code", &models.UpdateURLRequest{CustomCode: stringPtr("api")}},
+		{"empty url", &models.UpdateURLRequest{OriginalURL: stringPtr("")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.UpdateURL(context.Background(), testUserID, first.ID, tt.req); err == nil {
+				t.Error("UpdateURL() should return error")
+			}
+		})
+	}
+}
+
+// TestOwnership проверяет, что чужие ссылки недоступны
+func TestOwnership(t *testing.T) {
+	repo := newMockURLRepository()
+	gen := &mockGenerator{}
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+
+	created, err := service.CreateShortURL(context.Background(), testUserID, &models.CreateURLRequest{
+		OriginalURL: "https://example.com",
+		CustomCode:  "owned",
+	})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+
+	otherUserID := testUserID + 1
+
+	if _, err := service.GetURLByID(context.Background(), otherUserID, created.ID); err == nil {
+		t.Error("GetURLByID() should return error for another user")
+	}
+
+	if err := service.DeleteURL(context.Background(), otherUserID, created.ID); err == nil {
+		t.Error("DeleteURL() should return error for another user")
+	}
+
+	urls, err := service.GetAllURLs(context.Background(), otherUserID, models.URLFilter{Limit: 10})
 	if err != nil {
 		t.Fatalf("GetAllURLs() error = %v", err)
 	}
+	if len(urls) != 0 {
+		t.Errorf("GetAllURLs() for another user returned %d URLs, want 0", len(urls))
+	}
+
+	if _, err := service.GetURLByID(context.Background(), testUserID, created.ID); err != nil {
+		t.Errorf("GetURLByID() for owner error = %v", err)
+	}
 }
 
 // TestIncrementClicks проверяет инкремент счетчика
 func TestIncrementClicks(t *testing.T) {
 	repo := newMockURLRepository()
 	gen := &mockGenerator{}
-	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600)
+	service := NewURLService(repo, gen, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
 
 	// Создаем ссылку
 	req := &models.CreateURLRequest{
 		OriginalURL: "https://example.com",
 		CustomCode:  "click123",
 	}
-	result, err := service.CreateShortURL(context.Background(), req)
+	result, err := service.CreateShortURL(context.Background(), testUserID, req)
 	if err != nil {
 		t.Fatalf("CreateShortURL() error = %v", err)
 	}
@@ -308,3 +748,226 @@ func TestIncrementClicks(t *testing.T) {
 		t.Errorf("ClicksCount = %d, want 1", url.ClicksCount)
 	}
 }
+
+// TestURLService_Password проверяет хеширование и смену пароля ссылки
+func TestURLService_Password(t *testing.T) {
+	repo := newMockURLRepository()
+	service := NewURLService(repo, &mockGenerator{}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+
+	// Ошибка должна содержать "невалидн", чтобы handler ответил 400
+	for _, password := range []string{"abc", strings.Repeat("a", maxLinkPasswordLength+1)} {
+		if _, err := service.CreateShortURL(context.Background(), testUserID, &models.CreateURLRequest{OriginalURL: "https://example.com", Password: password}); err == nil || !strings.Contains(err.Error(), "невалидн") {
+			t.Errorf("CreateShortURL() with %d character password error = %v, want invalid", len(password), err)
+		}
+	}
+
+	created, err := service.CreateShortURL(context.Background(), testUserID, &models.CreateURLRequest{OriginalURL: "https://example.com", Password: "letmein"})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	if !created.PasswordProtected {
+		t.Error("PasswordProtected = false, want true")
+	}
+
+	url, _ := repo.GetByID(context.Background(), created.ID)
+	if url.PasswordHash.String == "letmein" {
+		t.Fatal("password stored in plain text")
+	}
+	if !service.VerifyPassword(url, "letmein") || service.VerifyPassword(url, "wrong") {
+		t.Error("VerifyPassword() does not match stored password")
+	}
+
+	updated, err := service.UpdateURL(context.Background(), testUserID, created.ID, &models.UpdateURLRequest{ClearPassword: true})
+	if err != nil {
+		t.Fatalf("UpdateURL() error = %v", err)
+	}
+	if updated.PasswordProtected || !service.VerifyPassword(url, "") {
+		t.Error("password is not cleared")
+	}
+}
+
+// TestURLService_MaxClicks проверяет лимит переходов и одноразовые ссылки
+func TestURLService_MaxClicks(t *testing.T) {
+	repo := newMockURLRepository()
+	service := NewURLService(repo, &mockGenerator{code: "limited"}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	ctx := context.Background()
+
+	// Ошибка должна содержать "невалидн", чтобы handler ответил 400
+	for _, invalid := range []int64{0, -1} {
+		if _, err := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{OriginalURL: "https://example.com", MaxClicks: &invalid}); err == nil || !strings.Contains(err.Error(), "невалидн") {
+			t.Errorf("CreateShortURL() with max_clicks = %d error = %v, want invalid", invalid, err)
+		}
+	}
+
+	maxClicks := int64(2)
+	created, err := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{OriginalURL: "https://example.com", MaxClicks: &maxClicks})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	if created.MaxClicks == nil || *created.MaxClicks != 2 || *created.RemainingClicks != 2 {
+		t.Fatalf("response limit = %v/%v, want 2/2", created.MaxClicks, created.RemainingClicks)
+	}
+
+	for i := 0; i < 2; i++ {
+		url, err := service.GetURLByShortCode(ctx, "limited")
+		if err != nil {
+			t.Fatalf("GetURLByShortCode() click %d error = %v", i+1, err)
+		}
+		if err := service.ConsumeClick(ctx, url); err != nil {
+			t.Fatalf("ConsumeClick() click %d error = %v", i+1, err)
+		}
+	}
+
+	url, _ := repo.GetByID(ctx, created.ID)
+	if err := service.ConsumeClick(ctx, url); err == nil || !strings.Contains(err.Error(), "истекла") {
+		t.Errorf("ConsumeClick() over limit error = %v, want expired", err)
+	}
+	if _, err := service.GetURLByShortCode(ctx, "limited"); err == nil || !strings.Contains(err.Error(), "истекла") {
+		t.Errorf("GetURLByShortCode() over limit error = %v, want expired", err)
+	}
+	if _, err := service.GetOriginalURL(ctx, "limited"); err == nil || !strings.Contains(err.Error(), "истекла") {
+		t.Errorf("GetOriginalURL() over limit error = %v, want expired", err)
+	}
+
+	// Увеличение лимита снова открывает ссылку, учтенные переходы сохраняются
+	maxClicks = 3
+	updated, err := service.UpdateURL(ctx, testUserID, created.ID, &models.UpdateURLRequest{MaxClicks: &maxClicks})
+	if err != nil {
+		t.Fatalf("UpdateURL() error = %v", err)
+	}
+	if *updated.RemainingClicks != 1 {
+		t.Errorf("RemainingClicks = %d, want 1", *updated.RemainingClicks)
+	}
+	if _, err := service.GetOriginalURL(ctx, "limited"); err != nil {
+		t.Errorf("GetOriginalURL() after raising limit error = %v", err)
+	}
+
+	updated, err = service.UpdateURL(ctx, testUserID, created.ID, &models.UpdateURLRequest{ClearMaxClicks: true})
+	if err != nil {
+		t.Fatalf("UpdateURL() error = %v", err)
+	}
+	if updated.MaxClicks != nil || updated.RemainingClicks != nil {
+		t.Error("limit is not cleared")
+	}
+}
+
+// TestURLService_StartsAt проверяет окно действия ссылки
+func TestURLService_StartsAt(t *testing.T) {
+	repo := newMockURLRepository()
+	service := NewURLService(repo, &mockGenerator{code: "launch"}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	ctx := context.Background()
+
+	startsAt := time.Now().Add(time.Hour)
+	for _, expiresAt := range []time.Time{startsAt.Add(-time.Minute), startsAt} {
+		if _, err := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{
+			OriginalURL: "https://example.com", StartsAt: &startsAt, ExpiresAt: &expiresAt,
+		}); err == nil || !strings.Contains(err.Error(), "невалидный") {
+			t.Errorf("CreateShortURL() with starts_at not before expires_at error = %v", err)
+		}
+	}
+
+	created, err := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{OriginalURL: "https://example.com", StartsAt: &startsAt})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	if created.StartsAt == nil || !created.StartsAt.Equal(startsAt) {
+		t.Errorf("StartsAt = %v, want %v", created.StartsAt, startsAt)
+	}
+
+	var notStarted *LinkNotStartedError
+	if _, err := service.GetURLByShortCode(ctx, "launch"); !errors.As(err, &notStarted) || !notStarted.StartsAt.Equal(startsAt) {
+		t.Errorf("GetURLByShortCode() before start error = %v, want LinkNotStartedError", err)
+	}
+	if _, err := service.GetOriginalURL(ctx, "launch"); !errors.As(err, &notStarted) {
+		t.Errorf("GetOriginalURL() before start error = %v, want LinkNotStartedError", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if _, err := service.UpdateURL(ctx, testUserID, created.ID, &models.UpdateURLRequest{StartsAt: &past}); err != nil {
+		t.Fatalf("UpdateURL() error = %v", err)
+	}
+	if _, err := service.GetOriginalURL(ctx, "launch"); err != nil {
+		t.Errorf("GetOriginalURL() after start error = %v", err)
+	}
+}
+
+// TestRedirectCacheTTL проверяет, что кеш редиректа не переживает границы окна действия
+func TestRedirectCacheTTL(t *testing.T) {
+	now := time.Now()
+	ttl := time.Hour
+	at := func(d time.Duration) sql.NullTime { return sql.NullTime{Time: now.Add(d), Valid: true} }
+
+	tests := []struct {
+		name    string
+		url     *models.URL
+		wantTTL time.Duration
+		wantOK  bool
+	}{
+		{"без ограничений", &models.URL{}, ttl, true},
+		{"истекает позже TTL", &models.URL{ExpiresAt: at(2 * time.Hour)}, ttl, true},
+		{"истекает раньше TTL", &models.URL{ExpiresAt: at(10 * time.Minute)}, 10 * time.Minute, true},
+		{"уже истекла", &models.URL{ExpiresAt: at(-time.Minute)}, 0, false},
+		{"еще не активна", &models.URL{StartsAt: at(time.Minute)}, 0, false},
+		{"уже активна", &models.URL{StartsAt: at(-time.Minute)}, ttl, true},
+		{"лимит переходов", &models.URL{MaxClicks: sql.NullInt64{Int64: 5, Valid: true}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := redirectCacheTTL(tt.url, ttl, now)
+			if got != tt.wantTTL || ok != tt.wantOK {
+				t.Errorf("redirectCacheTTL() = %v, %v, want %v, %v", got, ok, tt.wantTTL, tt.wantOK)
+			}
+		})
+	}
+}
+
+// recordingNotifier запоминает отправленные события webhook
+type recordingNotifier struct {
+	events []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, userID int64, eventType string, data interface{}) {
+	n.events = append(n.events, eventType)
+}
+
+// TestURLService_WebhookEvents проверяет события жизненного цикла ссылки
+func TestURLService_WebhookEvents(t *testing.T) {
+	repo := newMockURLRepository()
+	notifier := &recordingNotifier{}
+	service := NewURLService(repo, &mockGenerator{}, nil, "http://localhost:8080", 3600, ShortURLStylePath, notifier)
+
+	created, err := service.CreateShortURL(context.Background(), testUserID, &models.CreateURLRequest{OriginalURL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	if _, err := service.UpdateURL(context.Background(), testUserID, created.ID, &models.UpdateURLRequest{OriginalURL: stringPtr("https://example.org")}); err != nil {
+		t.Fatalf("UpdateURL() error = %v", err)
+	}
+
+	// Истекшая ссылка уведомляется проходом по истекшим ссылкам
+	url, _ := repo.GetByID(context.Background(), created.ID)
+	url.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+	if n, err := service.NotifyExpiredLinks(context.Background()); err != nil || n != 1 {
+		t.Fatalf("NotifyExpiredLinks() = %d, %v, want 1", n, err)
+	}
+
+	if err := service.DeleteURL(context.Background(), testUserID, created.ID); err != nil {
+		t.Fatalf("DeleteURL() error = %v", err)
+	}
+
+	want := []string{
+		models.WebhookEventLinkCreated,
+		models.WebhookEventLinkUpdated,
+		models.WebhookEventLinkExpired,
+		models.WebhookEventLinkDeleted,
+	}
+	if fmt.Sprint(notifier.events) != fmt.Sprint(want) {
+		t.Errorf("events = %v, want %v", notifier.events, want)
+	}
+}
+
+// stringPtr возвращает указатель на строку
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// purgeBatch ссылок, окончательно удаляемых одним запросом
+const purgeBatch = 100
+
+// ListTrash получает ссылки пользователя из корзины, недавно удаленные первыми
+func (s *urlService) ListTrash(ctx context.Context, userID int64, filter models.URLFilter) ([]*models.URLResponse, error) {
+	if err := normalizeURLFilter(&filter); err != nil {
+		return nil, err
+	}
+	filter.Deleted = true
+	filter.Sort, filter.Descending = models.URLSortDeletedAt, true
+
+	urls, err := s.urlRepo.GetAll(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*models.URLResponse, 0, len(urls))
+	for _, url := range urls {
+		responses = append(responses, s.toResponse(url))
+	}
+
+	return responses, nil
+}
+
+// RestoreURL возвращает ссылку из корзины: код, теги, правила и аналитика остаются прежними
+func (s *urlService) RestoreURL(ctx context.Context, userID, id int64) (*models.URLResponse, error) {
+	url, err := s.urlRepo.Restore(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.toResponse(url)
+	s.notify(ctx, userID, models.WebhookEventLinkRestored, response)
+
+	return response, nil
+}
+
+// PurgeDeletedLinks окончательно удаляет ссылки, пролежавшие в корзине дольше retention.
+// Их коды нельзя занять еще quarantine с момента удаления. Возвращает число удаленных ссылок
+func (s *urlService) PurgeDeletedLinks(ctx context.Context, retention, quarantine time.Duration) (int, error) {
+	total := 0
+	for {
+		purged, err := s.urlRepo.PurgeDeleted(ctx, retention, quarantine, purgeBatch)
+		total += purged
+		if err != nil || purged < purgeBatch {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// TestURLService_Trash проверяет корзину: удаление, восстановление, очистку и карантин кодов
+func TestURLService_Trash(t *testing.T) {
+	repo := newMockURLRepository()
+	service := NewURLService(repo, &mockGenerator{}, nil, "http://localhost:8080", 3600, ShortURLStylePath, nil)
+	ctx := context.Background()
+
+	created, err := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{OriginalURL: "https://example.com", CustomCode: "spring"})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+
+	if err := service.DeleteURL(ctx, testUserID, created.ID); err != nil {
+		t.Fatalf("DeleteURL() error = %v", err)
+	}
+	if _, err := service.GetURLByID(ctx, testUserID, created.ID); err == nil {
+		t.Error("GetURLByID() should not find deleted link")
+	}
+	if _, err := service.GetURLByShortCode(ctx, "spring"); err == nil {
+		t.Error("GetURLByShortCode() should not find deleted link")
+	}
+	if _, err := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{OriginalURL: "https://example.org", CustomCode: "spring"}); err == nil {
+		t.Error("CreateShortURL() should not reuse code of link in trash")
+	}
+
+	trash, err := service.ListTrash(ctx, testUserID, models.URLFilter{})
+	if err != nil {
+		t.Fatalf("ListTrash() error = %v", err)
+	}
+	if len(trash) != 1 || trash[0].DeletedAt == nil {
+		t.Fatalf("trash = %+v, want deleted link with deleted_at", trash)
+	}
+	if !repo.lastFilter.Deleted || repo.lastFilter.Sort != models.URLSortDeletedAt || !repo.lastFilter.Descending {
+		t.Errorf("filter = %+v, want trash ordered by deleted_at", repo.lastFilter)
+	}
+
+	restored, err := service.RestoreURL(ctx, testUserID, created.ID)
+	if err != nil {
+		t.Fatalf("RestoreURL() error = %v", err)
+	}
+	if restored.ShortCode != "spring" || restored.DeletedAt != nil {
+		t.Errorf("restored = %+v, want active link with the same code", restored)
+	}
+	if _, err := service.RestoreURL(ctx, testUserID, created.ID); err == nil || !strings.Contains(err.Error(), "не найден") {
+		t.Errorf("RestoreURL() of active link error = %v, want not found", err)
+	}
+
+	// Ссылка старше срока хранения удаляется окончательно, код остается в карантине
+	if err := service.DeleteURL(ctx, testUserID, created.ID); err != nil {
+		t.Fatalf("DeleteURL() error = %v", err)
+	}
+	repo.urlsByID[created.ID].DeletedAt.Time = time.Now().Add(-40 * 24 * time.Hour)
+
+	purged, err := service.PurgeDeletedLinks(ctx, 30*24*time.Hour, 365*24*time.Hour)
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeDeletedLinks() = %d, %v, want 1", purged, err)
+	}
+	if _, err := service.RestoreURL(ctx, testUserID, created.ID); err == nil {
+		t.Error("RestoreURL() should not restore purged link")
+	}
+	if _, err := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{OriginalURL: "https://example.org", CustomCode: "spring"}); err == nil {
+		t.Error("CreateShortURL() should not reuse quarantined code")
+	}
+
+	// Без карантина код освобождается вместе с ссылкой
+	other, _ := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{OriginalURL: "https://example.com", CustomCode: "autumn"})
+	service.DeleteURL(ctx, testUserID, other.ID) // nolint:errcheck
+	repo.urlsByID[other.ID].DeletedAt.Time = time.Now().Add(-40 * 24 * time.Hour)
+	if _, err := service.PurgeDeletedLinks(ctx, 30*24*time.Hour, 0); err != nil {
+		t.Fatalf("PurgeDeletedLinks() error = %v", err)
+	}
+	if _, err := service.CreateShortURL(ctx, testUserID, &models.CreateURLRequest{OriginalURL: "https://example.org", CustomCode: "autumn"}); err != nil {
+		t.Errorf("CreateShortURL() error = %v, want code free after purge without quarantine", err)
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-redis/redis/v8"
+
+	"url-short/internal/models"
+	"url-short/internal/repository"
+)
+
+const (
+	// maxURLVariants ограничение количества вариантов одной ссылки
+	maxURLVariants = 20
+	// maxVariantWeight ограничение веса варианта
+	maxVariantWeight = 10000
+	// maxVariantNameLength ограничение длины названия варианта (в символах)
+	maxVariantNameLength = 100
+)
+
+// VariantService интерфейс для управления вариантами A/B теста и выбора варианта перехода
+type VariantService interface {
+	CreateVariant(ctx context.Context, userID, urlID int64, req *models.CreateURLVariantRequest) (*models.URLVariant, error)
+	ListVariants(ctx context.Context, userID, urlID int64) ([]*models.URLVariant, error)
+	UpdateVariant(ctx context.Context, userID, urlID, id int64, req *models.UpdateURLVariantRequest) (*models.URLVariant, error)
+	DeleteVariant(ctx context.Context, userID, urlID, id int64) error
+	Pick(ctx context.Context, url *models.URL, stickyID int64) *models.URLVariant
+}
+
+// variantService имплементация VariantService
+type variantService struct {
+	variantRepo repository.URLVariantRepository
+	urlRepo     repository.URLRepository
+	redis       *redis.Client
+	cacheTTL    time.Duration
+	// intn возвращает случайное число в [0, n)
+	intn func(n int) int
+}
+
+// NewVariantService создает новый Variant service.
+// Варианты ссылок кешируются в Redis на cacheTTL секунд и сбрасываются при изменении
+func NewVariantService(
+	variantRepo repository.URLVariantRepository,
+	urlRepo repository.URLRepository,
+	redis *redis.Client,
+	cacheTTL int,
+) VariantService {
+	return &variantService{
+		variantRepo: variantRepo,
+		urlRepo:     urlRepo,
+		redis:       redis,
+		cacheTTL:    time.Duration(cacheTTL) * time.Second,
+		intn:        rand.Intn,
+	}
+}
+
+// CreateVariant добавляет вариант ссылке владельца
+func (s *variantService) CreateVariant(ctx context.Context, userID, urlID int64, req *models.CreateURLVariantRequest) (*models.URLVariant, error) {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
+		return nil, err
+	}
+
+	variants, err := s.variantRepo.GetByURL(ctx, urlID)
+	if err != nil {
+		return nil, err
+	}
+	if len(variants) >= maxURLVariants {
+		return nil, fmt.Errorf("невалидный вариант: у ссылки не больше %d вариантов", maxURLVariants)
+	}
+
+	variant := &models.URLVariant{
+		URLID:     urlID,
+		Name:      req.Name,
+		TargetURL: req.TargetURL,
+		Weight:    1,
+	}
+	if req.Weight != nil {
+		variant.Weight = *req.Weight
+	}
+	if err := normalizeURLVariant(variant, variants); err != nil {
+		return nil, err
+	}
+
+	if err := s.variantRepo.Create(ctx, variant); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, urlID)
+
+	return variant, nil
+}
+
+// ListVariants получает варианты ссылки владельца
+func (s *variantService) ListVariants(ctx context.Context, userID, urlID int64) ([]*models.URLVariant, error) {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
+		return nil, err
+	}
+
+	return s.variantRepo.GetByURL(ctx, urlID)
+}
+
+// UpdateVariant частично обновляет вариант ссылки владельца.
+// Изменение веса не переназначает посетителей, уже закрепленных за вариантом
+func (s *variantService) UpdateVariant(ctx context.Context, userID, urlID, id int64, req *models.UpdateURLVariantRequest) (*models.URLVariant, error) {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
+		return nil, err
+	}
+
+	variant, err := s.variantRepo.GetByID(ctx, urlID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		variant.Name = *req.Name
+	}
+	if req.TargetURL != nil {
+		variant.TargetURL = *req.TargetURL
+	}
+	if req.Weight != nil {
+		variant.Weight = *req.Weight
+	}
+
+	variants, err := s.variantRepo.GetByURL(ctx, urlID)
+	if err != nil {
+		return nil, err
+	}
+	if err := normalizeURLVariant(variant, variants); err != nil {
+		return nil, err
+	}
+
+	if err := s.variantRepo.Update(ctx, variant); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, urlID)
+
+	return variant, nil
+}
+
+// DeleteVariant удаляет вариант ссылки владельца
+func (s *variantService) DeleteVariant(ctx context.Context, userID, urlID, id int64) error {
+	if _, err := getOwnedURL(ctx, s.urlRepo, userID, urlID); err != nil {
+		return err
+	}
+
+	if err := s.variantRepo.Delete(ctx, urlID, id); err != nil {
+		return err
+	}
+	s.invalidate(ctx, urlID)
+
+	return nil
+}
+
+// Pick выбирает вариант перехода по весам. stickyID - вариант, ранее закрепленный
+// за посетителем: он сохраняется, пока существует и не стоит на паузе.
+// Возвращает nil, если у ссылки нет активных вариантов
+func (s *variantService) Pick(ctx context.Context, url *models.URL, stickyID int64) *models.URLVariant {
+	variants := s.variants(ctx, url.ID)
+
+	total := 0
+	for _, variant := range variants {
+		if variant.Weight <= 0 {
+			continue
+		}
+		if variant.ID == stickyID {
+			return variant
+		}
+		total += variant.Weight
+	}
+	if total == 0 {
+		return nil
+	}
+
+	n := s.intn(total)
+	for _, variant := range variants {
+		if variant.Weight <= 0 {
+			continue
+		}
+		if n < variant.Weight {
+			return variant
+		}
+		n -= variant.Weight
+	}
+
+	return nil
+}
+
+// variants получает варианты ссылки из кеша или БД. Пустой список тоже кешируется,
+// поэтому ссылки без A/B теста не делают лишний запрос к БД на каждый переход
+func (s *variantService) variants(ctx context.Context, urlID int64) []*models.URLVariant {
+	cacheKey := fmt.Sprintf("variants:%d", urlID)
+
+	if s.redis != nil {
+		if data, err := s.redis.Get(ctx, cacheKey).Bytes(); err == nil {
+			var variants []*models.URLVariant
+			if err := json.Unmarshal(data, &variants); err == nil {
+				return variants
+			}
+		}
+	}
+
+	variants, err := s.variantRepo.GetByURL(ctx, urlID)
+	if err != nil {
+		log.Printf("Ошибка получения вариантов ссылки %d: %v", urlID, err)
+		return nil
+	}
+
+	// Кешируем в Redis (игнорируем ошибку кеширования)
+	if s.redis != nil {
+		if data, err := json.Marshal(variants); err == nil {
+			s.redis.Set(ctx, cacheKey, data, s.cacheTTL) // nolint:errcheck
+		}
+	}
+
+	return variants
+}
+
+// invalidate сбрасывает кеш вариантов ссылки (игнорируем ошибку)
+func (s *variantService) invalidate(ctx context.Context, urlID int64) {
+	if s.redis != nil {
+		s.redis.Del(ctx, fmt.Sprintf("variants:%d", urlID)) // nolint:errcheck
+	}
+}
+
+// normalizeURLVariant проверяет название, адрес и вес варианта.
+// Название должно быть уникальным среди остальных вариантов ссылки
+func normalizeURLVariant(variant *models.URLVariant, existing []*models.URLVariant) error {
+	variant.Name = strings.TrimSpace(variant.Name)
+	if variant.Name == "" || utf8.RuneCountInString(variant.Name) > maxVariantNameLength {
+		return fmt.Errorf("невалидное название варианта: от 1 до %d символов", maxVariantNameLength)
+	}
+	for _, other := range existing {
+		if other.ID != variant.ID && strings.EqualFold(other.Name, variant.Name) {
+			return fmt.Errorf("невалидное название варианта: %s уже есть у ссылки", variant.Name)
+		}
+	}
+
+	target, err := url.Parse(variant.TargetURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("невалидный адрес варианта: нужен http(s) адрес")
+	}
+
+	if variant.Weight < 0 || variant.Weight > maxVariantWeight {
+		return fmt.Errorf("невалидный вес варианта: от 0 до %d", maxVariantWeight)
+	}
+
+	return nil
+}
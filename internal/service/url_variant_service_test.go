@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"url-short/internal/models"
+)
+
+// mockURLVariantRepository мок для тестирования VariantService
+type mockURLVariantRepository struct {
+	variants map[int64]*models.URLVariant
+	nextID   int64
+}
+
+func newMockURLVariantRepository() *mockURLVariantRepository {
+	return &mockURLVariantRepository{variants: make(map[int64]*models.URLVariant), nextID: 1}
+}
+
+func (m *mockURLVariantRepository) Create(ctx context.Context, variant *models.URLVariant) error {
+	variant.ID = m.nextID
+	m.nextID++
+	stored := *variant
+	m.variants[variant.ID] = &stored
+	return nil
+}
+
+func (m *mockURLVariantRepository) GetByID(ctx context.Context, urlID, id int64) (*models.URLVariant, error) {
+	variant, ok := m.variants[id]
+	if !ok || variant.URLID != urlID {
+		return nil, fmt.Errorf("вариант с ID %d не найден", id)
+	}
+	copied := *variant
+	return &copied, nil
+}
+
+func (m *mockURLVariantRepository) GetByURL(ctx context.Context, urlID int64) ([]*models.URLVariant, error) {
+	variants := make([]*models.URLVariant, 0)
+	for _, variant := range m.variants {
+		if variant.URLID == urlID {
+			variants = append(variants, variant)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].ID < variants[j].ID })
+	return variants, nil
+}
+
+func (m *mockURLVariantRepository) Update(ctx context.Context, variant *models.URLVariant) error {
+	stored := *variant
+	m.variants[variant.ID] = &stored
+	return nil
+}
+
+func (m *mockURLVariantRepository) Delete(ctx context.Context, urlID, id int64) error {
+	if variant, ok := m.variants[id]; !ok || variant.URLID != urlID {
+		return fmt.Errorf("вариант с ID %d не найден", id)
+	}
+	delete(m.variants, id)
+	return nil
+}
+
+// newTestVariantService создает сервис вариантов со ссылкой владельца testUserID.
+// Случайный выбор заменен на первый вариант, тесты подменяют intn при необходимости
+func newTestVariantService(t *testing.T) (*variantService, *models.URL) {
+	t.Helper()
+
+	urlRepo := newMockURLRepository()
+	url := &models.URL{ShortCode: "landing", OriginalURL: "https://example.com"}
+	url.UserID.Int64, url.UserID.Valid = testUserID, true
+	if err := urlRepo.Create(context.Background(), url); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	service := NewVariantService(newMockURLVariantRepository(), urlRepo, nil, 3600).(*variantService)
+	service.intn = func(n int) int { return 0 }
+	return service, url
+}
+
+// TestVariantService_Pick проверяет выбор варианта по весам, паузу и закрепление за посетителем
+func TestVariantService_Pick(t *testing.T) {
+	service, url := newTestVariantService(t)
+	ctx := context.Background()
+
+	if got := service.Pick(ctx, url, 0); got != nil {
+		t.Fatalf("Pick() without variants = %v, want nil", got)
+	}
+
+	weights := map[string]int{"a": 1, "paused": 0, "b": 3}
+	ids := make(map[string]int64)
+	for _, name := range []string{"a", "paused", "b"} {
+		weight := weights[name]
+		variant, err := service.CreateVariant(ctx, testUserID, url.ID, &models.CreateURLVariantRequest{
+			Name:      name,
+			TargetURL: "https://example.com/" + name,
+			Weight:    &weight,
+		})
+		if err != nil {
+			t.Fatalf("CreateVariant(%s) error = %v", name, err)
+		}
+		ids[name] = variant.ID
+	}
+
+	// Сумма активных весов 4: 0 -> a, 1..3 -> b, вариант на паузе не выбирается
+	for roll, want := range []string{"a", "b", "b", "b"} {
+		service.intn = func(n int) int {
+			if n != 4 {
+				t.Fatalf("intn(%d), want total weight 4", n)
+			}
+			return roll
+		}
+		if got := service.Pick(ctx, url, 0); got == nil || got.Name != want {
+			t.Errorf("Pick() with roll %d = %v, want %s", roll, got, want)
+		}
+	}
+
+	// Закрепленный вариант сохраняется, пока активен
+	service.intn = func(n int) int { return 3 }
+	if got := service.Pick(ctx, url, ids["a"]); got == nil || got.Name != "a" {
+		t.Errorf("Pick(sticky a) = %v, want a", got)
+	}
+	if got := service.Pick(ctx, url, ids["paused"]); got == nil || got.Name != "b" {
+		t.Errorf("Pick(sticky paused) = %v, want reassignment to b", got)
+	}
+	if got := service.Pick(ctx, url, 999); got == nil || got.Name != "b" {
+		t.Errorf("Pick(sticky deleted) = %v, want reassignment to b", got)
+	}
+
+	// Все варианты на паузе - переход на original_url
+	zero := 0
+	for _, name := range []string{"a", "b"} {
+		if _, err := service.UpdateVariant(ctx, testUserID, url.ID, ids[name], &models.UpdateURLVariantRequest{Weight: &zero}); err != nil {
+			t.Fatalf("UpdateVariant(%s) error = %v", name, err)
+		}
+	}
+	if got := service.Pick(ctx, url, ids["a"]); got != nil {
+		t.Errorf("Pick() with paused variants = %v, want nil", got)
+	}
+}
+
+// TestVariantService_Manage проверяет валидацию, владельца и изменение вариантов
+func TestVariantService_Manage(t *testing.T) {
+	service, url := newTestVariantService(t)
+	ctx := context.Background()
+
+	negative, huge := -1, maxVariantWeight+1
+	invalid := []*models.CreateURLVariantRequest{
+		{Name: "", TargetURL: "https://example.com/a"},
+		{Name: strings.Repeat("я", maxVariantNameLength+1), TargetURL: "https://example.com/a"},
+		{Name: "a", TargetURL: "ftp://example.com/a"},
+		{Name: "a", TargetURL: "example.com/a"},
+		{Name: "a", TargetURL: "https://example.com/a", Weight: &negative},
+		{Name: "a", TargetURL: "https://example.com/a", Weight: &huge},
+	}
+	for _, req := range invalid {
+		if _, err := service.CreateVariant(ctx, testUserID, url.ID, req); err == nil || !strings.Contains(err.Error(), "невалидн") {
+			t.Errorf("CreateVariant(%+v) error = %v, want validation error", req, err)
+		}
+	}
+
+	variant, err := service.CreateVariant(ctx, testUserID, url.ID, &models.CreateURLVariantRequest{
+		Name:      " Control ",
+		TargetURL: "https://example.com/a",
+	})
+	if err != nil {
+		t.Fatalf("CreateVariant() error = %v", err)
+	}
+	if variant.Name != "Control" || variant.Weight != 1 {
+		t.Errorf("variant = %+v, want trimmed name and default weight 1", variant)
+	}
+
+	if _, err := service.CreateVariant(ctx, testUserID, url.ID, &models.CreateURLVariantRequest{
+		Name:      "control",
+		TargetURL: "https://example.com/b",
+	}); err == nil || !strings.Contains(err.Error(), "невалидн") {
+		t.Errorf("CreateVariant(duplicate name) error = %v, want validation error", err)
+	}
+
+	// Чужая ссылка не видна
+	if _, err := service.ListVariants(ctx, testUserID+1, url.ID); err == nil || !strings.Contains(err.Error(), "не найден") {
+		t.Errorf("ListVariants(other user) error = %v, want not found", err)
+	}
+
+	name := "Control"
+	target := "https://example.com/c"
+	updated, err := service.UpdateVariant(ctx, testUserID, url.ID, variant.ID, &models.UpdateURLVariantRequest{Name: &name, TargetURL: &target})
+	if err != nil {
+		t.Fatalf("UpdateVariant() error = %v", err)
+	}
+	if updated.TargetURL != target || updated.Weight != 1 {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	if err := service.DeleteVariant(ctx, testUserID, url.ID, variant.ID); err != nil {
+		t.Fatalf("DeleteVariant() error = %v", err)
+	}
+	if err := service.DeleteVariant(ctx, testUserID, url.ID, variant.ID); err == nil || !strings.Contains(err.Error(), "не найден") {
+		t.Errorf("DeleteVariant(deleted) error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"url-short/internal/config"
+	"url-short/internal/models"
+	"url-short/internal/repository"
+)
+
+const (
+	// webhookPollInterval как часто проверяется очередь доставок
+	webhookPollInterval = time.Second
+	// webhookClaimBatch сколько доставок забирается за один проход
+	webhookClaimBatch = 20
+	// webhookConcurrency сколько доставок отправляется параллельно
+	webhookConcurrency = 4
+	// webhookRetryBase задержка перед второй попыткой, дальше удваивается
+	webhookRetryBase = 30 * time.Second
+	// webhookRetryMax максимальная задержка между попытками
+	webhookRetryMax = 6 * time.Hour
+	// webhookErrorBodyLimit сколько байт ответа подписчика сохраняется в last_error
+	webhookErrorBodyLimit = 512
+)
+
+// Заголовки запроса webhook
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookIDHeader        = "X-Webhook-ID"
+)
+
+// errPrivateTarget возвращается при попытке доставки на внутренний адрес
+var errPrivateTarget = errors.New("адрес webhook указывает во внутреннюю сеть")
+
+// WebhookDispatcher отправляет доставки из журнала подписчикам.
+// Доставки забираются из БД, поэтому несколько инстансов могут работать параллельно
+type WebhookDispatcher struct {
+	deliveryRepo repository.WebhookDeliveryRepository
+	client       *http.Client
+	maxAttempts  int
+	timeout      time.Duration
+
+	startOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewWebhookDispatcher создает отправщик webhook
+func NewWebhookDispatcher(deliveryRepo repository.WebhookDeliveryRepository, cfg config.WebhookConfig) *WebhookDispatcher {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+	timeout := cfg.GetTimeout()
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &WebhookDispatcher{
+		deliveryRepo: deliveryRepo,
+		client:       newWebhookClient(timeout, cfg.AllowPrivateTargets),
+		maxAttempts:  maxAttempts,
+		timeout:      timeout,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start запускает опрос очереди доставок
+func (d *WebhookDispatcher) Start() {
+	d.startOnce.Do(func() {
+		go d.run()
+	})
+}
+
+// Shutdown останавливает опрос и ждет завершения отправляемых доставок
+func (d *WebhookDispatcher) Shutdown(ctx context.Context) error {
+	d.Start()
+	select {
+	case <-d.stop:
+	default:
+		close(d.stop)
+	}
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run забирает доставки по таймеру, пока очередь не опустеет
+func (d *WebhookDispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			for d.dispatchBatch() == webhookClaimBatch {
+				select {
+				case <-d.stop:
+					return
+				default:
+				}
+			}
+		}
+	}
+}
+
+// dispatchBatch отправляет одну пачку доставок и возвращает ее размер
+func (d *WebhookDispatcher) dispatchBatch() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Доставка считается занятой на время всех попыток отправки пачки
+	lease := d.timeout*webhookClaimBatch/webhookConcurrency + time.Minute
+	deliveries, err := d.deliveryRepo.ClaimDue(ctx, webhookClaimBatch, lease)
+	cancel()
+	if err != nil {
+		log.Printf("Ошибка выборки доставок webhook: %v", err)
+		return 0
+	}
+
+	sem := make(chan struct{}, webhookConcurrency)
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			d.deliver(delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+// deliver отправляет доставку и сохраняет результат попытки
+func (d *WebhookDispatcher) deliver(delivery *models.WebhookDelivery) {
+	status, err := d.send(delivery)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err == nil {
+		if err := d.deliveryRepo.MarkSucceeded(ctx, delivery.ID, status); err != nil {
+			log.Printf("Ошибка сохранения доставки webhook %d: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	var next *time.Time
+	if delivery.Attempts < d.maxAttempts {
+		at := time.Now().Add(webhookRetryDelay(delivery.Attempts))
+		next = &at
+	}
+	if err := d.deliveryRepo.MarkFailed(ctx, delivery.ID, status, err.Error(), next); err != nil {
+		log.Printf("Ошибка сохранения доставки webhook %d: %v", delivery.ID, err)
+	}
+}
+
+// send выполняет запрос к подписчику. Успешным считается любой ответ 2xx
+func (d *WebhookDispatcher) send(delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("невалидный URL webhook: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "url-short-webhooks/1.0")
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Secret, time.Now(), body))
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookIDHeader, delivery.EventID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookErrorBodyLimit))
+		return resp.StatusCode, fmt.Errorf("ответ %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, webhookErrorBodyLimit)) // nolint:errcheck
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload формирует заголовок подписи: t=<unix время>,v1=<hex HMAC-SHA256>.
+// Подписывается строка "<t>.<тело>", чтобы подпись нельзя было переиспользовать позже
+func SignWebhookPayload(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay задержка перед следующей попыткой после attempt неудачных
+func webhookRetryDelay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := webhookRetryBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= webhookRetryMax {
+			return webhookRetryMax
+		}
+	}
+	return delay
+}
+
+// newWebhookClient создает HTTP клиент без переходов по редиректам.
+// Если allowPrivate выключен, соединения с внутренними адресами запрещены
+// на этапе подключения, поэтому DNS не может подменить адрес после проверки
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || isPrivateIP(ip) {
+				return errPrivateTarget
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isPrivateIP проверяет, что адрес не доступен из интернета
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
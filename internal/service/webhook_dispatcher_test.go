@@ -0,0 +1,126 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"url-short/internal/config"
+	"url-short/internal/models"
+)
+
+// TestSignWebhookPayload проверяет формат и значение подписи
+func TestSignWebhookPayload(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"evt_1"}`)
+
+	got := SignWebhookPayload("whsec_test", at, body)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got != want {
+		t.Errorf("SignWebhookPayload() = %s, want %s", got, want)
+	}
+}
+
+// TestWebhookRetryDelay проверяет экспоненциальную задержку с потолком
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{20, webhookRetryMax},
+	}
+
+	for _, tt := range tests {
+		if got := webhookRetryDelay(tt.attempt); got != tt.want {
+			t.Errorf("webhookRetryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// TestWebhookDispatcher_Deliver проверяет заголовки запроса и сохранение результата
+func TestWebhookDispatcher_Deliver(t *testing.T) {
+	var gotHeader http.Header
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		if strings.Contains(r.URL.Path, "fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := newMockWebhookDeliveryRepository()
+	dispatcher := NewWebhookDispatcher(repo, config.WebhookConfig{MaxAttempts: 3, TimeoutSeconds: 5, AllowPrivateTargets: true})
+
+	delivery := &models.WebhookDelivery{
+		ID:        7,
+		EventID:   "evt_1",
+		EventType: models.WebhookEventLinkCreated,
+		Payload:   `{"id":"evt_1"}`,
+		Attempts:  1,
+		URL:       server.URL + "/ok",
+		Secret:    "whsec_test",
+	}
+	dispatcher.deliver(delivery)
+
+	if repo.succeeded[7] != http.StatusNoContent {
+		t.Fatalf("succeeded = %v, want 204 for delivery 7", repo.succeeded)
+	}
+	if gotBody != delivery.Payload {
+		t.Errorf("body = %s, want %s", gotBody, delivery.Payload)
+	}
+	if gotHeader.Get(WebhookEventHeader) != models.WebhookEventLinkCreated ||
+		gotHeader.Get(WebhookIDHeader) != "evt_1" ||
+		gotHeader.Get(WebhookDeliveryHeader) != "7" ||
+		!strings.HasPrefix(gotHeader.Get(WebhookSignatureHeader), "t=") {
+		t.Errorf("headers = %v", gotHeader)
+	}
+
+	// Неуспешный ответ планирует повтор, пока попытки не исчерпаны
+	delivery.URL = server.URL + "/fail"
+	dispatcher.deliver(delivery)
+	if next := repo.failed[7]; next == nil || next.Before(time.Now()) {
+		t.Errorf("next attempt = %v, want retry in the future", next)
+	}
+
+	delivery.Attempts = 3
+	dispatcher.deliver(delivery)
+	if next, ok := repo.failed[7]; !ok || next != nil {
+		t.Errorf("next attempt = %v, want nil after last attempt", next)
+	}
+	if !strings.Contains(repo.lastErrors[7], "500") {
+		t.Errorf("last error = %q, want response status", repo.lastErrors[7])
+	}
+}
+
+// TestWebhookClient_RejectsPrivateTargets проверяет запрет доставки во внутреннюю сеть
+func TestWebhookClient_RejectsPrivateTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := newWebhookClient(time.Second, false)
+	_, err := client.Get(server.URL)
+	if err == nil || !errors.Is(err, errPrivateTarget) {
+		t.Errorf("Get() error = %v, want errPrivateTarget", err)
+	}
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"time"
+
+	"url-short/internal/models"
+	"url-short/internal/repository"
+)
+
+const (
+	// maxWebhookThresholds ограничение количества порогов кликов в подписке
+	maxWebhookThresholds = 20
+	// maxWebhookDeliveriesList ограничение журнала доставок в ответе
+	maxWebhookDeliveriesList = 100
+	// webhookNotifyTimeout время на сохранение доставок события
+	webhookNotifyTimeout = 5 * time.Second
+)
+
+// WebhookNotifier принимает события для рассылки подписчикам.
+// Ошибки не возвращаются: событие не должно ломать основную операцию
+type WebhookNotifier interface {
+	Notify(ctx context.Context, userID int64, eventType string, data interface{})
+}
+
+// WebhookService интерфейс для управления подписками webhook
+type WebhookService interface {
+	WebhookNotifier
+	ClickCountObserver
+	CreateWebhook(ctx context.Context, userID int64, req *models.CreateWebhookRequest) (*models.WebhookResponse, error)
+	ListWebhooks(ctx context.Context, userID int64) ([]*models.Webhook, error)
+	GetWebhook(ctx context.Context, userID, id int64) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, userID, id int64, req *models.UpdateWebhookRequest) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, id int64) error
+	ListDeliveries(ctx context.Context, userID, webhookID int64, limit int) ([]*models.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, userID, webhookID, deliveryID int64) (*models.WebhookDelivery, error)
+}
+
+// webhookService имплементация WebhookService
+type webhookService struct {
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+}
+
+// NewWebhookService создает новый Webhook service. События сохраняются
+// в журнал доставок, отправляет их WebhookDispatcher
+func NewWebhookService(
+	webhookRepo repository.WebhookRepository,
+	deliveryRepo repository.WebhookDeliveryRepository,
+) WebhookService {
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+	}
+}
+
+// CreateWebhook создает подписку. Секрет подписи возвращается только в этом ответе
+func (s *webhookService) CreateWebhook(ctx context.Context, userID int64, req *models.CreateWebhookRequest) (*models.WebhookResponse, error) {
+	webhook := &models.Webhook{
+		UserID:          userID,
+		URL:             req.URL,
+		Events:          req.Events,
+		ClickThresholds: req.ClickThresholds,
+		Active:          true,
+	}
+	if err := normalizeWebhook(webhook); err != nil {
+		return nil, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации секрета: %w", err)
+	}
+	webhook.Secret = models.WebhookSecretPrefix + token
+
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookResponse{Webhook: webhook, Secret: webhook.Secret}, nil
+}
+
+// ListWebhooks получает подписки пользователя
+func (s *webhookService) ListWebhooks(ctx context.Context, userID int64) ([]*models.Webhook, error) {
+	return s.webhookRepo.GetByUser(ctx, userID)
+}
+
+// GetWebhook получает подписку пользователя
+func (s *webhookService) GetWebhook(ctx context.Context, userID, id int64) (*models.Webhook, error) {
+	return s.webhookRepo.GetByID(ctx, userID, id)
+}
+
+// UpdateWebhook частично обновляет подписку
+func (s *webhookService) UpdateWebhook(ctx context.Context, userID, id int64, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		webhook.Events = *req.Events
+	}
+	if req.ClickThresholds != nil {
+		webhook.ClickThresholds = *req.ClickThresholds
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := normalizeWebhook(webhook); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook удаляет подписку
+func (s *webhookService) DeleteWebhook(ctx context.Context, userID, id int64) error {
+	return s.webhookRepo.Delete(ctx, userID, id)
+}
+
+// ListDeliveries получает последние доставки подписки
+func (s *webhookService) ListDeliveries(ctx context.Context, userID, webhookID int64, limit int) ([]*models.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetByID(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > maxWebhookDeliveriesList {
+		limit = 50
+	}
+
+	return s.deliveryRepo.GetByWebhook(ctx, webhookID, limit)
+}
+
+// ReplayDelivery ставит событие доставки в очередь повторно отдельной доставкой.
+// event id сохраняется, чтобы получатель мог отбросить дубликат
+func (s *webhookService) ReplayDelivery(ctx context.Context, userID, webhookID, deliveryID int64) (*models.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetByID(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	original, err := s.deliveryRepo.GetByID(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := &models.WebhookDelivery{
+		WebhookID: webhookID,
+		EventID:   original.EventID,
+		EventType: original.EventType,
+		Payload:   original.Payload,
+	}
+	if err := s.deliveryRepo.Create(ctx, []*models.WebhookDelivery{replay}); err != nil {
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+// Notify сохраняет событие в журнал доставок каждой активной подписки на него
+func (s *webhookService) Notify(ctx context.Context, userID int64, eventType string, data interface{}) {
+	if userID == 0 {
+		return
+	}
+
+	// Событие сохраняется, даже если запрос, который его вызвал, уже завершился
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webhookNotifyTimeout)
+	defer cancel()
+
+	webhooks, err := s.webhookRepo.GetActiveByEvent(ctx, userID, eventType)
+	if err != nil {
+		log.Printf("Ошибка получения webhooks для %s: %v", eventType, err)
+		return
+	}
+
+	s.enqueue(ctx, webhooks, eventType, data)
+}
+
+// ClickCountsUpdated отправляет click.threshold подпискам, порог которых
+// пересечен записанной пачкой кликов
+func (s *webhookService) ClickCountsUpdated(ctx context.Context, counts []models.URLClickCount) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webhookNotifyTimeout)
+	defer cancel()
+
+	// Подписки пользователя запрашиваются один раз на пачку
+	byUser := make(map[int64][]*models.Webhook)
+	for _, count := range counts {
+		if !count.UserID.Valid {
+			continue
+		}
+		userID := count.UserID.Int64
+
+		webhooks, loaded := byUser[userID]
+		if !loaded {
+			var err error
+			webhooks, err = s.webhookRepo.GetActiveByEvent(ctx, userID, models.WebhookEventClickThreshold)
+			if err != nil {
+				log.Printf("Ошибка получения webhooks для %s: %v", models.WebhookEventClickThreshold, err)
+				continue
+			}
+			byUser[userID] = webhooks
+		}
+
+		previous := count.ClicksCount - count.Added
+		for _, webhook := range webhooks {
+			for _, threshold := range webhook.ClickThresholds {
+				if previous < threshold && threshold <= count.ClicksCount {
+					s.enqueue(ctx, []*models.Webhook{webhook}, models.WebhookEventClickThreshold, models.ClickThresholdData{
+						URLID:       count.URLID,
+						ShortCode:   count.ShortCode,
+						Threshold:   threshold,
+						ClicksCount: count.ClicksCount,
+					})
+				}
+			}
+		}
+	}
+}
+
+// enqueue сериализует событие и создает доставки подписчикам
+func (s *webhookService) enqueue(ctx context.Context, webhooks []*models.Webhook, eventType string, data interface{}) {
+	if len(webhooks) == 0 {
+		return
+	}
+
+	event, err := newWebhookEvent(eventType, data)
+	if err != nil {
+		log.Printf("Ошибка создания события %s: %v", eventType, err)
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Ошибка сериализации события %s: %v", eventType, err)
+		return
+	}
+
+	deliveries := make([]*models.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: eventType,
+			Payload:   string(payload),
+		})
+	}
+
+	if err := s.deliveryRepo.Create(ctx, deliveries); err != nil {
+		log.Printf("Ошибка сохранения доставок события %s: %v", eventType, err)
+	}
+}
+
+// newWebhookEvent создает событие с уникальным ID
+func newWebhookEvent(eventType string, data interface{}) (*models.WebhookEvent, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookEvent{
+		ID:        "evt_" + hex.EncodeToString(buf),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}, nil
+}
+
+// normalizeWebhook проверяет адрес, события и пороги кликов подписки.
+// Пороги сортируются, дубликаты удаляются
+func normalizeWebhook(webhook *models.Webhook) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("невалидный URL webhook: нужен http(s) адрес")
+	}
+
+	if len(webhook.Events) == 0 {
+		return fmt.Errorf("невалидные события: нужно хотя бы одно событие")
+	}
+	for _, event := range webhook.Events {
+		if !models.IsValidWebhookEvent(event) {
+			return fmt.Errorf("невалидное событие: %s", event)
+		}
+	}
+
+	if len(webhook.ClickThresholds) > maxWebhookThresholds {
+		return fmt.Errorf("невалидные пороги кликов: не больше %d", maxWebhookThresholds)
+	}
+	thresholds := make([]int64, 0, len(webhook.ClickThresholds))
+	seen := make(map[int64]bool, len(webhook.ClickThresholds))
+	for _, threshold := range webhook.ClickThresholds {
+		if threshold <= 0 {
+			return fmt.Errorf("невалидный порог кликов: %d", threshold)
+		}
+		if !seen[threshold] {
+			seen[threshold] = true
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i] < thresholds[j] })
+	webhook.ClickThresholds = thresholds
+
+	if webhook.Subscribes(models.WebhookEventClickThreshold) && len(webhook.ClickThresholds) == 0 {
+		return fmt.Errorf("невалидные пороги кликов: для %s нужен хотя бы один порог", models.WebhookEventClickThreshold)
+	}
+
+	return nil
+}
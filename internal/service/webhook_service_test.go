@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"url-short/internal/models"
+)
+
+// mockWebhookRepository мок для тестирования WebhookService
+type mockWebhookRepository struct {
+	webhooks map[int64]*models.Webhook
+	nextID   int64
+}
+
+func newMockWebhookRepository() *mockWebhookRepository {
+	return &mockWebhookRepository{webhooks: make(map[int64]*models.Webhook), nextID: 1}
+}
+
+func (m *mockWebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	webhook.ID = m.nextID
+	m.nextID++
+	m.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (m *mockWebhookRepository) GetByID(ctx context.Context, userID, id int64) (*models.Webhook, error) {
+	webhook, ok := m.webhooks[id]
+	if !ok || webhook.UserID != userID {
+		return nil, fmt.Errorf("webhook с ID %d не найден", id)
+	}
+	copied := *webhook
+	return &copied, nil
+}
+
+func (m *mockWebhookRepository) GetByUser(ctx context.Context, userID int64) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	for _, webhook := range m.webhooks {
+		if webhook.UserID == userID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *mockWebhookRepository) GetActiveByEvent(ctx context.Context, userID int64, eventType string) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	for _, webhook := range m.webhooks {
+		if webhook.UserID == userID && webhook.Active && webhook.Subscribes(eventType) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *mockWebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	m.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (m *mockWebhookRepository) Delete(ctx context.Context, userID, id int64) error {
+	if _, err := m.GetByID(ctx, userID, id); err != nil {
+		return err
+	}
+	delete(m.webhooks, id)
+	return nil
+}
+
+// mockWebhookDeliveryRepository мок журнала доставок
+type mockWebhookDeliveryRepository struct {
+	mu         sync.Mutex
+	deliveries []*models.WebhookDelivery
+	due        []*models.WebhookDelivery
+	succeeded  map[int64]int
+	failed     map[int64]*time.Time
+	lastErrors map[int64]string
+}
+
+func newMockWebhookDeliveryRepository() *mockWebhookDeliveryRepository {
+	return &mockWebhookDeliveryRepository{
+		succeeded:  make(map[int64]int),
+		failed:     make(map[int64]*time.Time),
+		lastErrors: make(map[int64]string),
+	}
+}
+
+func (m *mockWebhookDeliveryRepository) Create(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		delivery.ID = int64(len(m.deliveries) + 1)
+		delivery.Status = models.WebhookDeliveryPending
+		m.deliveries = append(m.deliveries, delivery)
+	}
+	return nil
+}
+
+func (m *mockWebhookDeliveryRepository) GetByID(ctx context.Context, webhookID, id int64) (*models.WebhookDelivery, error) {
+	for _, delivery := range m.deliveries {
+		if delivery.ID == id && delivery.WebhookID == webhookID {
+			return delivery, nil
+		}
+	}
+	return nil, fmt.Errorf("доставка с ID %d не найдена", id)
+}
+
+func (m *mockWebhookDeliveryRepository) GetByWebhook(ctx context.Context, webhookID int64, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *mockWebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := m.due
+	m.due = nil
+	return due, nil
+}
+
+func (m *mockWebhookDeliveryRepository) MarkSucceeded(ctx context.Context, id int64, responseStatus int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.succeeded[id] = responseStatus
+	return nil
+}
+
+func (m *mockWebhookDeliveryRepository) MarkFailed(ctx context.Context, id int64, responseStatus int, lastError string, nextAttemptAt *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failed[id] = nextAttemptAt
+	m.lastErrors[id] = lastError
+	return nil
+}
+
+// TestCreateWebhook_Validation проверяет проверку адреса, событий и порогов
+func TestCreateWebhook_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.CreateWebhookRequest
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			req:  models.CreateWebhookRequest{URL: "https://example.com/hook", Events: []string{models.WebhookEventLinkCreated}},
+		},
+		{
+			name: "thresholds",
+			req: models.CreateWebhookRequest{
+				URL:             "https://example.com/hook",
+				Events:          []string{models.WebhookEventClickThreshold},
+				ClickThresholds: []int64{100, 10, 100},
+			},
+		},
+		{
+			name:    "not http",
+			req:     models.CreateWebhookRequest{URL: "ftp://example.com", Events: []string{models.WebhookEventLinkCreated}},
+			wantErr: true,
+		},
+		{
+			name:    "no events",
+			req:     models.CreateWebhookRequest{URL: "https://example.com/hook"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown event",
+			req:     models.CreateWebhookRequest{URL: "https://example.com/hook", Events: []string{"link.viewed"}},
+			wantErr: true,
+		},
+		{
+			name:    "threshold event without thresholds",
+			req:     models.CreateWebhookRequest{URL: "https://example.com/hook", Events: []string{models.WebhookEventClickThreshold}},
+			wantErr: true,
+		},
+		{
+			name: "negative threshold",
+			req: models.CreateWebhookRequest{
+				URL:             "https://example.com/hook",
+				Events:          []string{models.WebhookEventClickThreshold},
+				ClickThresholds: []int64{-5},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewWebhookService(newMockWebhookRepository(), newMockWebhookDeliveryRepository())
+
+			resp, err := svc.CreateWebhook(context.Background(), testUserID, &tt.req)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "невалидн") {
+					t.Errorf("CreateWebhook() error = %v, want validation error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateWebhook() error = %v", err)
+			}
+			if !strings.HasPrefix(resp.Secret, models.WebhookSecretPrefix) {
+				t.Errorf("Secret = %q, want prefix %q", resp.Secret, models.WebhookSecretPrefix)
+			}
+		})
+	}
+}
+
+// TestCreateWebhook_NormalizesThresholds проверяет сортировку и удаление дублей порогов
+func TestCreateWebhook_NormalizesThresholds(t *testing.T) {
+	svc := NewWebhookService(newMockWebhookRepository(), newMockWebhookDeliveryRepository())
+
+	resp, err := svc.CreateWebhook(context.Background(), testUserID, &models.CreateWebhookRequest{
+		URL:             "https://example.com/hook",
+		Events:          []string{models.WebhookEventClickThreshold},
+		ClickThresholds: []int64{1000, 10, 100, 10},
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	want := []int64{10, 100, 1000}
+	if fmt.Sprint(resp.ClickThresholds) != fmt.Sprint(want) {
+		t.Errorf("ClickThresholds = %v, want %v", resp.ClickThresholds, want)
+	}
+}
+
+// TestNotify_OnlySubscribedWebhooks проверяет, что событие получают только подписанные активные webhooks
+func TestNotify_OnlySubscribedWebhooks(t *testing.T) {
+	repo := newMockWebhookRepository()
+	deliveries := newMockWebhookDeliveryRepository()
+	svc := NewWebhookService(repo, deliveries)
+
+	repo.Create(context.Background(), &models.Webhook{UserID: testUserID, Events: []string{models.WebhookEventLinkCreated}, Active: true})  // nolint:errcheck
+	repo.Create(context.Background(), &models.Webhook{UserID: testUserID, Events: []string{models.WebhookEventLinkDeleted}, Active: true})  // nolint:errcheck
+	repo.Create(context.Background(), &models.Webhook{UserID: testUserID, Events: []string{models.WebhookEventLinkCreated}, Active: false}) // nolint:errcheck
+	repo.Create(context.Background(), &models.Webhook{UserID: 2, Events: []string{models.WebhookEventLinkCreated}, Active: true})           // nolint:errcheck
+
+	svc.Notify(context.Background(), testUserID, models.WebhookEventLinkCreated, map[string]string{"short_code": "abc"})
+
+	if len(deliveries.deliveries) != 1 {
+		t.Fatalf("deliveries = %d, want 1", len(deliveries.deliveries))
+	}
+	delivery := deliveries.deliveries[0]
+	if delivery.WebhookID != 1 {
+		t.Errorf("WebhookID = %d, want 1", delivery.WebhookID)
+	}
+
+	var event models.WebhookEvent
+	if err := json.Unmarshal([]byte(delivery.Payload), &event); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if event.Type != models.WebhookEventLinkCreated || event.ID != delivery.EventID || !strings.HasPrefix(event.ID, "evt_") {
+		t.Errorf("event = %+v, delivery event id = %s", event, delivery.EventID)
+	}
+}
+
+// TestClickCountsUpdated_FiresCrossedThresholds проверяет, что порог срабатывает один раз при пересечении
+func TestClickCountsUpdated_FiresCrossedThresholds(t *testing.T) {
+	repo := newMockWebhookRepository()
+	deliveries := newMockWebhookDeliveryRepository()
+	svc := NewWebhookService(repo, deliveries)
+
+	repo.Create(context.Background(), &models.Webhook{ // nolint:errcheck
+		UserID:          testUserID,
+		Events:          []string{models.WebhookEventClickThreshold},
+		ClickThresholds: []int64{10, 100, 1000},
+		Active:          true,
+	})
+
+	owner := sql.NullInt64{Int64: testUserID, Valid: true}
+
+	// 5 -> 9: порог не достигнут
+	svc.ClickCountsUpdated(context.Background(), []models.URLClickCount{{URLID: 1, UserID: owner, ShortCode: "abc", ClicksCount: 9, Added: 4}})
+	if len(deliveries.deliveries) != 0 {
+		t.Fatalf("deliveries = %d, want 0", len(deliveries.deliveries))
+	}
+
+	// 9 -> 120: пересечены 10 и 100
+	svc.ClickCountsUpdated(context.Background(), []models.URLClickCount{{URLID: 1, UserID: owner, ShortCode: "abc", ClicksCount: 120, Added: 111}})
+	if len(deliveries.deliveries) != 2 {
+		t.Fatalf("deliveries = %d, want 2", len(deliveries.deliveries))
+	}
+
+	var event struct {
+		Data models.ClickThresholdData `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(deliveries.deliveries[1].Payload), &event); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if event.Data.Threshold != 100 || event.Data.ClicksCount != 120 {
+		t.Errorf("data = %+v, want threshold 100 at 120 clicks", event.Data)
+	}
+
+	// 120 -> 130: новых порогов нет, 100 не повторяется
+	svc.ClickCountsUpdated(context.Background(), []models.URLClickCount{{URLID: 1, UserID: owner, ShortCode: "abc", ClicksCount: 130, Added: 10}})
+	if len(deliveries.deliveries) != 2 {
+		t.Errorf("deliveries = %d, want 2", len(deliveries.deliveries))
+	}
+
+	// Анонимные ссылки не отслеживаются
+	svc.ClickCountsUpdated(context.Background(), []models.URLClickCount{{URLID: 2, ShortCode: "anon", ClicksCount: 5000, Added: 5000}})
+	if len(deliveries.deliveries) != 2 {
+		t.Errorf("deliveries = %d, want 2", len(deliveries.deliveries))
+	}
+}
+
+// TestReplayDelivery проверяет повторную постановку события с тем же event id
+func TestReplayDelivery(t *testing.T) {
+	repo := newMockWebhookRepository()
+	deliveries := newMockWebhookDeliveryRepository()
+	svc := NewWebhookService(repo, deliveries)
+
+	repo.Create(context.Background(), &models.Webhook{UserID: testUserID, Events: []string{models.WebhookEventLinkCreated}, Active: true}) // nolint:errcheck
+	svc.Notify(context.Background(), testUserID, models.WebhookEventLinkCreated, nil)
+
+	original := deliveries.deliveries[0]
+	replay, err := svc.ReplayDelivery(context.Background(), testUserID, 1, original.ID)
+	if err != nil {
+		t.Fatalf("ReplayDelivery() error = %v", err)
+	}
+	if replay.ID == original.ID || replay.EventID != original.EventID || replay.Payload != original.Payload {
+		t.Errorf("replay = %+v, want copy of %+v", replay, original)
+	}
+
+	// Чужой webhook выглядит как несуществующий
+	if _, err := svc.ReplayDelivery(context.Background(), 2, 1, original.ID); err == nil || !strings.Contains(err.Error(), "не найден") {
+		t.Errorf("ReplayDelivery() other user error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS fk_urls_user_id;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,3 @@
+ALTER TABLE analytics DROP COLUMN IF EXISTS device_type;
+ALTER TABLE analytics DROP COLUMN IF EXISTS os;
+ALTER TABLE analytics DROP COLUMN IF EXISTS browser;
@@ -0,0 +1,4 @@
+-- Разобранный User-Agent: семейство браузера, ОС и класс устройства
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS browser VARCHAR(50);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS os VARCHAR(50);
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS device_type VARCHAR(20);
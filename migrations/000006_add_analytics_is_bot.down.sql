@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_analytics_url_id_human;
+ALTER TABLE analytics DROP COLUMN IF EXISTS is_bot;
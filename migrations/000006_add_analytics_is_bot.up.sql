@@ -0,0 +1,5 @@
+-- Клики ботов (превью ссылок, краулеры, сканеры) хранятся, но не входят в основные счетчики
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Индекс для статистики только по людям
+CREATE INDEX IF NOT EXISTS idx_analytics_url_id_human ON analytics(url_id, clicked_at DESC) WHERE NOT is_bot;
@@ -0,0 +1,2 @@
+-- bcrypt хеш пароля ссылки, NULL - ссылка открывается без пароля
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255);
@@ -0,0 +1,5 @@
+-- Лимит переходов по ссылке, NULL - без ограничения.
+-- used_clicks считается синхронно при редиректе, в отличие от clicks_count,
+-- который обновляется пачками и не учитывает ботов
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks BIGINT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS used_clicks BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Время активации ссылки, до него редирект не работает. NULL - ссылка активна сразу
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP;
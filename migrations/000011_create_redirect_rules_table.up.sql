@@ -0,0 +1,13 @@
+-- Правила редиректа ссылки: посетители из стран countries перенаправляются на target_url.
+-- Правила проверяются по возрастанию priority, первое совпавшее побеждает
+CREATE TABLE IF NOT EXISTS redirect_rules (
+    id BIGSERIAL PRIMARY KEY,
+    url_id BIGINT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    countries TEXT[] NOT NULL DEFAULT '{}',
+    target_url TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_redirect_rules_url_id ON redirect_rules(url_id, priority, id);
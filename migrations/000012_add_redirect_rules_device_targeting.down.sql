@@ -0,0 +1,3 @@
+ALTER TABLE redirect_rules DROP COLUMN IF EXISTS deep_link;
+ALTER TABLE redirect_rules DROP COLUMN IF EXISTS devices;
+ALTER TABLE redirect_rules DROP COLUMN IF EXISTS os;
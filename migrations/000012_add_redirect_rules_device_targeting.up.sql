@@ -0,0 +1,5 @@
+-- Условия правила по ОС и классу устройства посетителя (пустой список - любые).
+-- deep_link - адрес приложения (custom scheme), который пробуется до перехода на target_url
+ALTER TABLE redirect_rules ADD COLUMN IF NOT EXISTS os TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE redirect_rules ADD COLUMN IF NOT EXISTS devices TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE redirect_rules ADD COLUMN IF NOT EXISTS deep_link TEXT NOT NULL DEFAULT '';
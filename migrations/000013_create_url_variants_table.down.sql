@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_analytics_url_variant;
+ALTER TABLE analytics DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE urls DROP COLUMN IF EXISTS sticky_variants;
+DROP TABLE IF EXISTS url_variants;
@@ -0,0 +1,21 @@
+-- Варианты перехода ссылки для A/B теста: посетитель попадает на target_url
+-- с вероятностью weight / сумма весов. Вариант с weight = 0 на паузе
+CREATE TABLE IF NOT EXISTS url_variants (
+    id BIGSERIAL PRIMARY KEY,
+    url_id BIGINT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    target_url TEXT NOT NULL,
+    weight INTEGER NOT NULL DEFAULT 1 CHECK (weight >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_url_variants_url_id ON url_variants(url_id, id);
+
+-- sticky_variants закрепляет выбранный вариант за посетителем через cookie
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS sticky_variants BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Вариант, на который ушел клик. Без внешнего ключа, чтобы удаление варианта не теряло историю
+ALTER TABLE analytics ADD COLUMN IF NOT EXISTS variant_id BIGINT;
+
+CREATE INDEX idx_analytics_url_variant ON analytics(url_id, variant_id) WHERE variant_id IS NOT NULL;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_urls_domain_trgm;
+DROP INDEX IF EXISTS idx_urls_search_trgm;
+DROP INDEX IF EXISTS idx_urls_user_folder;
+DROP TABLE IF EXISTS url_tags;
+DROP TABLE IF EXISTS tags;
+ALTER TABLE urls DROP COLUMN IF EXISTS folder;
+ALTER TABLE urls DROP COLUMN IF EXISTS notes;
+ALTER TABLE urls DROP COLUMN IF EXISTS title;
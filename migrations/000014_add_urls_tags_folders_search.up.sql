@@ -0,0 +1,32 @@
+-- Триграммы для поиска подстроки в адресах, кодах, названиях и заметках ссылок
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Название, заметки и папка ссылки (пустая строка - не задано)
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS title TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS folder VARCHAR(100) NOT NULL DEFAULT '';
+
+-- Теги пользователя. Имена хранятся в нижнем регистре
+CREATE TABLE IF NOT EXISTS tags (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS url_tags (
+    url_id BIGINT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (url_id, tag_id)
+);
+
+CREATE INDEX idx_url_tags_tag_id ON url_tags(tag_id);
+
+CREATE INDEX idx_urls_user_folder ON urls(user_id, folder) WHERE folder <> '';
+
+-- Выражения индексов должны совпадать с urlSearchExpr и urlDomainExpr в url_repository.go
+CREATE INDEX idx_urls_search_trgm ON urls
+    USING GIN (lower(original_url || ' ' || short_code || ' ' || title || ' ' || notes) gin_trgm_ops);
+CREATE INDEX idx_urls_domain_trgm ON urls
+    USING GIN (lower(split_part(split_part(original_url, '://', 2), '/', 1)) gin_trgm_ops);
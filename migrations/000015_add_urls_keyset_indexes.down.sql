@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_urls_user_last_clicked_id;
+DROP INDEX IF EXISTS idx_urls_user_clicks_id;
+DROP INDEX IF EXISTS idx_urls_user_created_id;
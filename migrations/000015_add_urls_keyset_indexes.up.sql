@@ -0,0 +1,7 @@
+-- Индексы keyset-пагинации списка ссылок: (поле сортировки, id) в пределах пользователя.
+-- Обратный порядок обслуживается тем же индексом при обратном сканировании.
+-- Выражение last_clicked_at должно совпадать с urlSortKeys в url_repository.go
+CREATE INDEX idx_urls_user_created_id ON urls(user_id, created_at, id) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_urls_user_clicks_id ON urls(user_id, clicks_count, id) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_urls_user_last_clicked_id
+    ON urls(user_id, COALESCE(last_clicked_at, '1970-01-01 00:00:00'::timestamp), id) WHERE user_id IS NOT NULL;
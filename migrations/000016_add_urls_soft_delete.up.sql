@@ -0,0 +1,13 @@
+-- Мягкое удаление: ссылка попадает в корзину, аналитика сохраняется до окончательного удаления
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX idx_urls_deleted_at ON urls(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Коды окончательно удаленных ссылок в карантине: до available_at код нельзя занять заново,
+-- чтобы старые напечатанные ссылки не вели на чужой адрес
+CREATE TABLE IF NOT EXISTS retired_codes (
+    short_code VARCHAR(10) PRIMARY KEY,
+    available_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_retired_codes_available_at ON retired_codes(available_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_urls_archived_at;
+ALTER TABLE urls DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,4 @@
+-- Архив давно истекших ссылок: ссылка скрыта из обычного списка, код и аналитика сохраняются
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+CREATE INDEX idx_urls_archived_at ON urls(user_id, archived_at) WHERE archived_at IS NOT NULL;
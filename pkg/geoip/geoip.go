@@ -0,0 +1,86 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location результат геолокации IP адреса
+type Location struct {
+	Country string // ISO 3166-1 alpha-2 код страны
+	City    string
+}
+
+// Locator интерфейс геолокации IP адресов
+type Locator interface {
+	Lookup(ip string) (Location, error)
+	Close() error
+}
+
+// noopLocator ничего не определяет (GeoIP база не настроена)
+type noopLocator struct{}
+
+// NewNoopLocator создает локатор-заглушку
+func NewNoopLocator() Locator {
+	return noopLocator{}
+}
+
+// Lookup всегда возвращает пустую локацию
+func (noopLocator) Lookup(ip string) (Location, error) {
+	return Location{}, nil
+}
+
+// Close ничего не делает
+func (noopLocator) Close() error {
+	return nil
+}
+
+// mmdbRecord нужные поля записи GeoLite2/GeoIP2 Country и City баз
+type mmdbRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// maxMindLocator геолокация по локальному .mmdb файлу (без сетевых запросов)
+type maxMindLocator struct {
+	reader *maxminddb.Reader
+}
+
+// OpenMaxMind открывает базу в формате MaxMind DB (GeoLite2-City, GeoLite2-Country и т.п.)
+func OpenMaxMind(path string) (Locator, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия GeoIP базы %s: %w", path, err)
+	}
+	return &maxMindLocator{reader: reader}, nil
+}
+
+// Lookup определяет страну и город IP адреса.
+// Для адресов, которых нет в базе, возвращается пустая локация
+func (l *maxMindLocator) Lookup(ip string) (Location, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Location{}, fmt.Errorf("невалидный IP адрес: %s", ip)
+	}
+
+	var record mmdbRecord
+	if err := l.reader.Lookup(parsed, &record); err != nil {
+		return Location{}, fmt.Errorf("ошибка поиска IP в GeoIP базе: %w", err)
+	}
+
+	return Location{
+		Country: record.Country.ISOCode,
+		City:    record.City.Names["en"],
+	}, nil
+}
+
+// Close закрывает базу
+func (l *maxMindLocator) Close() error {
+	return l.reader.Close()
+}
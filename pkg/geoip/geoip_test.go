@@ -0,0 +1,27 @@
+package geoip
+
+import (
+	"testing"
+)
+
+// TestNoopLocator проверяет локатор-заглушку
+func TestNoopLocator(t *testing.T) {
+	locator := NewNoopLocator()
+
+	location, err := locator.Lookup("8.8.8.8")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	if location.Country != "" || location.City != "" {
+		t.Errorf("Lookup() = %+v, want empty location", location)
+	}
+}
+
+// TestOpenMaxMind_MissingFile проверяет ошибку при отсутствии файла базы
+func TestOpenMaxMind_MissingFile(t *testing.T) {
+	_, err := OpenMaxMind("testdata/missing.mmdb")
+	if err == nil {
+		t.Error("OpenMaxMind() should return error for missing file")
+	}
+}
@@ -0,0 +1,264 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // логотип может быть в JPEG
+	"image/png"
+	"os"
+	"strings"
+
+	goqrcode "github.com/skip2/go-qrcode"
+)
+
+// Форматы изображения
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// Уровни коррекции ошибок (доля восстанавливаемых данных)
+const (
+	LevelLow      = "L" // ~7%
+	LevelMedium   = "M" // ~15%
+	LevelQuartile = "Q" // ~25%
+	LevelHighest  = "H" // ~30%
+)
+
+// Ограничения размера изображения в пикселях
+const (
+	MinSize     = 64
+	MaxSize     = 2048
+	DefaultSize = 256
+)
+
+// logoScale доля стороны кода, которую занимает логотип. Закрытая площадь
+// (~5%) заведомо меньше запаса коррекции уровня H
+const logoScale = 0.22
+
+// Options параметры изображения
+type Options struct {
+	Format     string
+	Size       int
+	Level      string
+	Foreground color.RGBA
+	Background color.RGBA
+	// Logo рисуется по центру. С логотипом всегда используется уровень H
+	Logo image.Image
+}
+
+// DefaultOptions черный код на белом фоне в PNG
+func DefaultOptions() Options {
+	return Options{
+		Format:     FormatPNG,
+		Size:       DefaultSize,
+		Level:      LevelMedium,
+		Foreground: color.RGBA{A: 0xff},
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// Validate проверяет параметры
+func (o *Options) Validate() error {
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return fmt.Errorf("невалидный формат: %s (ожидается png или svg)", o.Format)
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("невалидный размер: от %d до %d пикселей", MinSize, MaxSize)
+	}
+	if _, ok := recoveryLevel(o.Level); !ok {
+		return fmt.Errorf("невалидный уровень коррекции: %s (ожидается L, M, Q или H)", o.Level)
+	}
+	if o.Foreground == o.Background {
+		return fmt.Errorf("невалидные цвета: цвет кода совпадает с фоном")
+	}
+	return nil
+}
+
+// ContentType возвращает MIME тип изображения
+func (o *Options) ContentType() string {
+	if o.Format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Encode рисует QR код с содержимым content
+func Encode(content string, opts Options) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	level, _ := recoveryLevel(opts.Level)
+	if opts.Logo != nil {
+		level = goqrcode.Highest
+	}
+
+	code, err := goqrcode.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка кодирования QR: %w", err)
+	}
+	code.ForegroundColor = opts.Foreground
+	code.BackgroundColor = opts.Background
+
+	if opts.Format == FormatSVG {
+		return renderSVG(code.Bitmap(), opts)
+	}
+	return renderPNG(code, opts)
+}
+
+// ParseColor разбирает цвет в формате RRGGBB или #RRGGBB
+func ParseColor(value string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("невалидный цвет: %s (ожидается RRGGBB)", value)
+	}
+
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(strings.ToLower(hex), "%02x%02x%02x", &r, &g, &b); err != nil {
+		return color.RGBA{}, fmt.Errorf("невалидный цвет: %s (ожидается RRGGBB)", value)
+	}
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}, nil
+}
+
+// LoadLogo загружает логотип из PNG или JPEG файла
+func LoadLogo(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия логотипа: %w", err)
+	}
+	defer file.Close()
+
+	logo, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения логотипа: %w", err)
+	}
+	return logo, nil
+}
+
+// recoveryLevel переводит уровень коррекции в значение библиотеки
+func recoveryLevel(level string) (goqrcode.RecoveryLevel, bool) {
+	switch strings.ToUpper(level) {
+	case LevelLow:
+		return goqrcode.Low, true
+	case LevelMedium:
+		return goqrcode.Medium, true
+	case LevelQuartile:
+		return goqrcode.High, true
+	case LevelHighest:
+		return goqrcode.Highest, true
+	}
+	return 0, false
+}
+
+// renderPNG рисует PNG и накладывает логотип
+func renderPNG(code *goqrcode.QRCode, opts Options) ([]byte, error) {
+	img := code.Image(opts.Size)
+
+	if opts.Logo != nil {
+		canvas := image.NewRGBA(img.Bounds())
+		draw.Draw(canvas, canvas.Bounds(), img, image.Point{}, draw.Src)
+		drawLogo(canvas, centeredSquare(canvas.Bounds(), logoScale), opts.Logo, opts.Background)
+		img = canvas
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("ошибка кодирования PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderSVG рисует SVG: каждый ряд темных модулей одним path, логотип вставляется как PNG
+func renderSVG(bitmap [][]bool, opts Options) ([]byte, error) {
+	modules := len(bitmap)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, modules, modules, hexColor(opts.Background))
+
+	buf.WriteString(`<path fill="` + hexColor(opts.Foreground) + `" d="`)
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/>`)
+
+	if opts.Logo != nil {
+		logoSize := int(float64(opts.Size) * logoScale)
+		logo := image.NewRGBA(image.Rect(0, 0, logoSize, logoSize))
+		drawLogo(logo, logo.Bounds(), opts.Logo, opts.Background)
+
+		var logoPNG bytes.Buffer
+		if err := png.Encode(&logoPNG, logo); err != nil {
+			return nil, fmt.Errorf("ошибка кодирования логотипа: %w", err)
+		}
+
+		side := float64(modules) * logoScale
+		offset := (float64(modules) - side) / 2
+		fmt.Fprintf(&buf, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" href="data:image/png;base64,%s"/>`,
+			offset, offset, side, side, base64.StdEncoding.EncodeToString(logoPNG.Bytes()))
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+// centeredSquare квадрат со стороной scale от bounds по центру
+func centeredSquare(bounds image.Rectangle, scale float64) image.Rectangle {
+	side := int(float64(bounds.Dx()) * scale)
+	origin := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+	return image.Rectangle{Min: origin, Max: origin.Add(image.Pt(side, side))}
+}
+
+// drawLogo рисует логотип в area на подложке цвета фона. Масштабирование
+// ближайшим соседом, пропорции логотипа сохраняются
+func drawLogo(canvas *image.RGBA, area image.Rectangle, logo image.Image, background color.RGBA) {
+	// Подложка закрывает модули под логотипом, чтобы он читался
+	draw.Draw(canvas, area, &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	src := logo.Bounds()
+	if src.Empty() {
+		return
+	}
+
+	// Небольшой отступ от краев подложки
+	inner := area.Dx() * 9 / 10
+	width, height := inner, inner
+	if src.Dx() > src.Dy() {
+		height = inner * src.Dy() / src.Dx()
+	} else {
+		width = inner * src.Dx() / src.Dy()
+	}
+	if width == 0 || height == 0 {
+		return
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			scaled.Set(x, y, logo.At(src.Min.X+x*src.Dx()/width, src.Min.Y+y*src.Dy()/height))
+		}
+	}
+
+	at := image.Pt(area.Min.X+(area.Dx()-width)/2, area.Min.Y+(area.Dy()-height)/2)
+	draw.Draw(canvas, image.Rectangle{Min: at, Max: at.Add(image.Pt(width, height))}, scaled, image.Point{}, draw.Over)
+}
+
+// hexColor форматирует цвет как #rrggbb
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
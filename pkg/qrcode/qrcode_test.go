@@ -0,0 +1,160 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// TestEncode_PNG проверяет размер и цвета PNG
+func TestEncode_PNG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Size = 300
+	opts.Foreground = color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}
+
+	data, err := Encode("https://sho.rt/abc123", opts)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if img.Bounds().Dx() != 300 || img.Bounds().Dy() != 300 {
+		t.Errorf("size = %v, want 300x300", img.Bounds())
+	}
+
+	// Угол - рамка (quiet zone) цвета фона
+	if r, g, b, _ := img.At(0, 0).RGBA(); r>>8 != 0xff || g>>8 != 0xff || b>>8 != 0xff {
+		t.Errorf("corner color = %v, want background", img.At(0, 0))
+	}
+	if !hasColor(img, opts.Foreground) {
+		t.Error("image does not contain foreground color")
+	}
+}
+
+// TestEncode_SVG проверяет разметку SVG
+func TestEncode_SVG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Format = FormatSVG
+	opts.Background = color.RGBA{R: 0xff, G: 0xee, B: 0xdd, A: 0xff}
+
+	data, err := Encode("https://sho.rt/abc123", opts)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	svg := string(data)
+	for _, want := range []string{`<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256"`, `fill="#ffeedd"`, `fill="#000000" d="M`, `</svg>`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG does not contain %q", want)
+		}
+	}
+	if strings.Contains(svg, "<image") {
+		t.Error("SVG without logo contains image")
+	}
+}
+
+// TestEncode_Logo проверяет наложение логотипа по центру
+func TestEncode_Logo(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	logo := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			logo.Set(x, y, red)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.Logo = logo
+
+	data, err := Encode("https://sho.rt/abc123", opts)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if r, g, b, _ := img.At(128, 128).RGBA(); r>>8 != 0xff || g != 0 || b != 0 {
+		t.Errorf("center color = %v, want logo color", img.At(128, 128))
+	}
+
+	opts.Format = FormatSVG
+	data, err = Encode("https://sho.rt/abc123", opts)
+	if err != nil {
+		t.Fatalf("Encode() SVG error = %v", err)
+	}
+	if !strings.Contains(string(data), `href="data:image/png;base64,`) {
+		t.Error("SVG does not contain embedded logo")
+	}
+}
+
+// TestOptions_Validate проверяет ограничения параметров
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{"format", func(o *Options) { o.Format = "gif" }},
+		{"too small", func(o *Options) { o.Size = MinSize - 1 }},
+		{"too large", func(o *Options) { o.Size = MaxSize + 1 }},
+		{"level", func(o *Options) { o.Level = "X" }},
+		{"same colors", func(o *Options) { o.Background = o.Foreground }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			if err := opts.Validate(); err == nil {
+				t.Error("Validate() error = nil, want error")
+			}
+		})
+	}
+
+	opts := DefaultOptions()
+	opts.Level = "q"
+	if err := opts.Validate(); err != nil {
+		t.Errorf("Validate() lowercase level error = %v", err)
+	}
+}
+
+// TestParseColor проверяет разбор цветов
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{"#1a2B3c", color.RGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}, false},
+		{"ffffff", color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, false},
+		{"fff", color.RGBA{}, true},
+		{"zzzzzz", color.RGBA{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseColor(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, %v, want %v (error %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// hasColor проверяет, что в изображении есть пиксель цвета c
+func hasColor(img image.Image, c color.RGBA) bool {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if uint8(r>>8) == c.R && uint8(g>>8) == c.G && uint8(b>>8) == c.B {
+				return true
+			}
+		}
+	}
+	return false
+}
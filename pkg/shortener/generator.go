@@ -4,11 +4,26 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"strings"
 )
 
 // Base62 алфавит для генерации коротких кодов
 const base62Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 
+// MaxShortCodeLength максимальная длина короткого кода (колонка urls.short_code VARCHAR(10))
+const MaxShortCodeLength = 10
+
+// reservedCodes коды, совпадающие с маршрутами верхнего уровня.
+// Такие коды нельзя использовать, иначе ссылка /{code} перекроется служебным маршрутом
+var reservedCodes = map[string]bool{
+	"api":    true,
+	"static": true,
+	"links":  true,
+	"login":  true,
+	"health": true,
+	"qr":     true,
+}
+
 // Generator интерфейс для генерации коротких кодов
 type Generator interface {
 	Generate(length int) (string, error)
@@ -83,7 +98,7 @@ func DecodeToID(encoded string) int64 {
 
 // IsValidShortCode проверяет, что короткий код содержит только допустимые символы
 func IsValidShortCode(code string) bool {
-	if len(code) == 0 || len(code) > 50 {
+	if len(code) == 0 || len(code) > MaxShortCodeLength {
 		return false
 	}
 
@@ -104,3 +119,8 @@ func IsValidShortCode(code string) bool {
 
 	return true
 }
+
+// IsReservedCode проверяет, что код совпадает с зарезервированным маршрутом
+func IsReservedCode(code string) bool {
+	return reservedCodes[strings.ToLower(code)]
+}
@@ -118,6 +118,8 @@ func TestIsValidShortCode(t *testing.T) {
 		{"valid uppercase", "ABC123", true},
 		{"valid mixed case", "AbC123", true},
 		{"empty string", "", false},
+		{"max length", "abcde12345", true},
+		{"longer than column", "abcde123456", false},
 		{"too long", "a123456789012345678901234567890123456789012345678901", false},
 		{"with space", "abc 123", false},
 		{"with special chars", "abc@123", false},
@@ -135,6 +137,32 @@ func TestIsValidShortCode(t *testing.T) {
 	}
 }
 
+// TestIsReservedCode проверяет зарезервированные коды
+func TestIsReservedCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		reserved bool
+	}{
+		{"api", "api", true},
+		{"static", "static", true},
+		{"links uppercase", "LINKS", true},
+		{"health", "health", true},
+		{"qr", "qr", true},
+		{"regular code", "abc123", false},
+		{"prefix of reserved", "apis", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IsReservedCode(tt.code)
+			if result != tt.reserved {
+				t.Errorf("IsReservedCode(%q) = %v, want %v", tt.code, result, tt.reserved)
+			}
+		})
+	}
+}
+
 // TestGenerateZeroLength проверяет обработку нулевой длины
 func TestGenerateZeroLength(t *testing.T) {
 	generator := NewGenerator()
@@ -0,0 +1,114 @@
+package useragent
+
+import (
+	"net/http"
+	"strings"
+)
+
+// botSignatures подстроки User-Agent ботов (в нижнем регистре): превью мессенджеров
+// и соцсетей, поисковые краулеры, сканеры безопасности и HTTP клиенты.
+// Новые сигнатуры добавляются сюда
+var botSignatures = []string{
+	// Превью ссылок
+	"slackbot",
+	"slack-imgproxy",
+	"telegrambot",
+	"facebookexternalhit",
+	"facebookcatalog",
+	"twitterbot",
+	"linkedinbot",
+	"discordbot",
+	"whatsapp",
+	"skypeuripreview",
+	"vkshare",
+	"pinterestbot",
+	"redditbot",
+	"embedly",
+	"iframely",
+	"bitlybot",
+	"applebot",
+	"outlook",
+	"microsoft office",
+	"google-pagerenderer",
+
+	// Поисковые краулеры
+	"googlebot",
+	"bingbot",
+	"yandexbot",
+	"duckduckbot",
+	"baiduspider",
+	"petalbot",
+	"semrushbot",
+	"ahrefsbot",
+	"mj12bot",
+	"dotbot",
+
+	// Сканеры безопасности и мониторинг
+	"safebrowsing",
+	"barracuda",
+	"proofpoint",
+	"mimecast",
+	"virustotal",
+	"urlscan",
+	"censys",
+	"zgrab",
+	"masscan",
+	"nmap",
+	"nuclei",
+	"uptimerobot",
+	"pingdom",
+
+	// HTTP клиенты и headless браузеры
+	"curl/",
+	"wget/",
+	"python-requests",
+	"python-urllib",
+	"aiohttp",
+	"go-http-client",
+	"okhttp",
+	"java/",
+	"libwww-perl",
+	"axios/",
+	"node-fetch",
+	"headlesschrome",
+	"phantomjs",
+
+	// Общие признаки
+	"bot",
+	"crawler",
+	"spider",
+	"scraper",
+	"preview",
+}
+
+// IsBot проверяет User-Agent по списку сигнатур. Пустой User-Agent считается ботом:
+// браузеры всегда его отправляют
+func IsBot(ua string) bool {
+	lower := strings.ToLower(strings.TrimSpace(ua))
+	if lower == "" {
+		return true
+	}
+
+	for _, signature := range botSignatures {
+		if strings.Contains(lower, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPreviewRequest проверяет признаки автоматического запроса, не видимые в User-Agent:
+// HEAD запросы (проверка ссылок) и заголовки предзагрузки/превью
+func IsPreviewRequest(method string, header http.Header) bool {
+	if method == http.MethodHead {
+		return true
+	}
+
+	for _, name := range []string{"Purpose", "Sec-Purpose", "X-Purpose", "X-Moz"} {
+		value := strings.ToLower(header.Get(name))
+		if strings.Contains(value, "prefetch") || strings.Contains(value, "preview") {
+			return true
+		}
+	}
+	return false
+}
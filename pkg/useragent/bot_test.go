@@ -0,0 +1,54 @@
+package useragent
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestIsBot проверяет определение ботов по User-Agent
+func TestIsBot(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want bool
+	}{
+		{"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"TelegramBot (like TwitterBot)", true},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"Twitterbot/1.0", true},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", true},
+		{"curl/8.4.0", true},
+		{"", true},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", false},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsBot(tt.ua); got != tt.want {
+			t.Errorf("IsBot(%q) = %v, want %v", tt.ua, got, tt.want)
+		}
+	}
+}
+
+// TestIsPreviewRequest проверяет эвристики по методу и заголовкам
+func TestIsPreviewRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		want   bool
+	}{
+		{"GET", http.MethodGet, http.Header{}, false},
+		{"HEAD", http.MethodHead, http.Header{}, true},
+		{"Sec-Purpose prefetch", http.MethodGet, http.Header{"Sec-Purpose": {"prefetch;prerender"}}, true},
+		{"X-Purpose preview", http.MethodGet, http.Header{"X-Purpose": {"preview"}}, true},
+		{"X-Moz prefetch", http.MethodGet, http.Header{"X-Moz": {"prefetch"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPreviewRequest(tt.method, tt.header); got != tt.want {
+				t.Errorf("IsPreviewRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package useragent
+
+import "strings"
+
+// Классы устройств
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceOther   = "other"
+)
+
+// Other значение для неопознанного браузера или ОС
+const Other = "Other"
+
+// Info результат разбора User-Agent
+type Info struct {
+	Browser string
+	OS      string
+	Device  string
+}
+
+// signature сопоставляет подстроку User-Agent с названием семейства.
+// Порядок важен: более специфичные сигнатуры проверяются раньше
+type signature struct {
+	token  string
+	family string
+}
+
+// browserSignatures сигнатуры браузеров (в нижнем регистре).
+// Chromium-браузеры (Edge, Opera, Samsung, Yandex) содержат "chrome/", поэтому идут раньше Chrome,
+// а Chrome содержит "safari/", поэтому идет раньше Safari
+var browserSignatures = []signature{
+	{"edg/", "Edge"},
+	{"edge/", "Edge"},
+	{"edgios/", "Edge"},
+	{"edga/", "Edge"},
+	{"opr/", "Opera"},
+	{"opera", "Opera"},
+	{"samsungbrowser/", "Samsung Internet"},
+	{"yabrowser/", "Yandex Browser"},
+	{"fxios/", "Firefox"},
+	{"firefox/", "Firefox"},
+	{"crios/", "Chrome"},
+	{"chrome/", "Chrome"},
+	{"chromium/", "Chrome"},
+	{"msie ", "Internet Explorer"},
+	{"trident/", "Internet Explorer"},
+	{"safari/", "Safari"},
+}
+
+// osSignatures сигнатуры операционных систем (в нижнем регистре).
+// Android и iOS идут раньше Linux и macOS, так как их User-Agent содержит эти токены
+var osSignatures = []signature{
+	{"windows phone", "Windows Phone"},
+	{"android", "Android"},
+	{"iphone", "iOS"},
+	{"ipad", "iOS"},
+	{"ipod", "iOS"},
+	{"cros", "Chrome OS"},
+	{"windows", "Windows"},
+	{"mac os x", "macOS"},
+	{"macintosh", "macOS"},
+	{"linux", "Linux"},
+}
+
+// Devices все классы устройств
+var Devices = []string{DeviceDesktop, DeviceMobile, DeviceTablet, DeviceOther}
+
+// OSFamilies все семейства ОС, которые возвращает Parse
+var OSFamilies = []string{"iOS", "Android", "Windows", "macOS", "Linux", "Chrome OS", "Windows Phone", Other}
+
+// CanonicalOS приводит название ОС к виду из OSFamilies без учета регистра
+func CanonicalOS(name string) (string, bool) {
+	for _, family := range OSFamilies {
+		if strings.EqualFold(family, name) {
+			return family, true
+		}
+	}
+	return "", false
+}
+
+// Parse определяет семейство браузера, ОС и класс устройства
+func Parse(ua string) Info {
+	lower := strings.ToLower(ua)
+
+	info := Info{
+		Browser: match(lower, browserSignatures),
+		OS:      match(lower, osSignatures),
+	}
+	info.Device = deviceClass(lower, info.OS)
+
+	return info
+}
+
+// match возвращает первое совпавшее семейство
+func match(lower string, signatures []signature) string {
+	for _, s := range signatures {
+		if strings.Contains(lower, s.token) {
+			return s.family
+		}
+	}
+	return Other
+}
+
+// deviceClass определяет класс устройства
+func deviceClass(lower, os string) string {
+	switch {
+	case strings.Contains(lower, "ipad"),
+		strings.Contains(lower, "tablet"),
+		os == "Android" && !strings.Contains(lower, "mobile"):
+		return DeviceTablet
+	case strings.Contains(lower, "mobi"),
+		strings.Contains(lower, "iphone"),
+		strings.Contains(lower, "ipod"),
+		os == "Windows Phone":
+		return DeviceMobile
+	case os == "Windows", os == "macOS", os == "Linux", os == "Chrome OS":
+		return DeviceDesktop
+	default:
+		return DeviceOther
+	}
+}
@@ -0,0 +1,108 @@
+package useragent
+
+import (
+	"testing"
+)
+
+// TestParse проверяет разбор популярных User-Agent
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want Info
+	}{
+		{
+			"chrome windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Info{"Chrome", "Windows", DeviceDesktop},
+		},
+		{
+			"edge windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			Info{"Edge", "Windows", DeviceDesktop},
+		},
+		{
+			"safari macos",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			Info{"Safari", "macOS", DeviceDesktop},
+		},
+		{
+			"firefox linux",
+			"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			Info{"Firefox", "Linux", DeviceDesktop},
+		},
+		{
+			"safari iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			Info{"Safari", "iOS", DeviceMobile},
+		},
+		{
+			"chrome iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			Info{"Chrome", "iOS", DeviceMobile},
+		},
+		{
+			"safari ipad",
+			"Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			Info{"Safari", "iOS", DeviceTablet},
+		},
+		{
+			"chrome android phone",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			Info{"Chrome", "Android", DeviceMobile},
+		},
+		{
+			"samsung android tablet",
+			"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Safari/537.36",
+			Info{"Samsung Internet", "Android", DeviceTablet},
+		},
+		{
+			"empty",
+			"",
+			Info{Other, Other, DeviceOther},
+		},
+		{
+			"curl",
+			"curl/8.4.0",
+			Info{Other, Other, DeviceOther},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.ua)
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCanonicalOS проверяет нормализацию названий ОС
+func TestCanonicalOS(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"iOS", "iOS", true},
+		{"ios", "iOS", true},
+		{"ANDROID", "Android", true},
+		{"chrome os", "Chrome OS", true},
+		{"symbian", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := CanonicalOS(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("CanonicalOS(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	// Каждое семейство, которое возвращает Parse, должно проходить нормализацию
+	for _, family := range OSFamilies {
+		if got, ok := CanonicalOS(family); !ok || got != family {
+			t.Errorf("CanonicalOS(%q) = %q, %v", family, got, ok)
+		}
+	}
+}
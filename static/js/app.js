@@ -9,6 +9,8 @@ form.addEventListener('submit', async (e) => {
 
     const originalUrl = document.getElementById('originalUrl').value;
     const customCode = document.getElementById('customCode').value;
+    const password = document.getElementById('linkPassword').value;
+    const maxClicks = document.getElementById('maxClicks').value;
 
     // Скрываем предыдущие результаты
     result.classList.remove('show');
@@ -24,6 +26,12 @@ form.addEventListener('submit', async (e) => {
         if (customCode) {
             payload.custom_code = customCode;
         }
+        if (password) {
+            payload.password = password;
+        }
+        if (maxClicks) {
+            payload.max_clicks = parseInt(maxClicks, 10);
+        }
 
         const response = await fetch('/api/v1/urls', {
             method: 'POST',
@@ -33,6 +41,12 @@ form.addEventListener('submit', async (e) => {
             body: JSON.stringify(payload)
         });
 
+        // Анонимное создание ссылок может быть запрещено
+        if (response.status === 401) {
+            window.location.href = '/login?next=/';
+            return;
+        }
+
         const data = await response.json();
 
         if (!response.ok) {
@@ -45,6 +59,7 @@ form.addEventListener('submit', async (e) => {
         document.getElementById('urlCode').textContent = data.short_code;
         document.getElementById('urlCreated').textContent = new Date(data.created_at).toLocaleString('ru-RU');
         document.getElementById('urlClicks').textContent = data.clicks_count;
+        showQRCode(data.short_code);
         result.classList.add('show');
 
         // Очищаем форму
@@ -80,3 +95,11 @@ async function copyToClipboard() {
         btn.textContent = '✓ Скопировано';
     }
 }
+
+// Показывает QR код ссылки и ссылки на скачивание для печати
+function showQRCode(shortCode) {
+    const base = '/qr/' + encodeURIComponent(shortCode);
+    document.getElementById('qrImage').src = base + '?size=400';
+    document.getElementById('qrDownloadPng').href = base + '?size=1024&download=true';
+    document.getElementById('qrDownloadSvg').href = base + '?format=svg&size=1024&download=true';
+}
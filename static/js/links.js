@@ -1,6 +1,19 @@
 let currentPage = 0;
 const pageSize = 20;
 
+// Курсоры открытых страниц: cursors[i] - начало i-й страницы, '' - первая страница
+let cursors = [''];
+let nextCursor = '';
+// Всего ссылок по текущим фильтрам (запрашивается вместе с первой страницей)
+let totalCount = 0;
+
+// Активные фильтры списка (query параметры /api/v1/urls)
+let filters = new URLSearchParams();
+
+// Открытый поток кликов (одна ссылка за раз)
+let liveSource = null;
+let liveButton = null;
+
 async function loadURLs() {
     const loading = document.getElementById('loading');
     const error = document.getElementById('error');
@@ -14,25 +27,45 @@ async function loadURLs() {
     emptyState.style.display = 'none';
 
     try {
-        const offset = currentPage * pageSize;
-        const response = await fetch(`/api/v1/urls?limit=${pageSize}&offset=${offset}`);
+        const params = new URLSearchParams(filters);
+        params.set('limit', pageSize);
+        params.set('cursor', cursors[currentPage]);
+        if (currentPage === 0) {
+            params.set('include_total', 'true');
+        }
+        const response = await fetch(`/api/v1/urls?${params}`);
+
+        // Список ссылок доступен только после входа
+        if (response.status === 401) {
+            window.location.href = '/login?next=/links';
+            return;
+        }
 
         if (!response.ok) {
-            throw new Error('Ошибка загрузки данных');
+            const body = await response.json().catch(() => ({}));
+            throw new Error(body.error || 'Ошибка загрузки данных');
         }
 
-        const urls = await response.json();
+        const page = await response.json();
+        const urls = page.items;
+        nextCursor = page.next_cursor || '';
+        if (page.total_count !== undefined) {
+            totalCount = page.total_count;
+        }
 
         loading.style.display = 'none';
 
         if (!urls || urls.length === 0) {
             if (currentPage === 0) {
-                emptyState.style.display = 'block';
+                stopLive();
+                tableBody.innerHTML = '';
+                showEmptyState();
             }
             nextBtn.disabled = true;
             return;
         }
 
+        stopLive();
         tableBody.innerHTML = '';
 
         urls.forEach(url => {
@@ -40,13 +73,25 @@ async function loadURLs() {
 
             const createdDate = new Date(url.created_at).toLocaleString('ru-RU');
 
+            const title = url.title ? `<div class="link-title">${escapeHTML(url.title)}</div>` : '';
+            const folder = url.folder ? `<span class="folder-badge">📁 ${escapeHTML(url.folder)}</span>` : '';
+            const tags = (url.tags || [])
+                .map(tag => `<span class="tag-badge" data-tag="${escapeHTML(tag)}">#${escapeHTML(tag)}</span>`)
+                .join('');
+
             row.innerHTML = `
                 <td><a href="${url.short_url}" class="url-link" target="_blank">${url.short_code}</a></td>
-                <td><div class="original-url" title="${url.original_url}">${url.original_url}</div></td>
+                <td>${title}<div class="original-url" title="${url.original_url}">${url.original_url}</div></td>
+                <td>${folder}${tags}</td>
                 <td><span class="clicks-badge">${url.clicks_count}</span></td>
                 <td class="date">${createdDate}</td>
+                <td><button class="btn-live" onclick="toggleLive(this, ${url.id})">● Live</button></td>
             `;
 
+            row.querySelectorAll('.tag-badge').forEach(badge => {
+                badge.addEventListener('click', () => filterByTag(badge.dataset.tag));
+            });
+
             tableBody.appendChild(row);
         });
 
@@ -55,7 +100,7 @@ async function loadURLs() {
 
         // Управление кнопками пагинации
         prevBtn.disabled = currentPage === 0;
-        nextBtn.disabled = urls.length < pageSize;
+        nextBtn.disabled = nextCursor === '';
 
     } catch (err) {
         loading.style.display = 'none';
@@ -64,6 +109,90 @@ async function loadURLs() {
     }
 }
 
+// showEmptyState показывает пустой список: без фильтров - приглашение создать ссылку
+function showEmptyState() {
+    const filtered = [...filters.keys()].some(name => name !== 'sort');
+    document.getElementById('emptyTitle').textContent = filtered
+        ? 'Ничего не найдено'
+        : 'Пока нет созданных ссылок';
+    document.getElementById('emptyHint').textContent = filtered
+        ? 'Измените условия поиска или сбросьте фильтры'
+        : 'Создайте первую ссылку на главной странице';
+    document.getElementById('emptyState').style.display = 'block';
+    document.getElementById('totalUrls').textContent = '0';
+    document.getElementById('totalClicks').textContent = '0';
+}
+
+// applyFilters читает форму фильтров и загружает первую страницу
+function applyFilters(event) {
+    if (event) {
+        event.preventDefault();
+    }
+
+    filters = new URLSearchParams();
+    const form = new FormData(document.getElementById('filters'));
+    for (const [name, value] of form.entries()) {
+        if (value.trim() !== '') {
+            filters.set(name, value.trim());
+        }
+    }
+
+    currentPage = 0;
+    cursors = [''];
+    loadURLs();
+}
+
+function resetFilters() {
+    document.getElementById('filters').reset();
+    applyFilters();
+}
+
+// filterByTag показывает ссылки с тегом, по которому кликнули в таблице
+function filterByTag(tag) {
+    const select = document.getElementById('tagFilter');
+    if (![...select.options].some(option => option.value === tag)) {
+        select.add(new Option(`#${tag}`, tag));
+    }
+    select.value = tag;
+    applyFilters();
+}
+
+// loadGroups заполняет списки тегов и папок для фильтров
+async function loadGroups() {
+    const groups = [
+        ['/api/v1/tags', 'tagFilter', name => `#${name}`],
+        ['/api/v1/folders', 'folderFilter', name => name],
+    ];
+
+    for (const [endpoint, selectId, label] of groups) {
+        try {
+            const response = await fetch(endpoint);
+            if (!response.ok) {
+                continue;
+            }
+            const select = document.getElementById(selectId);
+            (await response.json()).forEach(group => {
+                const option = document.createElement('option');
+                option.value = group.name;
+                option.textContent = `${label(group.name)} (${group.count})`;
+                select.appendChild(option);
+            });
+        } catch (err) {
+            // Без списка фильтр остается со значением "все"
+        }
+    }
+}
+
+// escapeHTML экранирует пользовательский текст для вставки в разметку
+function escapeHTML(value) {
+    return String(value)
+        .replace(/&/g, '&amp;')
+        .replace(/</g, '&lt;')
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;')
+        .replace(/'/g, '&#39;');
+}
+
 function updateStats(urls) {
     const totalUrls = document.getElementById('totalUrls');
     const totalClicks = document.getElementById('totalClicks');
@@ -71,15 +200,61 @@ function updateStats(urls) {
     // Текущая страница
     const startIndex = currentPage * pageSize + 1;
     const endIndex = currentPage * pageSize + urls.length;
-    totalUrls.textContent = `${startIndex}-${endIndex}`;
+    totalUrls.textContent = `${startIndex}-${endIndex} из ${totalCount}`;
 
     // Сумма кликов на текущей странице
     const clicks = urls.reduce((sum, url) => sum + url.clicks_count, 0);
     totalClicks.textContent = clicks;
 }
 
+// toggleLive включает или выключает отображение кликов ссылки в реальном времени
+function toggleLive(button, urlId) {
+    const wasActive = button === liveButton;
+    stopLive();
+    if (wasActive) {
+        return;
+    }
+
+    const badge = button.closest('tr').querySelector('.clicks-badge');
+    const totalClicks = document.getElementById('totalClicks');
+
+    liveSource = new EventSource(`/api/v1/urls/${urlId}/events`);
+    liveButton = button;
+    button.classList.add('active');
+
+    liveSource.addEventListener('click', (event) => {
+        const click = JSON.parse(event.data);
+        // Клики ботов не входят в счетчик
+        if (click.is_bot) {
+            return;
+        }
+
+        badge.textContent = Number(badge.textContent) + 1;
+        totalClicks.textContent = Number(totalClicks.textContent) + 1;
+
+        badge.classList.remove('flash');
+        void badge.offsetWidth;
+        badge.classList.add('flash');
+    });
+}
+
+function stopLive() {
+    if (liveSource) {
+        liveSource.close();
+        liveSource = null;
+    }
+    if (liveButton) {
+        liveButton.classList.remove('active');
+        liveButton = null;
+    }
+}
+
 function nextPage() {
+    if (nextCursor === '') {
+        return;
+    }
     currentPage++;
+    cursors[currentPage] = nextCursor;
     loadURLs();
 }
 
@@ -91,4 +266,7 @@ function prevPage() {
 }
 
 // Загружаем данные при загрузке страницы
-document.addEventListener('DOMContentLoaded', loadURLs);
+document.addEventListener('DOMContentLoaded', () => {
+    loadGroups();
+    loadURLs();
+});
@@ -0,0 +1,65 @@
+const loginForm = document.getElementById('loginForm');
+const registerBtn = document.getElementById('registerBtn');
+const error = document.getElementById('error');
+
+// Страница, на которую вернемся после входа
+const nextPage = new URLSearchParams(window.location.search).get('next') || '/links';
+
+async function postJSON(url, payload) {
+    const response = await fetch(url, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(payload)
+    });
+
+    const data = await response.json();
+
+    if (!response.ok) {
+        throw new Error(data.error || 'Ошибка запроса');
+    }
+
+    return data;
+}
+
+function credentials() {
+    return {
+        email: document.getElementById('email').value,
+        password: document.getElementById('password').value,
+    };
+}
+
+async function login() {
+    // Сервер выставляет cookie сессии, токен в ответе нужен только API клиентам
+    await postJSON('/api/v1/auth/login', credentials());
+    window.location.href = nextPage.startsWith('/') ? nextPage : '/links';
+}
+
+loginForm.addEventListener('submit', async (e) => {
+    e.preventDefault();
+    error.classList.remove('show');
+
+    try {
+        await login();
+    } catch (err) {
+        error.textContent = err.message;
+        error.classList.add('show');
+    }
+});
+
+registerBtn.addEventListener('click', async () => {
+    error.classList.remove('show');
+
+    if (!loginForm.reportValidity()) {
+        return;
+    }
+
+    try {
+        await postJSON('/api/v1/auth/register', credentials());
+        await login();
+    } catch (err) {
+        error.textContent = err.message;
+        error.classList.add('show');
+    }
+});